@@ -0,0 +1,106 @@
+// Package gbjwt provides signing and verification of JSON Web Tokens,
+// supporting algorithms HS256, RS256, ES256 and EdDSA, and JSON Web Key Sets with key rotation.
+package gbjwt
+
+import (
+	"encoding/base64"
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	"ghostbb.io/gb/internal/json"
+	"strings"
+)
+
+// Algorithm is the signing algorithm of token.
+type Algorithm string
+
+// Supported signing algorithms.
+const (
+	HS256 Algorithm = "HS256" // HMAC using SHA-256, using []byte secret.
+	RS256 Algorithm = "RS256" // RSASSA-PKCS1-v1_5 using SHA-256, using *rsa.PrivateKey and *rsa.PublicKey.
+	ES256 Algorithm = "ES256" // ECDSA using P-256 and SHA-256, using *ecdsa.PrivateKey and *ecdsa.PublicKey.
+	EdDSA Algorithm = "EdDSA" // EdDSA using Ed25519, using ed25519.PrivateKey and ed25519.PublicKey.
+)
+
+// Key is a key for signing or verifying tokens.
+type Key struct {
+	Id        string      // Id is the key id, which is the "kid" header of token.
+	Algorithm Algorithm   // Algorithm is the signing algorithm, which is inferred from Key if empty.
+	Key       interface{} // Key is the secret, private key or public key according to Algorithm.
+}
+
+// Header is the header of token.
+type Header struct {
+	Algorithm Algorithm `json:"alg"`
+	Type      string    `json:"typ,omitempty"`
+	KeyId     string    `json:"kid,omitempty"`
+}
+
+// Token is a verified token.
+type Token struct {
+	Raw    string           // Raw is the raw token string.
+	Header Header           // Header is the decoded header.
+	Claims RegisteredClaims // Claims is the registered claims of token.
+}
+
+// Sign signs `claims` with `key` and returns the token string.
+// The parameter `claims` is usually RegisteredClaims, MapClaims or a struct embedding RegisteredClaims,
+// which is encoded as JSON.
+func Sign(claims interface{}, key Key) (string, error) {
+	alg := key.Algorithm
+	if alg == "" {
+		alg = inferAlgorithm(key.Key)
+	}
+	header, err := json.Marshal(Header{Algorithm: alg, Type: "JWT", KeyId: key.Id})
+	if err != nil {
+		return "", gberror.Wrap(err, `marshal token header failed`)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", gberror.Wrap(err, `marshal token claims failed`)
+	}
+	input := encodeSegment(header) + "." + encodeSegment(payload)
+	signature, err := sign(alg, key.Key, []byte(input))
+	if err != nil {
+		return "", err
+	}
+	return input + "." + encodeSegment(signature), nil
+}
+
+// splitToken splits `token` into decoded header, payload, signature and the signing input.
+func splitToken(token string) (header Header, payload, signature []byte, input string, err error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		err = gberror.NewCode(gbcode.CodeNotAuthorized, `malformed token`)
+		return
+	}
+	headerBytes, err := decodeSegment(parts[0])
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(headerBytes, &header); err != nil {
+		err = gberror.WrapCode(gbcode.CodeNotAuthorized, err, `malformed token header`)
+		return
+	}
+	if payload, err = decodeSegment(parts[1]); err != nil {
+		return
+	}
+	if signature, err = decodeSegment(parts[2]); err != nil {
+		return
+	}
+	input = parts[0] + "." + parts[1]
+	return
+}
+
+// encodeSegment encodes `data` using unpadded base64url encoding.
+func encodeSegment(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeSegment decodes the unpadded base64url encoded segment of token.
+func decodeSegment(segment string) ([]byte, error) {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return nil, gberror.WrapCode(gbcode.CodeNotAuthorized, err, `malformed token segment`)
+	}
+	return data, nil
+}
@@ -0,0 +1,170 @@
+package gbjwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	"math/big"
+)
+
+const (
+	es256KeySize = 32 // Byte size of the r and s of ES256 signature.
+)
+
+// inferAlgorithm infers the signing algorithm from the type of `key`.
+// It returns empty string if the key type is not supported.
+func inferAlgorithm(key interface{}) Algorithm {
+	switch k := key.(type) {
+	case []byte, string:
+		return HS256
+	case *rsa.PrivateKey, *rsa.PublicKey:
+		return RS256
+	case *ecdsa.PrivateKey:
+		if k.Curve == elliptic.P256() {
+			return ES256
+		}
+	case *ecdsa.PublicKey:
+		if k.Curve == elliptic.P256() {
+			return ES256
+		}
+	case ed25519.PrivateKey, ed25519.PublicKey:
+		return EdDSA
+	}
+	return ""
+}
+
+// sign signs `input` using algorithm `alg` and private key or secret `key`.
+func sign(alg Algorithm, key interface{}, input []byte) ([]byte, error) {
+	switch alg {
+	case HS256:
+		secret, ok := hmacSecret(key)
+		if !ok {
+			break
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(input)
+		return mac.Sum(nil), nil
+
+	case RS256:
+		privateKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			break
+		}
+		hash := sha256.Sum256(input)
+		signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, hash[:])
+		if err != nil {
+			return nil, gberror.Wrap(err, `sign token using RS256 failed`)
+		}
+		return signature, nil
+
+	case ES256:
+		privateKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok || privateKey.Curve != elliptic.P256() {
+			break
+		}
+		hash := sha256.Sum256(input)
+		r, s, err := ecdsa.Sign(rand.Reader, privateKey, hash[:])
+		if err != nil {
+			return nil, gberror.Wrap(err, `sign token using ES256 failed`)
+		}
+		// The signature is the concatenated fixed size r and s, but not ASN.1 DER encoded.
+		signature := make([]byte, 2*es256KeySize)
+		r.FillBytes(signature[:es256KeySize])
+		s.FillBytes(signature[es256KeySize:])
+		return signature, nil
+
+	case EdDSA:
+		privateKey, ok := key.(ed25519.PrivateKey)
+		if !ok || len(privateKey) != ed25519.PrivateKeySize {
+			break
+		}
+		return ed25519.Sign(privateKey, input), nil
+
+	default:
+		return nil, gberror.NewCodef(gbcode.CodeNotSupported, `unsupported algorithm "%s"`, alg)
+	}
+	return nil, gberror.NewCodef(gbcode.CodeInvalidParameter, `invalid key type %T for algorithm "%s"`, key, alg)
+}
+
+// verify verifies `signature` of `input` using algorithm `alg` and public key or secret `key`.
+// The private key is also accepted, whose public key is used.
+func verify(alg Algorithm, key interface{}, input, signature []byte) bool {
+	switch alg {
+	case HS256:
+		secret, ok := hmacSecret(key)
+		if !ok {
+			return false
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(input)
+		return hmac.Equal(signature, mac.Sum(nil))
+
+	case RS256:
+		var publicKey *rsa.PublicKey
+		switch k := key.(type) {
+		case *rsa.PublicKey:
+			publicKey = k
+		case *rsa.PrivateKey:
+			publicKey = &k.PublicKey
+		default:
+			return false
+		}
+		hash := sha256.Sum256(input)
+		return rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hash[:], signature) == nil
+
+	case ES256:
+		var publicKey *ecdsa.PublicKey
+		switch k := key.(type) {
+		case *ecdsa.PublicKey:
+			publicKey = k
+		case *ecdsa.PrivateKey:
+			publicKey = &k.PublicKey
+		default:
+			return false
+		}
+		if publicKey.Curve != elliptic.P256() || len(signature) != 2*es256KeySize {
+			return false
+		}
+		var (
+			hash = sha256.Sum256(input)
+			r    = new(big.Int).SetBytes(signature[:es256KeySize])
+			s    = new(big.Int).SetBytes(signature[es256KeySize:])
+		)
+		return ecdsa.Verify(publicKey, hash[:], r, s)
+
+	case EdDSA:
+		var publicKey ed25519.PublicKey
+		switch k := key.(type) {
+		case ed25519.PublicKey:
+			publicKey = k
+		case ed25519.PrivateKey:
+			publicKey, _ = k.Public().(ed25519.PublicKey)
+		default:
+			return false
+		}
+		if len(publicKey) != ed25519.PublicKeySize {
+			return false
+		}
+		return ed25519.Verify(publicKey, input, signature)
+	}
+	return false
+}
+
+// hmacSecret returns the HMAC secret of `key`, which should not be empty.
+func hmacSecret(key interface{}) ([]byte, bool) {
+	var secret []byte
+	switch k := key.(type) {
+	case []byte:
+		secret = k
+	case string:
+		secret = []byte(k)
+	}
+	return secret, len(secret) > 0
+}
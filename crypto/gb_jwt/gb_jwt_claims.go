@@ -0,0 +1,107 @@
+package gbjwt
+
+import (
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	"ghostbb.io/gb/internal/json"
+	"math"
+	"strconv"
+	"time"
+)
+
+// RegisteredClaims is the registered claims of token, which is usually embedded in custom claims struct.
+type RegisteredClaims struct {
+	Issuer    string      `json:"iss,omitempty"` // Issuer identifies the principal that issued the token.
+	Subject   string      `json:"sub,omitempty"` // Subject identifies the principal that is the subject of the token.
+	Audience  Audience    `json:"aud,omitempty"` // Audience identifies the recipients that the token is intended for.
+	ExpiresAt NumericDate `json:"exp,omitempty"` // ExpiresAt is the expiration time, after which the token is not accepted.
+	NotBefore NumericDate `json:"nbf,omitempty"` // NotBefore is the time before which the token is not accepted.
+	IssuedAt  NumericDate `json:"iat,omitempty"` // IssuedAt is the time at which the token was issued.
+	Id        string      `json:"jti,omitempty"` // Id is the unique identifier of the token.
+}
+
+// MapClaims is the claims of token in map, which is used if the claims struct is not defined.
+type MapClaims map[string]interface{}
+
+// Audience is the "aud" claim, which is a single string or an array of strings in JSON.
+type Audience []string
+
+// NumericDate is the seconds since the epoch, which is used by the time claims.
+type NumericDate int64
+
+// NewNumericDate creates and returns a NumericDate of time `t`.
+func NewNumericDate(t time.Time) NumericDate {
+	return NumericDate(t.Unix())
+}
+
+// Time returns the NumericDate as time.Time.
+func (d NumericDate) Time() time.Time {
+	return time.Unix(int64(d), 0)
+}
+
+// UnmarshalJSON implements the interface json.Unmarshaler,
+// which accepts the fractional seconds and truncates them.
+func (d *NumericDate) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(string(b), 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return gberror.NewCodef(gbcode.CodeNotAuthorized, `invalid numeric date "%s"`, b)
+	}
+	*d = NumericDate(f)
+	return nil
+}
+
+// MarshalJSON implements the interface json.Marshaler,
+// which marshals the single audience as string.
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+// UnmarshalJSON implements the interface json.Unmarshaler,
+// which accepts either a string or an array of strings.
+func (a *Audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(b, &multiple); err != nil {
+		return gberror.WrapCode(gbcode.CodeNotAuthorized, err, `invalid audience`)
+	}
+	*a = multiple
+	return nil
+}
+
+// Contains checks whether `audience` is in the Audience.
+func (a Audience) Contains(audience string) bool {
+	for _, v := range a {
+		if v == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// validate validates the time claims with `now` and `leeway`,
+// and checks the issuer and audience if `issuer` and `audience` are not empty.
+func (c *RegisteredClaims) validate(now time.Time, leeway time.Duration, issuer, audience string) error {
+	if c.ExpiresAt != 0 && now.After(c.ExpiresAt.Time().Add(leeway)) {
+		return gberror.NewCode(gbcode.CodeNotAuthorized, `token is expired`)
+	}
+	if c.NotBefore != 0 && now.Add(leeway).Before(c.NotBefore.Time()) {
+		return gberror.NewCode(gbcode.CodeNotAuthorized, `token is not valid yet`)
+	}
+	if issuer != "" && c.Issuer != issuer {
+		return gberror.NewCodef(gbcode.CodeNotAuthorized, `invalid token issuer "%s"`, c.Issuer)
+	}
+	if audience != "" && !c.Audience.Contains(audience) {
+		return gberror.NewCode(gbcode.CodeNotAuthorized, `token audience does not match`)
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+package gbjwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	"ghostbb.io/gb/internal/intlog"
+	"ghostbb.io/gb/internal/json"
+	gbfile "ghostbb.io/gb/os/gb_file"
+	gbres "ghostbb.io/gb/os/gb_res"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultJWKSRefreshInterval    = time.Hour        // Default interval of refreshing key set.
+	defaultJWKSMinRefreshInterval = time.Minute      // Default min interval of refreshing key set for unknown key id.
+	defaultJWKSTimeout            = 10 * time.Second // Default timeout of fetching key set from URL.
+	maxJWKSSize                   = 1 << 20          // Max size of key set document.
+)
+
+// JWKSOption is the option for JWKS.
+type JWKSOption struct {
+	// RefreshInterval specifies the interval of refreshing the key set, which is one hour in default.
+	RefreshInterval time.Duration
+	// MinRefreshInterval specifies the min interval of refreshing the key set when the key id of token
+	// is not found, which is one minute in default. It limits the refreshing caused by forged tokens.
+	MinRefreshInterval time.Duration
+	// Client specifies the http client for fetching key set from URL.
+	Client *http.Client
+}
+
+// JWKS is the KeySet of JSON Web Key Set loaded from file or URL,
+// which is refreshed periodically and when the key id of token is not found,
+// so that the rotated keys are used without restarting.
+type JWKS struct {
+	mu          sync.Mutex
+	source      string // File path or URL of key set.
+	option      JWKSOption
+	keys        []Key            // Keys of last successful loading.
+	loadedAt    time.Time        // Time of last successful loading.
+	attemptedAt time.Time        // Time of last loading attempt.
+	refreshing  *jwksRefreshCall // In-flight loading shared by concurrent callers, which is nil if not loading.
+}
+
+// jwksRefreshCall is the in-flight loading of key set.
+type jwksRefreshCall struct {
+	done chan struct{} // Closed when the loading is done.
+	err  error         // Error of the loading, which is set before done is closed.
+}
+
+// jsonWebKey is the JSON Web Key defined in RFC 7517.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// NewJWKS creates and returns a JWKS loaded from `source`, which is URL starting with "http://"
+// or "https://", or else file path which is searched on disk first and then in gbres.
+// It returns error if the key set fails loading for the first time.
+func NewJWKS(source string, option ...JWKSOption) (*JWKS, error) {
+	var o JWKSOption
+	if len(option) > 0 {
+		o = option[0]
+	}
+	if o.RefreshInterval <= 0 {
+		o.RefreshInterval = defaultJWKSRefreshInterval
+	}
+	if o.MinRefreshInterval <= 0 {
+		o.MinRefreshInterval = defaultJWKSMinRefreshInterval
+	}
+	if o.Client == nil {
+		o.Client = &http.Client{Timeout: defaultJWKSTimeout}
+	}
+	jwks := &JWKS{
+		source: source,
+		option: o,
+	}
+	if err := jwks.Refresh(context.Background()); err != nil {
+		return nil, err
+	}
+	return jwks, nil
+}
+
+// Keys implements the interface KeySet.
+// It refreshes the key set in background if the refresh interval is reached, using the current keys meanwhile,
+// and refreshes the key set and waits for it if the key id `kid` is not found.
+// The previous keys are kept if refreshing fails.
+func (j *JWKS) Keys(ctx context.Context, kid string) ([]Key, error) {
+	j.mu.Lock()
+	var (
+		keys       = matchKeys(j.keys, kid)
+		canRefresh = j.refreshing == nil && time.Since(j.attemptedAt) >= j.option.MinRefreshInterval
+		expired    = time.Since(j.loadedAt) >= j.option.RefreshInterval
+	)
+	j.mu.Unlock()
+	if len(keys) > 0 {
+		if expired && canRefresh {
+			go func() {
+				_ = j.refresh(context.WithoutCancel(ctx), false)
+			}()
+		}
+		return keys, nil
+	}
+	if err := j.refresh(ctx, false); err == nil {
+		j.mu.Lock()
+		keys = matchKeys(j.keys, kid)
+		j.mu.Unlock()
+	}
+	if len(keys) == 0 {
+		return nil, gberror.NewCodef(gbcode.CodeNotAuthorized, `no key found for key id "%s"`, kid)
+	}
+	return keys, nil
+}
+
+// Refresh reloads the key set immediately.
+func (j *JWKS) Refresh(ctx context.Context) error {
+	return j.refresh(ctx, true)
+}
+
+// refresh reloads the key set without holding the lock during loading, so that the current keys are
+// still available meanwhile. The concurrent callers wait for and share the result of the same loading.
+// It does nothing if `force` is false and the loading was attempted within MinRefreshInterval.
+func (j *JWKS) refresh(ctx context.Context, force bool) error {
+	j.mu.Lock()
+	if call := j.refreshing; call != nil {
+		j.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if !force && time.Since(j.attemptedAt) < j.option.MinRefreshInterval {
+		j.mu.Unlock()
+		return nil
+	}
+	call := &jwksRefreshCall{done: make(chan struct{})}
+	j.refreshing = call
+	j.attemptedAt = time.Now()
+	j.mu.Unlock()
+
+	// The loading is shared, so it is not canceled with the context of the caller starting it.
+	var keys []Key
+	content, err := j.fetch(context.WithoutCancel(ctx))
+	if err == nil {
+		keys, err = ParseJWKS(content)
+	}
+	j.mu.Lock()
+	if err == nil {
+		j.keys = keys
+		j.loadedAt = time.Now()
+	}
+	j.refreshing = nil
+	j.mu.Unlock()
+	call.err = err
+	close(call.done)
+	if err != nil {
+		intlog.Errorf(ctx, `refresh key set from "%s" failed: %+v`, j.source, err)
+	}
+	return err
+}
+
+// fetch reads the key set document from file or URL.
+func (j *JWKS) fetch(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(j.source, "http://") && !strings.HasPrefix(j.source, "https://") {
+		if realPath, _ := gbfile.Search(j.source); realPath != "" {
+			return gbfile.GetBytes(realPath), nil
+		}
+		if gbres.Contains(j.source) {
+			return gbres.GetContent(j.source), nil
+		}
+		return nil, gberror.NewCodef(gbcode.CodeInvalidParameter, `key set file "%s" does not exist`, j.source)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, j.source, nil)
+	if err != nil {
+		return nil, gberror.Wrapf(err, `create request for key set "%s" failed`, j.source)
+	}
+	response, err := j.option.Client.Do(request)
+	if err != nil {
+		return nil, gberror.Wrapf(err, `fetch key set "%s" failed`, j.source)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, gberror.NewCodef(
+			gbcode.CodeOperationFailed, `fetch key set "%s" failed with status %d`, j.source, response.StatusCode,
+		)
+	}
+	content, err := io.ReadAll(io.LimitReader(response.Body, maxJWKSSize))
+	if err != nil {
+		return nil, gberror.Wrapf(err, `read key set "%s" failed`, j.source)
+	}
+	return content, nil
+}
+
+// ParseJWKS parses the JSON Web Key Set document `content` and returns the signing keys.
+// The keys for encryption and of unsupported types are ignored.
+func ParseJWKS(content []byte) ([]Key, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(content, &set); err != nil {
+		return nil, gberror.WrapCode(gbcode.CodeInvalidParameter, err, `invalid key set`)
+	}
+	keys := make([]Key, 0, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.toKey()
+		if err != nil {
+			intlog.Errorf(context.TODO(), `ignore key "%s": %+v`, jwk.Kid, err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// toKey converts the JSON Web Key to Key.
+func (jwk jsonWebKey) toKey() (Key, error) {
+	key := Key{Id: jwk.Kid, Algorithm: Algorithm(jwk.Alg)}
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return key, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return key, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return key, gberror.NewCode(gbcode.CodeInvalidParameter, `invalid RSA exponent`)
+		}
+		key.Key = &rsa.PublicKey{N: n, E: int(e.Int64())}
+
+	case "EC":
+		if jwk.Crv != "P-256" {
+			return key, gberror.NewCodef(gbcode.CodeNotSupported, `unsupported curve "%s"`, jwk.Crv)
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return key, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return key, err
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return key, gberror.NewCode(gbcode.CodeInvalidParameter, `invalid EC public key`)
+		}
+		key.Key = &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return key, gberror.NewCodef(gbcode.CodeNotSupported, `unsupported curve "%s"`, jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return key, gberror.NewCode(gbcode.CodeInvalidParameter, `invalid Ed25519 public key`)
+		}
+		key.Key = ed25519.PublicKey(x)
+
+	case "oct":
+		k, err := base64.RawURLEncoding.DecodeString(jwk.K)
+		if err != nil || len(k) == 0 {
+			return key, gberror.NewCode(gbcode.CodeInvalidParameter, `invalid symmetric key`)
+		}
+		key.Key = k
+
+	default:
+		return key, gberror.NewCodef(gbcode.CodeNotSupported, `unsupported key type "%s"`, jwk.Kty)
+	}
+	if key.Algorithm == "" {
+		key.Algorithm = inferAlgorithm(key.Key)
+	}
+	return key, nil
+}
+
+// decodeBigInt decodes the base64url encoded big-endian integer.
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, gberror.NewCodef(gbcode.CodeInvalidParameter, `invalid key parameter "%s"`, s)
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,125 @@
+package gbjwt
+
+import (
+	"context"
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	"ghostbb.io/gb/internal/json"
+	"time"
+)
+
+// KeySet provides the keys for verifying tokens.
+type KeySet interface {
+	// Keys returns the candidate keys for the key id `kid`, which is the "kid" header of token.
+	Keys(ctx context.Context, kid string) ([]Key, error)
+}
+
+// VerifierConfig is the configuration for Verifier.
+type VerifierConfig struct {
+	Keys       KeySet        // Keys provides the keys for verifying tokens, which is required.
+	Issuer     string        // Issuer specifies the required "iss" claim if it is not empty.
+	Audience   string        // Audience specifies the required "aud" claim if it is not empty.
+	Leeway     time.Duration // Leeway specifies the allowed clock skew for checking "exp" and "nbf" claims.
+	Algorithms []Algorithm   // Algorithms specifies the accepted algorithms, which are all supported ones if empty.
+}
+
+// Verifier verifies tokens and their registered claims.
+type Verifier struct {
+	config VerifierConfig
+}
+
+// staticKeySet is the KeySet of static keys.
+type staticKeySet []Key
+
+// NewKeySet creates and returns a KeySet of static `keys`.
+// The key without id is the candidate key for any token.
+func NewKeySet(keys ...Key) KeySet {
+	return staticKeySet(keys)
+}
+
+// Keys implements the interface KeySet.
+func (s staticKeySet) Keys(ctx context.Context, kid string) ([]Key, error) {
+	return matchKeys(s, kid), nil
+}
+
+// NewVerifier creates and returns a new Verifier.
+func NewVerifier(config VerifierConfig) *Verifier {
+	return &Verifier{config: config}
+}
+
+// Verify verifies the signature and registered claims of `token`,
+// and decodes the claims into `claims` if it is not nil.
+// The parameter `claims` should be a pointer, like *MapClaims or pointer to custom claims struct.
+// The returned error is with code gbcode.CodeNotAuthorized if the token is invalid.
+func (v *Verifier) Verify(ctx context.Context, token string, claims interface{}) (*Token, error) {
+	if v.config.Keys == nil {
+		return nil, gberror.NewCode(gbcode.CodeMissingConfiguration, `no key set is configured for verifier`)
+	}
+	header, payload, signature, input, err := splitToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if !v.isAllowed(header.Algorithm) {
+		return nil, gberror.NewCodef(gbcode.CodeNotAuthorized, `token algorithm "%s" is not allowed`, header.Algorithm)
+	}
+	keys, err := v.config.Keys.Keys(ctx, header.KeyId)
+	if err != nil {
+		return nil, err
+	}
+	var verified bool
+	for _, key := range keys {
+		alg := key.Algorithm
+		if alg == "" {
+			alg = inferAlgorithm(key.Key)
+		}
+		// The algorithm of key must match the token, which prevents the algorithm confusion attack.
+		if alg == header.Algorithm && verify(alg, key.Key, []byte(input), signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, gberror.NewCode(gbcode.CodeNotAuthorized, `token signature is invalid`)
+	}
+	result := &Token{Raw: token, Header: header}
+	if err = json.Unmarshal(payload, &result.Claims); err != nil {
+		return nil, gberror.WrapCode(gbcode.CodeNotAuthorized, err, `malformed token claims`)
+	}
+	if err = result.Claims.validate(time.Now(), v.config.Leeway, v.config.Issuer, v.config.Audience); err != nil {
+		return nil, err
+	}
+	if claims != nil {
+		if err = json.UnmarshalUseNumber(payload, claims); err != nil {
+			return nil, gberror.WrapCode(gbcode.CodeNotAuthorized, err, `malformed token claims`)
+		}
+	}
+	return result, nil
+}
+
+// isAllowed checks whether the algorithm `alg` is accepted.
+func (v *Verifier) isAllowed(alg Algorithm) bool {
+	if len(v.config.Algorithms) == 0 {
+		switch alg {
+		case HS256, RS256, ES256, EdDSA:
+			return true
+		}
+		return false
+	}
+	for _, allowed := range v.config.Algorithms {
+		if allowed == alg {
+			return true
+		}
+	}
+	return false
+}
+
+// matchKeys returns the keys with id `kid` and the keys without id.
+func matchKeys(keys []Key, kid string) []Key {
+	var matched []Key
+	for _, key := range keys {
+		if key.Id == "" || key.Id == kid {
+			matched = append(matched, key)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,304 @@
+package gbjwt_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	gbjwt "ghostbb.io/gb/crypto/gb_jwt"
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	"ghostbb.io/gb/internal/json"
+	gbfile "ghostbb.io/gb/os/gb_file"
+	gbtest "ghostbb.io/gb/test/gb_test"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var (
+	ctx         = context.Background()
+	secret      = []byte("0123456789abcdef0123456789abcdef")
+	rsaKey, _   = rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _    = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ = ed25519.GenerateKey(rand.Reader)
+)
+
+type userClaims struct {
+	gbjwt.RegisteredClaims
+	Name  string   `json:"name"`
+	Roles []string `json:"roles"`
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// jwk returns the JSON Web Key of public key `key`.
+func jwk(kid string, key interface{}) map[string]string {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kty": "RSA", "kid": kid, "n": b64(k.N.Bytes()), "e": b64(big.NewInt(int64(k.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		return map[string]string{"kty": "EC", "kid": kid, "crv": "P-256", "x": b64(k.X.FillBytes(make([]byte, 32))), "y": b64(k.Y.FillBytes(make([]byte, 32)))}
+	case ed25519.PublicKey:
+		return map[string]string{"kty": "OKP", "kid": kid, "crv": "Ed25519", "x": b64(k)}
+	}
+	return nil
+}
+
+func jwks(t *gbtest.T, keys ...map[string]string) []byte {
+	content, err := json.Marshal(map[string]interface{}{"keys": keys})
+	t.AssertNil(err)
+	return content
+}
+
+func claims(subject string) gbjwt.RegisteredClaims {
+	return gbjwt.RegisteredClaims{
+		Issuer:    "issuer",
+		Subject:   subject,
+		Audience:  gbjwt.Audience{"api"},
+		ExpiresAt: gbjwt.NewNumericDate(time.Now().Add(time.Hour)),
+		IssuedAt:  gbjwt.NewNumericDate(time.Now()),
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		keys := []gbjwt.Key{
+			{Id: "hs", Key: secret},
+			{Id: "rs", Key: rsaKey},
+			{Id: "es", Key: ecKey},
+			{Id: "ed", Key: edKey},
+		}
+		verifier := gbjwt.NewVerifier(gbjwt.VerifierConfig{
+			Keys: gbjwt.NewKeySet(
+				gbjwt.Key{Id: "hs", Key: secret},
+				gbjwt.Key{Id: "rs", Key: &rsaKey.PublicKey},
+				gbjwt.Key{Id: "es", Key: &ecKey.PublicKey},
+				gbjwt.Key{Id: "ed", Key: edKey.Public()},
+			),
+			Issuer:   "issuer",
+			Audience: "api",
+		})
+		for _, key := range keys {
+			token, err := gbjwt.Sign(userClaims{RegisteredClaims: claims(key.Id), Name: "john", Roles: []string{"admin"}}, key)
+			t.AssertNil(err)
+
+			var c userClaims
+			result, err := verifier.Verify(ctx, token, &c)
+			t.AssertNil(err)
+			t.Assert(result.Claims.Subject, key.Id)
+			t.Assert(result.Header.KeyId, key.Id)
+			t.Assert(c.Subject, key.Id)
+			t.Assert(c.Name, "john")
+			t.Assert(c.Roles, []string{"admin"})
+
+			// Tampered payload.
+			parts := strings.Split(token, ".")
+			payload, _ := json.Marshal(claims("admin"))
+			_, err = verifier.Verify(ctx, parts[0]+"."+b64(payload)+"."+parts[2], nil)
+			t.Assert(gberror.Code(err), gbcode.CodeNotAuthorized)
+		}
+
+		var m gbjwt.MapClaims
+		token, err := gbjwt.Sign(claims("map"), gbjwt.Key{Id: "hs", Key: secret})
+		t.AssertNil(err)
+		_, err = verifier.Verify(ctx, token, &m)
+		t.AssertNil(err)
+		t.Assert(m["sub"], "map")
+		t.Assert(m["aud"], "api")
+
+		_, err = gbjwt.Sign(claims("x"), gbjwt.Key{Algorithm: gbjwt.RS256, Key: secret})
+		t.AssertNE(err, nil)
+	})
+}
+
+func TestVerify_Invalid(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		verifier := gbjwt.NewVerifier(gbjwt.VerifierConfig{
+			Keys:     gbjwt.NewKeySet(gbjwt.Key{Key: &rsaKey.PublicKey}),
+			Issuer:   "issuer",
+			Audience: "api",
+			Leeway:   time.Minute,
+		})
+		sign := func(c gbjwt.RegisteredClaims) string {
+			token, err := gbjwt.Sign(c, gbjwt.Key{Key: rsaKey})
+			t.AssertNil(err)
+			return token
+		}
+		verifyErr := func(token string) error {
+			_, err := verifier.Verify(ctx, token, nil)
+			return err
+		}
+		t.AssertNil(verifyErr(sign(claims("ok"))))
+
+		c := claims("expired")
+		c.ExpiresAt = gbjwt.NewNumericDate(time.Now().Add(-2 * time.Minute))
+		t.Assert(gberror.Code(verifyErr(sign(c))), gbcode.CodeNotAuthorized)
+		c.ExpiresAt = gbjwt.NewNumericDate(time.Now().Add(-30 * time.Second))
+		t.AssertNil(verifyErr(sign(c)))
+
+		c = claims("nbf")
+		c.NotBefore = gbjwt.NewNumericDate(time.Now().Add(2 * time.Minute))
+		t.AssertNE(verifyErr(sign(c)), nil)
+
+		c = claims("iss")
+		c.Issuer = "other"
+		t.AssertNE(verifyErr(sign(c)), nil)
+
+		c = claims("aud")
+		c.Audience = gbjwt.Audience{"web", "mobile"}
+		t.AssertNE(verifyErr(sign(c)), nil)
+		c.Audience = gbjwt.Audience{"web", "api"}
+		t.AssertNil(verifyErr(sign(c)))
+
+		// The algorithm "none" and the HS256 token signed with public key are rejected.
+		header := b64([]byte(`{"alg":"none","typ":"JWT"}`))
+		payload, _ := json.Marshal(claims("none"))
+		t.AssertNE(verifyErr(header+"."+b64(payload)+"."), nil)
+
+		publicKey := jwk("", &rsaKey.PublicKey)["n"]
+		token, err := gbjwt.Sign(claims("confusion"), gbjwt.Key{Key: []byte(publicKey)})
+		t.AssertNil(err)
+		t.AssertNE(verifyErr(token), nil)
+
+		t.AssertNE(verifyErr("invalid"), nil)
+		t.AssertNE(verifyErr("a.b.c"), nil)
+	})
+}
+
+func TestJWKS_File(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		var (
+			file      = gbfile.Temp("gbjwt", fmt.Sprintf("jwks-%d.json", time.Now().UnixNano()))
+			newKey, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		)
+		defer gbfile.Remove(file)
+		t.AssertNil(gbfile.PutBytes(file, jwks(t, jwk("rs", &rsaKey.PublicKey), jwk("ed", edKey.Public()))))
+
+		set, err := gbjwt.NewJWKS(file, gbjwt.JWKSOption{MinRefreshInterval: time.Millisecond})
+		t.AssertNil(err)
+		verifier := gbjwt.NewVerifier(gbjwt.VerifierConfig{Keys: set})
+
+		for _, key := range []gbjwt.Key{{Id: "rs", Key: rsaKey}, {Id: "ed", Key: edKey}} {
+			token, err := gbjwt.Sign(claims(key.Id), key)
+			t.AssertNil(err)
+			_, err = verifier.Verify(ctx, token, nil)
+			t.AssertNil(err)
+		}
+
+		// The rotated key is loaded when its key id is not found.
+		token, err := gbjwt.Sign(claims("new"), gbjwt.Key{Id: "new", Key: newKey})
+		t.AssertNil(err)
+		_, err = verifier.Verify(ctx, token, nil)
+		t.AssertNE(err, nil)
+		t.AssertNil(gbfile.PutBytes(file, jwks(t, jwk("new", &newKey.PublicKey))))
+		time.Sleep(time.Millisecond)
+		_, err = verifier.Verify(ctx, token, nil)
+		t.AssertNil(err)
+
+		_, err = gbjwt.NewJWKS(file + ".none")
+		t.AssertNE(err, nil)
+	})
+}
+
+func TestJWKS_URL(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		var (
+			requests int32
+			content  atomic.Value
+		)
+		content.Store(jwks(t, jwk("es", &ecKey.PublicKey)))
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			_, _ = w.Write(content.Load().([]byte))
+		}))
+		defer server.Close()
+
+		set, err := gbjwt.NewJWKS(server.URL, gbjwt.JWKSOption{MinRefreshInterval: time.Hour})
+		t.AssertNil(err)
+		verifier := gbjwt.NewVerifier(gbjwt.VerifierConfig{Keys: set, Algorithms: []gbjwt.Algorithm{gbjwt.ES256}})
+
+		token, err := gbjwt.Sign(claims("es"), gbjwt.Key{Id: "es", Key: ecKey})
+		t.AssertNil(err)
+		_, err = verifier.Verify(ctx, token, nil)
+		t.AssertNil(err)
+
+		// The unknown key id does not refresh the key set within MinRefreshInterval.
+		token, err = gbjwt.Sign(claims("ed"), gbjwt.Key{Id: "ed", Key: edKey})
+		t.AssertNil(err)
+		_, err = verifier.Verify(ctx, token, nil)
+		t.AssertNE(err, nil)
+		t.Assert(atomic.LoadInt32(&requests), 1)
+
+		// The key set is refreshed manually, and the algorithm is still not allowed.
+		content.Store(jwks(t, jwk("ed", edKey.Public())))
+		t.AssertNil(set.Refresh(ctx))
+		t.Assert(atomic.LoadInt32(&requests), 2)
+		_, err = verifier.Verify(ctx, token, nil)
+		t.AssertNE(err, nil)
+		_, err = gbjwt.NewVerifier(gbjwt.VerifierConfig{Keys: set}).Verify(ctx, token, nil)
+		t.AssertNil(err)
+	})
+}
+
+func TestJWKS_RefreshNotBlocking(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		var (
+			requests int32
+			slow     atomic.Bool
+		)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			if slow.Load() {
+				time.Sleep(500 * time.Millisecond)
+			}
+			_, _ = w.Write(jwks(t, jwk("es", &ecKey.PublicKey)))
+		}))
+		defer server.Close()
+
+		set, err := gbjwt.NewJWKS(server.URL, gbjwt.JWKSOption{MinRefreshInterval: time.Millisecond})
+		t.AssertNil(err)
+		slow.Store(true)
+		done := make(chan error, 2)
+		go func() { done <- set.Refresh(ctx) }()
+		go func() { done <- set.Refresh(ctx) }()
+		time.Sleep(50 * time.Millisecond)
+
+		// The current keys are used while refreshing.
+		start := time.Now()
+		keys, err := set.Keys(ctx, "es")
+		t.AssertNil(err)
+		t.Assert(len(keys), 1)
+		t.Assert(time.Since(start) < 100*time.Millisecond, true)
+
+		// The concurrent refreshing shares the same loading.
+		t.AssertNil(<-done)
+		t.AssertNil(<-done)
+		t.Assert(atomic.LoadInt32(&requests), 2)
+	})
+}
+
+func TestAudience(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		var c gbjwt.RegisteredClaims
+		t.AssertNil(json.Unmarshal([]byte(`{"aud":"a","exp":1700000000.5}`), &c))
+		t.Assert(c.Audience, gbjwt.Audience{"a"})
+		t.Assert(c.ExpiresAt, 1700000000)
+		t.AssertNil(json.Unmarshal([]byte(`{"aud":["a","b"]}`), &c))
+		t.Assert(c.Audience, gbjwt.Audience{"a", "b"})
+
+		b, err := json.Marshal(gbjwt.RegisteredClaims{Audience: gbjwt.Audience{"a"}})
+		t.AssertNil(err)
+		t.Assert(string(b), `{"aud":"a"}`)
+	})
+}
@@ -1,11 +1,13 @@
 package gbdb
 
 import (
+	"context"
 	gbmap "ghostbb.io/gb/container/gb_map"
 	gbcode "ghostbb.io/gb/errors/gb_code"
 	gberror "ghostbb.io/gb/errors/gb_error"
 	"ghostbb.io/gb/internal/intlog"
 	gbctx "ghostbb.io/gb/os/gb_ctx"
+	gbhealth "ghostbb.io/gb/os/gb_health"
 	"gorm.io/gorm"
 )
 
@@ -38,6 +40,7 @@ func NewDBByConfig(name string, config DatabaseConfig) (*DB, error) {
 		intlog.Printf(gbctx.New(), "%s | %s | database connection successful.", name, config.Type)
 		dbMap.Set(name, db)
 		db.DB = db.DB.Set("gb:database:name", name)
+		gbhealth.RegisterFunc(healthCheckerPrefix+name, db.Ping, gbhealth.ProbeReadiness)
 		return db, nil
 	}
 
@@ -64,4 +67,16 @@ const (
 	DefaultGroupName = "default" // Default group name.
 	defaultCharset   = `utf8`
 	defaultProtocol  = `tcp`
+
+	healthCheckerPrefix = "database." // Name prefix of the health checker for each database.
 )
+
+// Ping verifies the connection to the database is still alive.
+// It is registered as the readiness health checker of the database.
+func (db *DB) Ping(ctx context.Context) error {
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	gberror "ghostbb.io/gb/errors/gb_error"
 	"ghostbb.io/gb/internal/instance"
+	gbctx "ghostbb.io/gb/os/gb_ctx"
 	gblog "ghostbb.io/gb/os/gb_log"
 	gbstr "ghostbb.io/gb/text/gb_str"
 	"gorm.io/gorm"
@@ -94,6 +95,9 @@ func (d *dbLogger) Trace(ctx context.Context, begin time.Time, fc func() (string
 			d.instance,
 			sql,
 		)
+		if requestId := gbctx.RequestId(ctx); requestId != "" {
+			msg += " ｜ " + requestId
+		}
 		if err != nil && (d.RecordNotFoundErr || !gberror.Is(err, gorm.ErrRecordNotFound)) {
 			msg += "\n    " + err.Error() + "\n"
 		}
@@ -141,6 +145,8 @@ func (d *dbLogger) setLogger() *gblog.Logger {
 		logger.SetFile(d.AccessLogPattern)
 		logger.SetStdoutPrint(d.LogStdout)
 		logger.SetLevelPrint(false)
+		// The request id is always logged for correlating SQL logs with the request.
+		logger.AppendCtxKeys(gbctx.CtxKeyRequestId)
 		return logger
 	}).(*gblog.Logger)
 }
@@ -4,6 +4,11 @@ import (
 	"context"
 	gbmap "ghostbb.io/gb/container/gb_map"
 	"ghostbb.io/gb/internal/intlog"
+	gbhealth "ghostbb.io/gb/os/gb_health"
+)
+
+const (
+	healthCheckerPrefix = "redis." // Name prefix of the health checker for each redis instance.
 )
 
 var (
@@ -26,6 +31,7 @@ func Instance(name ...string) *Redis {
 				intlog.Errorf(context.TODO(), `%+v`, err)
 				return nil
 			}
+			gbhealth.RegisterFunc(healthCheckerPrefix+group, r.Ping, gbhealth.ProbeReadiness)
 			return r
 		}
 		return nil
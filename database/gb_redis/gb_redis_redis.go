@@ -102,6 +102,13 @@ func (r *Redis) Do(ctx context.Context, command string, args ...interface{}) (*g
 	return r.localAdapter.Do(ctx, command, args...)
 }
 
+// Ping sends command PING to the redis server to verify the connection is still alive.
+// It is registered as the readiness health checker of the redis instance.
+func (r *Redis) Ping(ctx context.Context) error {
+	_, err := r.Do(ctx, "PING")
+	return err
+}
+
 // MustConn performs as function Conn, but it panics if any error occurs internally.
 func (r *Redis) MustConn(ctx context.Context) Conn {
 	c, err := r.Conn(ctx)
@@ -13,6 +13,17 @@ func SetLanguage(language string) {
 	Instance().SetLanguage(language)
 }
 
+// GetLanguages returns the sorted names of languages loaded from i18n files.
+func GetLanguages() []string {
+	return Instance().GetLanguages()
+}
+
+// MatchLanguage returns the loaded language best matching the language tag `language`.
+// It returns an empty string if no loaded language matches.
+func MatchLanguage(language string) string {
+	return Instance().MatchLanguage(language)
+}
+
 // SetDelimiters sets the delimiters for translator.
 func SetDelimiters(left, right string) {
 	Instance().SetDelimiters(left, right)
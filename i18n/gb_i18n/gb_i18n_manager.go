@@ -12,6 +12,7 @@ import (
 	gbres "ghostbb.io/gb/os/gb_res"
 	gbregex "ghostbb.io/gb/text/gb_regex"
 	gbconv "ghostbb.io/gb/util/gb_conv"
+	"sort"
 	"strings"
 	"sync"
 )
@@ -141,6 +142,62 @@ func (m *Manager) SetLanguage(language string) {
 	intlog.Printf(context.TODO(), `SetLanguage: %s`, m.options.Language)
 }
 
+// GetLanguage returns the default language of translator.
+func (m *Manager) GetLanguage() string {
+	return m.options.Language
+}
+
+// GetLanguages returns the sorted names of languages loaded from i18n files.
+func (m *Manager) GetLanguages() []string {
+	m.init(context.TODO())
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	languages := make([]string, 0, len(m.data))
+	for language := range m.data {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// MatchLanguage returns the loaded language best matching the language tag `language` like "zh-TW",
+// which is compared case-insensitively with "_" treated as "-".
+// The tag is matched exactly first, and then with its subtags removed from the end one by one like "zh-TW" to "zh".
+// The primary language like "en" also matches the first loaded language of its region like "en-US".
+// It returns an empty string if no loaded language matches.
+func (m *Manager) MatchLanguage(language string) string {
+	tag := normalizeLanguage(language)
+	if tag == "" {
+		return ""
+	}
+	languages := m.GetLanguages()
+	for {
+		for _, loaded := range languages {
+			if normalizeLanguage(loaded) == tag {
+				return loaded
+			}
+		}
+		index := strings.LastIndexByte(tag, '-')
+		if index <= 0 {
+			break
+		}
+		tag = tag[:index]
+	}
+	if normalizeLanguage(language) == tag {
+		for _, loaded := range languages {
+			if strings.HasPrefix(normalizeLanguage(loaded), tag+"-") {
+				return loaded
+			}
+		}
+	}
+	return ""
+}
+
+// normalizeLanguage returns the lower-case language tag using "-" as separator.
+func normalizeLanguage(language string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(language), "_", "-"))
+}
+
 // SetDelimiters sets the delimiters for translator.
 func (m *Manager) SetDelimiters(left, right string) {
 	m.pattern = fmt.Sprintf(`%s(.+?)%s`, gbregex.Quote(left), gbregex.Quote(right))
@@ -26,7 +26,9 @@ type Client struct {
 	retryCount        int               // Retry count when request fails.
 	noUrlEncode       bool              // No url encoding for request parameters.
 	retryInterval     time.Duration     // Retry interval when request fails.
+	retryPolicy       *RetryPolicy      // Retry policy with exponential backoff, which takes precedence over retry count.
 	middlewareHandler []HandlerFunc     // Interceptor handlers
+	cassette          *Cassette         // Cassette for recording and replaying requests.
 	discovery         gbsvc.Discovery   // Discovery for service.
 	builder           gbsel.Builder     // Builder for request balance.
 }
@@ -40,6 +42,7 @@ const (
 	httpHeaderCookie          = `Cookie`
 	httpHeaderUserAgent       = `User-Agent`
 	httpHeaderContentType     = `Content-Type`
+	httpHeaderRequestId       = `X-Request-Id`
 	httpHeaderContentTypeJson = `application/json`
 	httpHeaderContentTypeXml  = `application/xml`
 	httpHeaderContentTypeForm = `application/x-www-form-urlencoded`
@@ -0,0 +1,357 @@
+package gbclient
+
+import (
+	"context"
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	gblog "ghostbb.io/gb/os/gb_log"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BreakerState is the state of circuit breaker.
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // Requests are allowed and the failures are counted.
+	BreakerOpen                         // Requests are rejected until the open timeout passes.
+	BreakerHalfOpen                     // Limited probe requests are allowed to check whether the dependency recovers.
+)
+
+const (
+	defaultBreakerWindow           = 10 * time.Second
+	defaultBreakerFailureRatio     = 0.5
+	defaultBreakerMinRequests      = 20
+	defaultBreakerOpenTimeout      = 30 * time.Second
+	defaultBreakerHalfOpenRequests = 1
+	breakerBucketCount             = 10
+	tracingEventBreakerStateChange = "http.breaker.state_change"
+	tracingEventBreakerRejected    = "http.breaker.rejected"
+	tracingAttrBreakerKey          = "http.breaker.key"
+	tracingAttrBreakerFrom         = "http.breaker.from"
+	tracingAttrBreakerTo           = "http.breaker.to"
+)
+
+// breakerResult is the result of request passing through circuit breaker.
+type breakerResult int
+
+const (
+	breakerSuccess breakerResult = iota
+	breakerFailure
+	breakerIgnored // The request is canceled by caller, which is not counted.
+)
+
+// BreakerOption is the option for Breaker.
+type BreakerOption struct {
+	// Window specifies the rolling window of counting requests in closed state, which is 10 seconds in default.
+	Window time.Duration
+	// FailureRatio specifies the ratio of failed requests in window that opens the circuit, which is 0.5 in default.
+	FailureRatio float64
+	// MinRequests specifies the min requests in window before the failure ratio is checked,
+	// which is 20 in default.
+	MinRequests int
+	// OpenTimeout specifies how long the circuit stays open before turning half-open, which is 30 seconds in default.
+	OpenTimeout time.Duration
+	// HalfOpenRequests specifies the probe requests allowed in half-open state, which is 1 in default.
+	// The circuit is closed if all of them succeed, or else opened again.
+	HalfOpenRequests int
+	// Key returns the circuit key of request. It is the name of discovered service if the request
+	// is sent using service discovery, or else the host of request URL in default.
+	Key func(r *http.Request) string
+	// IsFailure checks whether the request fails, which is request error or response status 5xx in default.
+	IsFailure func(resp *Response, err error) bool
+	// Fallback is called if the request is rejected by the open circuit or fails,
+	// and its result is returned instead. The `err` is the rejection error with code gbcode.CodeServerBusy,
+	// or the request error. The failed response is closed before calling Fallback.
+	Fallback func(c *Client, r *http.Request, err error) (*Response, error)
+	// OnStateChange is called after the state of circuit `key` changes.
+	OnStateChange func(ctx context.Context, key string, from, to BreakerState)
+	// Logger specifies the logger for state changes, which is the default logger of gblog in default.
+	Logger *gblog.Logger
+}
+
+// Breaker is the circuit breaker for client, which keeps a circuit for each host or discovered service,
+// so that the requests to a failing dependency fail fast instead of waiting for timeouts.
+type Breaker struct {
+	mu       sync.Mutex
+	option   BreakerOption
+	circuits map[string]*circuit
+}
+
+// circuit is the state of one circuit key.
+type circuit struct {
+	state      BreakerState
+	generation uint64    // It is increased on state change, to ignore the results of requests of previous state.
+	openedAt   time.Time // Time of opening.
+	buckets    [breakerBucketCount]breakerBucket
+	probes     int // Probe requests allowed in half-open state.
+	successes  int // Succeeded probe requests in half-open state.
+}
+
+// breakerBucket counts the requests of one time slice in rolling window.
+type breakerBucket struct {
+	index    int64
+	requests int
+	failures int
+}
+
+// breakerTransition is the state change for emitting after lock released.
+type breakerTransition struct {
+	key      string
+	from, to BreakerState
+}
+
+// NewBreaker creates and returns a circuit breaker, which is used as client middleware like:
+// gbclient.New().Use(gbclient.NewBreaker().Middleware).
+func NewBreaker(option ...BreakerOption) *Breaker {
+	var o BreakerOption
+	if len(option) > 0 {
+		o = option[0]
+	}
+	if o.Window <= 0 {
+		o.Window = defaultBreakerWindow
+	}
+	if o.FailureRatio <= 0 || o.FailureRatio > 1 {
+		o.FailureRatio = defaultBreakerFailureRatio
+	}
+	if o.MinRequests <= 0 {
+		o.MinRequests = defaultBreakerMinRequests
+	}
+	if o.OpenTimeout <= 0 {
+		o.OpenTimeout = defaultBreakerOpenTimeout
+	}
+	if o.HalfOpenRequests <= 0 {
+		o.HalfOpenRequests = defaultBreakerHalfOpenRequests
+	}
+	if o.Key == nil {
+		o.Key = defaultBreakerKey
+	}
+	if o.IsFailure == nil {
+		o.IsFailure = defaultBreakerIsFailure
+	}
+	return &Breaker{
+		option:   o,
+		circuits: make(map[string]*circuit),
+	}
+}
+
+// String returns the name of state.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// State returns the current state of circuit `key`.
+func (b *Breaker) State(key string) BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c, ok := b.circuits[key]; ok {
+		if c.state == BreakerOpen && time.Since(c.openedAt) >= b.option.OpenTimeout {
+			return BreakerHalfOpen
+		}
+		return c.state
+	}
+	return BreakerClosed
+}
+
+// Middleware is the client middleware handler of circuit breaker.
+func (b *Breaker) Middleware(c *Client, r *http.Request) (resp *Response, err error) {
+	var (
+		ctx = r.Context()
+		key = b.option.Key(r)
+	)
+	generation, err := b.allow(ctx, key)
+	if err != nil {
+		trace.SpanFromContext(ctx).AddEvent(tracingEventBreakerRejected, trace.WithAttributes(
+			attribute.String(tracingAttrBreakerKey, key),
+		))
+		if b.option.Fallback != nil {
+			return b.option.Fallback(c, r, err)
+		}
+		return nil, err
+	}
+	result := breakerFailure
+	defer func() {
+		// The request is counted as failure if the next handler panics.
+		b.done(ctx, key, generation, result)
+	}()
+	resp, err = c.Next(r)
+	switch {
+	case ctx.Err() != nil:
+		result = breakerIgnored
+	case b.option.IsFailure(resp, err):
+		result = breakerFailure
+	default:
+		result = breakerSuccess
+	}
+	if result == breakerFailure && b.option.Fallback != nil {
+		if err == nil {
+			err = gberror.NewCodef(gbcode.CodeOperationFailed, `request "%s" failed`, r.URL.String())
+			if resp != nil && resp.Response != nil {
+				err = gberror.NewCodef(
+					gbcode.CodeOperationFailed, `request "%s" failed with status %d`, r.URL.String(), resp.StatusCode,
+				)
+			}
+		}
+		if resp != nil && resp.Response != nil {
+			_ = resp.Close()
+		}
+		return b.option.Fallback(c, r, err)
+	}
+	return resp, err
+}
+
+// allow checks whether the request of circuit `key` is allowed,
+// and returns the generation of circuit that the result of request belongs to.
+func (b *Breaker) allow(ctx context.Context, key string) (generation uint64, err error) {
+	var transition *breakerTransition
+	defer func() {
+		b.emit(ctx, transition)
+	}()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+	if c.state == BreakerOpen && time.Since(c.openedAt) >= b.option.OpenTimeout {
+		transition = b.setState(key, c, BreakerHalfOpen)
+	}
+	switch c.state {
+	case BreakerOpen:
+		return 0, gberror.NewCodef(gbcode.CodeServerBusy, `circuit breaker is open for "%s"`, key)
+	case BreakerHalfOpen:
+		if c.probes >= b.option.HalfOpenRequests {
+			return 0, gberror.NewCodef(gbcode.CodeServerBusy, `circuit breaker is half-open for "%s"`, key)
+		}
+		c.probes++
+	}
+	return c.generation, nil
+}
+
+// done records the result of request of circuit `key`, and changes the state if necessary.
+func (b *Breaker) done(ctx context.Context, key string, generation uint64, result breakerResult) {
+	var transition *breakerTransition
+	defer func() {
+		b.emit(ctx, transition)
+	}()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[key]
+	if !ok || c.generation != generation {
+		return
+	}
+	switch c.state {
+	case BreakerClosed:
+		if result == breakerIgnored {
+			return
+		}
+		requests, failures := c.record(b.option.Window, result == breakerFailure)
+		if result == breakerFailure &&
+			requests >= b.option.MinRequests &&
+			float64(failures) >= float64(requests)*b.option.FailureRatio {
+			transition = b.setState(key, c, BreakerOpen)
+		}
+
+	case BreakerHalfOpen:
+		switch result {
+		case breakerIgnored:
+			c.probes--
+		case breakerFailure:
+			transition = b.setState(key, c, BreakerOpen)
+		default:
+			if c.successes++; c.successes >= b.option.HalfOpenRequests {
+				transition = b.setState(key, c, BreakerClosed)
+			}
+		}
+	}
+}
+
+// setState changes the state of circuit `c` and resets its counters, which should be called with lock.
+func (b *Breaker) setState(key string, c *circuit, state BreakerState) *breakerTransition {
+	transition := &breakerTransition{key: key, from: c.state, to: state}
+	c.state = state
+	c.generation++
+	c.buckets = [breakerBucketCount]breakerBucket{}
+	c.probes = 0
+	c.successes = 0
+	if state == BreakerOpen {
+		c.openedAt = time.Now()
+	}
+	return transition
+}
+
+// emit emits the state change to logger, tracing span and OnStateChange.
+func (b *Breaker) emit(ctx context.Context, transition *breakerTransition) {
+	if transition == nil {
+		return
+	}
+	logger := b.option.Logger
+	if logger == nil {
+		logger = gblog.DefaultLogger()
+	}
+	if transition.to == BreakerOpen {
+		logger.Warningf(ctx, `circuit breaker "%s" changed from %s to %s`, transition.key, transition.from, transition.to)
+	} else {
+		logger.Infof(ctx, `circuit breaker "%s" changed from %s to %s`, transition.key, transition.from, transition.to)
+	}
+	trace.SpanFromContext(ctx).AddEvent(tracingEventBreakerStateChange, trace.WithAttributes(
+		attribute.String(tracingAttrBreakerKey, transition.key),
+		attribute.String(tracingAttrBreakerFrom, transition.from.String()),
+		attribute.String(tracingAttrBreakerTo, transition.to.String()),
+	))
+	if b.option.OnStateChange != nil {
+		b.option.OnStateChange(ctx, transition.key, transition.from, transition.to)
+	}
+}
+
+// record counts the request in rolling window, and returns the requests and failures in window.
+func (c *circuit) record(window time.Duration, failed bool) (requests, failures int) {
+	var (
+		size  = int64(window / breakerBucketCount)
+		index = time.Now().UnixNano() / max(size, 1)
+	)
+	bucket := &c.buckets[index%breakerBucketCount]
+	if bucket.index != index {
+		*bucket = breakerBucket{index: index}
+	}
+	bucket.requests++
+	if failed {
+		bucket.failures++
+	}
+	for _, v := range c.buckets {
+		if v.index > index-breakerBucketCount {
+			requests += v.requests
+			failures += v.failures
+		}
+	}
+	return
+}
+
+// defaultBreakerKey returns the name of discovered service, or else the host of request URL.
+func defaultBreakerKey(r *http.Request) string {
+	if name, ok := r.Context().Value(discoveryServiceName).(string); ok && name != "" {
+		return name
+	}
+	return r.URL.Host
+}
+
+// defaultBreakerIsFailure checks whether the request fails with error or response status 5xx.
+func defaultBreakerIsFailure(resp *Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp != nil && resp.Response != nil && resp.StatusCode >= http.StatusInternalServerError
+}
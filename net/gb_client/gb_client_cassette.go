@@ -0,0 +1,301 @@
+package gbclient
+
+import (
+	"bytes"
+	"ghostbb.io/gb"
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	"ghostbb.io/gb/internal/json"
+	gbfile "ghostbb.io/gb/os/gb_file"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CassetteMode is the mode of cassette.
+type CassetteMode int
+
+const (
+	CassetteReplay CassetteMode = iota // Serves the recorded responses without touching the network, which is the default mode.
+	CassetteRecord                     // Sends requests to the network and records them, overwriting the existing cassette.
+	CassetteAuto                       // Serves the recorded response if it matches, or else sends the request and records it.
+)
+
+const (
+	cassetteRedacted = "REDACTED"
+)
+
+var (
+	// defaultCassetteRedactHeaders are the headers whose values are redacted in default.
+	defaultCassetteRedactHeaders = []string{
+		"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key",
+	}
+)
+
+// CassetteOption is the option for Cassette.
+type CassetteOption struct {
+	// Mode specifies the mode of cassette, which is CassetteReplay in default.
+	Mode CassetteMode
+	// Matcher checks whether the recorded request `recorded` matches the request `r` with body `body`.
+	// The method, URL and body are compared in default. Note that the redacted query parameters of `r`
+	// are already redacted before matching.
+	Matcher func(r *http.Request, body []byte, recorded HarRequest) bool
+	// RedactHeaders specifies the headers whose values are replaced with "REDACTED" in the cassette,
+	// which are Authorization, Proxy-Authorization, Cookie, Set-Cookie and X-Api-Key in default.
+	RedactHeaders []string
+	// RedactQuery specifies the query parameters whose values are replaced with "REDACTED" in the cassette,
+	// like the api keys passed in URL.
+	RedactQuery []string
+}
+
+// Cassette records the requests and responses of client into a file of HTTP Archive (HAR) format,
+// and replays the recorded responses without touching the network, which makes the tests of client code
+// deterministic and runnable offline.
+//
+// Each recorded response is replayed once in the order of recording for the same requests,
+// and the last one is replayed repeatedly after all of them are replayed.
+type Cassette struct {
+	mu       sync.Mutex
+	path     string
+	option   CassetteOption
+	har      Har
+	replayed []bool // Whether the entry of the same index is replayed.
+}
+
+// NewCassette creates and returns a cassette of file `path`.
+// The file is loaded in CassetteReplay and CassetteAuto mode, which should exist in CassetteReplay mode.
+func NewCassette(path string, option ...CassetteOption) (*Cassette, error) {
+	var o CassetteOption
+	if len(option) > 0 {
+		o = option[0]
+	}
+	if o.Matcher == nil {
+		o.Matcher = defaultCassetteMatcher
+	}
+	if o.RedactHeaders == nil {
+		o.RedactHeaders = defaultCassetteRedactHeaders
+	}
+	cassette := &Cassette{
+		path:   path,
+		option: o,
+		har: Har{Log: HarLog{
+			Version: harVersion,
+			Creator: HarCreator{Name: harCreatorName, Version: gb.VERSION},
+			Entries: make([]HarEntry, 0),
+		}},
+	}
+	if o.Mode == CassetteRecord || (o.Mode == CassetteAuto && !gbfile.Exists(path)) {
+		return cassette, nil
+	}
+	if !gbfile.Exists(path) {
+		return nil, gberror.NewCodef(gbcode.CodeInvalidParameter, `cassette file "%s" does not exist`, path)
+	}
+	if err := json.Unmarshal(gbfile.GetBytes(path), &cassette.har); err != nil {
+		return nil, gberror.WrapCodef(gbcode.CodeInvalidParameter, err, `invalid cassette file "%s"`, path)
+	}
+	cassette.replayed = make([]bool, len(cassette.har.Log.Entries))
+	return cassette, nil
+}
+
+// SetCassette sets the cassette for recording and replaying requests of the client.
+func (c *Client) SetCassette(cassette *Cassette) *Client {
+	c.cassette = cassette
+	return c
+}
+
+// Cassette is a chaining function,
+// which sets the cassette for next request.
+func (c *Client) Cassette(cassette *Cassette) *Client {
+	newClient := c.Clone()
+	newClient.SetCassette(cassette)
+	return newClient
+}
+
+// Entries returns the recorded entries of cassette.
+func (cs *Cassette) Entries() []HarEntry {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return append([]HarEntry(nil), cs.har.Log.Entries...)
+}
+
+// do sends request `req` through the cassette, and `send` is used for sending request to the network.
+func (cs *Cassette) do(req *http.Request, send func(req *http.Request) (*http.Response, error)) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if cs.option.Mode != CassetteRecord {
+		if entry, ok := cs.match(req, body); ok {
+			return cs.replay(req, entry), nil
+		}
+		if cs.option.Mode == CassetteReplay {
+			return nil, gberror.NewCodef(
+				gbcode.CodeNotFound, `no recorded response in cassette "%s" for request "%s %s"`,
+				cs.path, req.Method, cs.redactURL(req.URL),
+			)
+		}
+	}
+	start := time.Now()
+	resp, err := send(req)
+	if err != nil {
+		return resp, err
+	}
+	// The response body is captured the same way as Response.RawResponse.
+	responseBody, err := readResponseBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, cs.record(req, body, resp, responseBody, start)
+}
+
+// match returns the recorded entry matching request `req`, preferring the one not replayed.
+func (cs *Cassette) match(req *http.Request, body []byte) (entry HarEntry, ok bool) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	matchReq := req.Clone(req.Context())
+	matchReq.URL, _ = url.Parse(cs.redactURL(req.URL))
+	last := -1
+	for i, v := range cs.har.Log.Entries {
+		if !cs.option.Matcher(matchReq, body, v.Request) {
+			continue
+		}
+		if !cs.replayed[i] {
+			cs.replayed[i] = true
+			return v, true
+		}
+		last = i
+	}
+	if last >= 0 {
+		return cs.har.Log.Entries[last], true
+	}
+	return entry, false
+}
+
+// replay creates the response of recorded entry.
+func (cs *Cassette) replay(req *http.Request, entry HarEntry) *http.Response {
+	var (
+		body   = entry.Response.Content.Body()
+		header = harHttpHeader(entry.Response.Headers)
+		proto  = entry.Response.HTTPVersion
+	)
+	major, minor, ok := http.ParseHTTPVersion(proto)
+	if !ok {
+		proto, major, minor = "HTTP/1.1", 1, 1
+	}
+	return &http.Response{
+		Status:        strings.TrimSpace(strconv.Itoa(entry.Response.Status) + " " + entry.Response.StatusText),
+		StatusCode:    entry.Response.Status,
+		Proto:         proto,
+		ProtoMajor:    major,
+		ProtoMinor:    minor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// record appends the request and response to the cassette and saves the cassette file.
+func (cs *Cassette) record(req *http.Request, body []byte, resp *http.Response, responseBody []byte, start time.Time) error {
+	var (
+		elapsed = float64(time.Since(start)) / float64(time.Millisecond)
+		redact  = cs.isRedactHeader
+		entry   = HarEntry{
+			StartedDateTime: start.Format(time.RFC3339Nano),
+			Time:            elapsed,
+			Request: HarRequest{
+				Method:      req.Method,
+				URL:         cs.redactURL(req.URL),
+				HTTPVersion: req.Proto,
+				Cookies:     make([]HarNameValue, 0),
+				Headers:     harHeaders(req.Header, redact),
+				HeadersSize: -1,
+				BodySize:    len(body),
+			},
+			Response: HarResponse{
+				Status:      resp.StatusCode,
+				StatusText:  http.StatusText(resp.StatusCode),
+				HTTPVersion: resp.Proto,
+				Cookies:     make([]HarNameValue, 0),
+				Headers:     harHeaders(resp.Header, redact),
+				RedirectURL: resp.Header.Get("Location"),
+				HeadersSize: -1,
+				BodySize:    len(responseBody),
+			},
+			Timings: HarTimings{Wait: elapsed},
+		}
+	)
+	if parsed, err := url.Parse(entry.Request.URL); err == nil {
+		entry.Request.QueryString = harQueryString(parsed)
+	}
+	if len(body) > 0 {
+		text, encoding := encodeHarText(body)
+		entry.Request.PostData = &HarPostData{MimeType: req.Header.Get(httpHeaderContentType), Text: text, Encoding: encoding}
+	}
+	text, encoding := encodeHarText(responseBody)
+	entry.Response.Content = HarContent{
+		Size:     len(responseBody),
+		MimeType: resp.Header.Get(httpHeaderContentType),
+		Text:     text,
+		Encoding: encoding,
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.har.Log.Entries = append(cs.har.Log.Entries, entry)
+	cs.replayed = append(cs.replayed, true)
+	var (
+		buffer  = bytes.NewBuffer(nil)
+		encoder = json.NewEncoder(buffer)
+	)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(cs.har); err != nil {
+		return gberror.Wrapf(err, `marshal cassette "%s" failed`, cs.path)
+	}
+	return gbfile.PutBytes(cs.path, buffer.Bytes())
+}
+
+// isRedactHeader checks whether the value of header `name` is redacted.
+func (cs *Cassette) isRedactHeader(name string) bool {
+	for _, v := range cs.option.RedactHeaders {
+		if strings.EqualFold(v, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL returns the URL string with the values of redacted query parameters replaced.
+func (cs *Cassette) redactURL(u *url.URL) string {
+	if len(cs.option.RedactQuery) == 0 || u.RawQuery == "" {
+		return u.String()
+	}
+	var (
+		redacted = *u
+		query    = u.Query()
+	)
+	for _, name := range cs.option.RedactQuery {
+		if _, ok := query[name]; ok {
+			query.Set(name, cassetteRedacted)
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+// defaultCassetteMatcher matches the method, URL and body of request.
+func defaultCassetteMatcher(r *http.Request, body []byte, recorded HarRequest) bool {
+	return r.Method == recorded.Method &&
+		r.URL.String() == recorded.URL &&
+		bytes.Equal(body, recorded.PostData.Body())
+}
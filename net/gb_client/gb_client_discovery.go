@@ -6,6 +6,7 @@ import (
 	"ghostbb.io/gb/internal/intlog"
 	gbsel "ghostbb.io/gb/net/gb_sel"
 	gbsvc "ghostbb.io/gb/net/gb_svc"
+	gbctx "ghostbb.io/gb/os/gb_ctx"
 	"net/http"
 )
 
@@ -24,6 +25,9 @@ func (n *discoveryNode) Address() string {
 	return n.address
 }
 
+// discoveryServiceName is the context key for the name of discovered service of request.
+const discoveryServiceName gbctx.StrKey = "MiddlewareClientDiscoveryService"
+
 var clientSelectorMap = gbmap.New(true)
 
 // internalMiddlewareDiscovery is a client middleware that enables service discovery feature for client.
@@ -74,6 +78,7 @@ func internalMiddlewareDiscovery(c *Client, r *http.Request) (response *Response
 	if done != nil {
 		defer done(ctx, gbsel.DoneInfo{})
 	}
+	r = r.WithContext(context.WithValue(ctx, discoveryServiceName, service.GetName()))
 	r.Host = node.Address()
 	r.URL.Host = node.Address()
 	return c.Next(r)
@@ -0,0 +1,286 @@
+package gbclient
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	gbfile "ghostbb.io/gb/os/gb_file"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultDownloadMaxResumes     = 3
+	defaultDownloadResumeInterval = time.Second
+	downloadPartSuffix            = ".part"
+	downloadMetaSuffix            = ".meta"
+	downloadBufferSize            = 32 << 10
+)
+
+// DownloadOption is the option for Download.
+type DownloadOption struct {
+	// Checksum specifies the expected checksum of file like "sha256:<hex>", which is verified after downloading.
+	// The algorithms md5, sha1, sha256 and sha512 are supported.
+	Checksum string
+	// Progress specifies the progress callback of downloading.
+	Progress ProgressFunc
+	// MaxResumes specifies the max times of resuming after interruption, which is 3 in default.
+	MaxResumes int
+}
+
+// downloadError is the error of downloading attempt, which is resumable or not.
+type downloadError struct {
+	err       error
+	resumable bool
+}
+
+// Download downloads the file of `url` to `dstPath`, streaming the response body to disk.
+//
+// The content is written to "dstPath.part" first, which is resumed using Range request
+// if the downloading is interrupted, in the same call or in the next call after the process restarts.
+// The validator of the content, the ETag or Last-Modified, is stored in "dstPath.part.meta"
+// and sent with header "If-Range", so that the changed file is downloaded from beginning.
+// The file is renamed to `dstPath` after it is completed and its checksum is verified.
+func (c *Client) Download(ctx context.Context, url, dstPath string, option ...DownloadOption) error {
+	var o DownloadOption
+	if len(option) > 0 {
+		o = option[0]
+	}
+	if o.MaxResumes <= 0 {
+		o.MaxResumes = defaultDownloadMaxResumes
+	}
+	newHash, expected, err := parseChecksum(o.Checksum)
+	if err != nil {
+		return err
+	}
+	var (
+		partPath = dstPath + downloadPartSuffix
+		metaPath = partPath + downloadMetaSuffix
+	)
+	if err = gbfile.Mkdir(gbfile.Dir(dstPath)); err != nil {
+		return err
+	}
+	for resumes := 0; ; resumes++ {
+		downloadErr := c.downloadOnce(ctx, url, partPath, metaPath, o.Progress)
+		if downloadErr == nil {
+			break
+		}
+		if !downloadErr.resumable || resumes >= o.MaxResumes || ctx.Err() != nil {
+			return downloadErr.err
+		}
+		if !sleepContext(ctx, defaultDownloadResumeInterval*time.Duration(resumes+1)) {
+			return gberror.Wrapf(ctx.Err(), `download "%s" failed`, url)
+		}
+	}
+	if newHash != nil {
+		if err = verifyChecksum(partPath, newHash, expected); err != nil {
+			_ = gbfile.Remove(partPath)
+			_ = gbfile.Remove(metaPath)
+			return err
+		}
+	}
+	if err = gbfile.Rename(partPath, dstPath); err != nil {
+		return err
+	}
+	return gbfile.Remove(metaPath)
+}
+
+// downloadOnce downloads or resumes the file of `url` to `partPath`.
+func (c *Client) downloadOnce(ctx context.Context, url, partPath, metaPath string, progress ProgressFunc) *downloadError {
+	var (
+		offset    int64
+		validator string
+		client    = c.Clone()
+	)
+	// The content is not compressed, as the range is applied to the compressed content.
+	client.SetHeader("Accept-Encoding", "identity")
+	if gbfile.Exists(partPath) && gbfile.Exists(metaPath) {
+		offset = gbfile.Size(partPath)
+		validator = gbfile.GetContents(metaPath)
+	}
+	if offset > 0 {
+		client.SetHeader("Range", fmt.Sprintf("bytes=%d-", offset))
+		if validator != "" {
+			client.SetHeader("If-Range", validator)
+		}
+	}
+	resp, err := client.doStreamingRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return &downloadError{err: err, resumable: true}
+	}
+	defer resp.Close()
+
+	total := int64(-1)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		offset = 0
+		total = resp.ContentLength
+
+	case http.StatusPartialContent:
+		start, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			_ = gbfile.Remove(partPath)
+			return &downloadError{
+				err:       gberror.NewCodef(gbcode.CodeOperationFailed, `unexpected Content-Range of "%s"`, url),
+				resumable: true,
+			}
+		}
+		total = size
+
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The file is already completed if the partial file is of the full size.
+		if _, size, ok := parseContentRange(resp.Header.Get("Content-Range")); ok && size == offset && offset > 0 {
+			return nil
+		}
+		_ = gbfile.Remove(partPath)
+		return &downloadError{
+			err:       gberror.NewCodef(gbcode.CodeOperationFailed, `range of "%s" is not satisfiable`, url),
+			resumable: true,
+		}
+
+	default:
+		return &downloadError{
+			err: gberror.NewCodef(
+				gbcode.CodeOperationFailed, `download "%s" failed with status %d`, url, resp.StatusCode,
+			),
+			resumable: resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests,
+		}
+	}
+	if err = gbfile.PutContents(metaPath, downloadValidator(resp.Header)); err != nil {
+		return &downloadError{err: err}
+	}
+	flag := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if offset == 0 {
+		flag |= os.O_TRUNC
+	}
+	file, err := gbfile.OpenWithFlagPerm(partPath, flag, gbfile.DefaultPermOpen)
+	if err != nil {
+		return &downloadError{err: err}
+	}
+	defer file.Close()
+
+	var (
+		buffer  = make([]byte, downloadBufferSize)
+		written = offset
+	)
+	for {
+		n, readErr := resp.Body.Read(buffer)
+		if n > 0 {
+			if _, err = file.Write(buffer[:n]); err != nil {
+				return &downloadError{err: gberror.Wrapf(err, `write "%s" failed`, partPath)}
+			}
+			written += int64(n)
+			if progress != nil {
+				progress(written, total)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return &downloadError{err: gberror.Wrapf(readErr, `download "%s" interrupted`, url), resumable: true}
+		}
+	}
+	if total >= 0 && written != total {
+		return &downloadError{
+			err:       gberror.Wrapf(io.ErrUnexpectedEOF, `download "%s" interrupted at %d of %d bytes`, url, written, total),
+			resumable: true,
+		}
+	}
+	return nil
+}
+
+// downloadValidator returns the validator of response for header "If-Range",
+// which is the strong ETag or Last-Modified.
+func downloadValidator(header http.Header) string {
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return header.Get("Last-Modified")
+}
+
+// parseContentRange parses header "Content-Range" like "bytes 100-199/200" or "bytes */200",
+// and returns the start of range and the total size, which is -1 if it is unknown.
+func parseContentRange(value string) (start, total int64, ok bool) {
+	value, found := strings.CutPrefix(value, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	rangePart, totalPart, found := strings.Cut(value, "/")
+	if !found {
+		return 0, 0, false
+	}
+	total = -1
+	if totalPart != "*" {
+		var err error
+		if total, err = strconv.ParseInt(totalPart, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+	if rangePart == "*" {
+		return 0, total, true
+	}
+	startPart, _, found := strings.Cut(rangePart, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(startPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, total, true
+}
+
+// parseChecksum parses the checksum like "sha256:<hex>", and returns the hash constructor and the expected sum.
+func parseChecksum(checksum string) (newHash func() hash.Hash, expected string, err error) {
+	if checksum == "" {
+		return nil, "", nil
+	}
+	algorithm, expected, found := strings.Cut(checksum, ":")
+	if !found || expected == "" {
+		return nil, "", gberror.NewCodef(gbcode.CodeInvalidParameter, `invalid checksum "%s"`, checksum)
+	}
+	switch strings.ToLower(algorithm) {
+	case "md5":
+		newHash = md5.New
+	case "sha1":
+		newHash = sha1.New
+	case "sha256":
+		newHash = sha256.New
+	case "sha512":
+		newHash = sha512.New
+	default:
+		return nil, "", gberror.NewCodef(gbcode.CodeNotSupported, `unsupported checksum algorithm "%s"`, algorithm)
+	}
+	return newHash, strings.ToLower(expected), nil
+}
+
+// verifyChecksum verifies the checksum of file `path`.
+func verifyChecksum(path string, newHash func() hash.Hash, expected string) error {
+	file, err := gbfile.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	h := newHash()
+	if _, err = io.Copy(h, file); err != nil {
+		return gberror.Wrapf(err, `read "%s" failed`, path)
+	}
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != expected {
+		return gberror.NewCodef(
+			gbcode.CodeValidationFailed, `checksum mismatch of "%s", expected "%s" but got "%s"`, path, expected, actual,
+		)
+	}
+	return nil
+}
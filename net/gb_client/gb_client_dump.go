@@ -19,12 +19,20 @@ const dumpTextFormat = `+---------------------------------------------+
 
 // getResponseBody returns the text of the response body.
 func getResponseBody(res *http.Response) string {
+	bodyContent, _ := readResponseBody(res)
+	return string(bodyContent)
+}
+
+// readResponseBody reads the response body and replaces it with a repeatable reader of the content,
+// so that the body can still be read after it is dumped or recorded.
+func readResponseBody(res *http.Response) ([]byte, error) {
 	if res.Body == nil {
-		return ""
+		return nil, nil
 	}
-	bodyContent, _ := io.ReadAll(res.Body)
+	bodyContent, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
 	res.Body = utils.NewReadCloser(bodyContent, true)
-	return string(bodyContent)
+	return bodyContent, err
 }
 
 // RawRequest returns the raw content of the request.
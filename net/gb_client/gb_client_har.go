@@ -0,0 +1,177 @@
+package gbclient
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	harVersion        = "1.2"
+	harCreatorName    = "gbclient"
+	harEncodingBase64 = "base64"
+)
+
+// Har is the HTTP Archive document of version 1.2, which is used as the cassette format.
+// Only the fields used for recording and replaying are defined.
+type Har struct {
+	Log HarLog `json:"log"`
+}
+
+// HarLog is the root of exported data of HTTP Archive.
+type HarLog struct {
+	Version string     `json:"version"`
+	Creator HarCreator `json:"creator"`
+	Entries []HarEntry `json:"entries"`
+}
+
+// HarCreator is the application that creates the HTTP Archive.
+type HarCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HarEntry is one request and response pair.
+type HarEntry struct {
+	StartedDateTime string      `json:"startedDateTime"` // Start time of request in ISO 8601 format.
+	Time            float64     `json:"time"`            // Elapsed time of request in milliseconds.
+	Request         HarRequest  `json:"request"`
+	Response        HarResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HarTimings  `json:"timings"`
+}
+
+// HarRequest is the request of entry.
+type HarRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HarNameValue `json:"cookies"`
+	Headers     []HarNameValue `json:"headers"`
+	QueryString []HarNameValue `json:"queryString"`
+	PostData    *HarPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HarResponse is the response of entry.
+type HarResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HarNameValue `json:"cookies"`
+	Headers     []HarNameValue `json:"headers"`
+	Content     HarContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HarNameValue is the name and value pair of header, cookie and query parameter.
+type HarNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HarPostData is the body of request.
+type HarPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"` // It is "base64" if the body is not valid UTF-8 text, which is extended by HAR 1.2 for response only.
+}
+
+// HarContent is the body of response.
+type HarContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"` // It is "base64" if the body is not valid UTF-8 text.
+}
+
+// HarTimings is the timings of request, only the wait time is recorded.
+type HarTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// Body returns the decoded body of request.
+func (d *HarPostData) Body() []byte {
+	if d == nil {
+		return nil
+	}
+	return decodeHarText(d.Text, d.Encoding)
+}
+
+// Body returns the decoded body of response.
+func (c HarContent) Body() []byte {
+	return decodeHarText(c.Text, c.Encoding)
+}
+
+// harHeaders converts http header to HAR name value pairs, with the values of `redact` headers replaced.
+// The pairs are sorted by name, so that re-recording the same interaction produces the same cassette.
+func harHeaders(header http.Header, redact func(name string) bool) []HarNameValue {
+	pairs := make([]HarNameValue, 0, len(header))
+	for _, name := range sortedKeys(header) {
+		for _, value := range header[name] {
+			if redact(name) {
+				value = cassetteRedacted
+			}
+			pairs = append(pairs, HarNameValue{Name: name, Value: value})
+		}
+	}
+	return pairs
+}
+
+// harQueryString converts the query of URL to HAR name value pairs, which are sorted by name.
+func harQueryString(u *url.URL) []HarNameValue {
+	var (
+		pairs = make([]HarNameValue, 0)
+		query = u.Query()
+	)
+	for _, name := range sortedKeys(query) {
+		for _, value := range query[name] {
+			pairs = append(pairs, HarNameValue{Name: name, Value: value})
+		}
+	}
+	return pairs
+}
+
+// sortedKeys returns the sorted keys of `m`.
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// harHttpHeader converts HAR name value pairs to http header.
+func harHttpHeader(pairs []HarNameValue) http.Header {
+	header := make(http.Header, len(pairs))
+	for _, pair := range pairs {
+		header.Add(pair.Name, pair.Value)
+	}
+	return header
+}
+
+// encodeHarText encodes `body` as text, which is base64 encoded if it is not valid UTF-8.
+func encodeHarText(body []byte) (text, encoding string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), harEncodingBase64
+}
+
+// decodeHarText decodes the text encoded by encodeHarText.
+func decodeHarText(text, encoding string) []byte {
+	if strings.EqualFold(encoding, harEncodingBase64) {
+		body, _ := base64.StdEncoding.DecodeString(text)
+		return body
+	}
+	return []byte(text)
+}
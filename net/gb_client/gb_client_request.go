@@ -4,19 +4,16 @@ import (
 	"bytes"
 	"context"
 	gbjson "ghostbb.io/gb/encoding/gb_json"
-	gbcode "ghostbb.io/gb/errors/gb_code"
 	gberror "ghostbb.io/gb/errors/gb_error"
 	"ghostbb.io/gb/internal/httputil"
 	"ghostbb.io/gb/internal/json"
-	"ghostbb.io/gb/internal/utils"
-	gbfile "ghostbb.io/gb/os/gb_file"
+	gbctx "ghostbb.io/gb/os/gb_ctx"
 	gbregex "ghostbb.io/gb/text/gb_regex"
 	gbstr "ghostbb.io/gb/text/gb_str"
 	gbconv "ghostbb.io/gb/util/gb_conv"
 	"io"
 	"mime/multipart"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 )
@@ -119,7 +116,21 @@ func (c *Client) DoRequest(ctx context.Context, method, url string, data ...inte
 	if err != nil {
 		return nil, err
 	}
+	return c.handleRequest(req)
+}
+
+// doStreamingRequest sends the request whose body and response body are not buffered in memory,
+// with the multipart body `stream` if it is not nil.
+func (c *Client) doStreamingRequest(ctx context.Context, method, url string, stream *multipartStream) (*Response, error) {
+	req, err := c.prepareRequest(ctx, method, url)
+	if err != nil {
+		return nil, err
+	}
+	return c.handleRequest(setStreaming(req, stream))
+}
 
+// handleRequest sends the prepared request `req` through the client middlewares.
+func (c *Client) handleRequest(req *http.Request) (resp *Response, err error) {
 	// Client middleware.
 	if len(c.middlewareHandler) > 0 {
 		mdlHandlers := make([]HandlerFunc, 0, len(c.middlewareHandler)+1)
@@ -127,7 +138,7 @@ func (c *Client) DoRequest(ctx context.Context, method, url string, data ...inte
 		mdlHandlers = append(mdlHandlers, func(cli *Client, r *http.Request) (*Response, error) {
 			return cli.callRequest(r)
 		})
-		ctx = context.WithValue(req.Context(), clientMiddlewareKey, &clientMiddleware{
+		ctx := context.WithValue(req.Context(), clientMiddlewareKey, &clientMiddleware{
 			client:       c,
 			handlers:     mdlHandlers,
 			handlerIndex: -1,
@@ -149,7 +160,10 @@ func (c *Client) prepareRequest(ctx context.Context, method, url string, data ..
 	if !gbstr.ContainsI(url, httpProtocolName) {
 		url = httpProtocolName + `://` + url
 	}
-	var params string
+	var (
+		params string
+		stream *multipartStream
+	)
 	if len(data) > 0 {
 		switch c.header[httpHeaderContentType] {
 		case httpHeaderContentTypeJson:
@@ -206,61 +220,25 @@ func (c *Client) prepareRequest(ctx context.Context, method, url string, data ..
 		}
 	} else {
 		if strings.Contains(params, httpParamFileHolder) {
-			// File uploading request.
+			// File uploading request, which streams the files from disk.
 			var (
-				buffer = bytes.NewBuffer(nil)
-				writer = multipart.NewWriter(buffer)
+				fields [][2]string
+				files  []UploadFile
 			)
 			for _, item := range strings.Split(params, "&") {
 				array := strings.Split(item, "=")
 				if len(array[1]) > 6 && strings.Compare(array[1][0:6], httpParamFileHolder) == 0 {
-					path := array[1][6:]
-					if !gbfile.Exists(path) {
-						return nil, gberror.NewCodef(gbcode.CodeInvalidParameter, `"%s" does not exist`, path)
-					}
-					var (
-						file          io.Writer
-						formFileName  = gbfile.Basename(path)
-						formFieldName = array[0]
-					)
-					if file, err = writer.CreateFormFile(formFieldName, formFileName); err != nil {
-						err = gberror.Wrapf(err, `CreateFormFile failed with "%s", "%s"`, formFieldName, formFileName)
-						return nil, err
-					} else {
-						var f *os.File
-						if f, err = gbfile.Open(path); err != nil {
-							return nil, err
-						}
-						if _, err = io.Copy(file, f); err != nil {
-							err = gberror.Wrapf(err, `io.Copy failed from "%s" to form "%s"`, path, formFieldName)
-							_ = f.Close()
-							return nil, err
-						}
-						_ = f.Close()
-					}
+					files = append(files, UploadFile{Field: array[0], Path: array[1][6:]})
 				} else {
-					var (
-						fieldName  = array[0]
-						fieldValue = array[1]
-					)
-					if err = writer.WriteField(fieldName, fieldValue); err != nil {
-						err = gberror.Wrapf(err, `write form field failed with "%s", "%s"`, fieldName, fieldValue)
-						return nil, err
-					}
+					fields = append(fields, [2]string{array[0], array[1]})
 				}
 			}
-			// Close finishes the multipart message and writes the trailing
-			// boundary end line to the output.
-			if err = writer.Close(); err != nil {
-				err = gberror.Wrapf(err, `form writer close failed`)
+			if stream, err = newMultipartStream(fields, files, nil); err != nil {
 				return nil, err
 			}
-
-			if req, err = http.NewRequest(method, url, buffer); err != nil {
+			if req, err = http.NewRequest(method, url, nil); err != nil {
 				err = gberror.Wrapf(err, `http.NewRequest failed for method "%s" and URL "%s"`, method, url)
 				return nil, err
-			} else {
-				req.Header.Set(httpHeaderContentType, writer.FormDataContentType())
 			}
 		} else {
 			// Normal request.
@@ -287,6 +265,11 @@ func (c *Client) prepareRequest(ctx context.Context, method, url string, data ..
 
 	// Context.
 	if ctx != nil {
+		// The streaming mark belongs to the request created with `ctx`, like the request in client middleware,
+		// which is not inherited by the requests created with its context.
+		if ctx.Value(streamingRequest) != nil {
+			ctx = context.WithValue(ctx, streamingRequest, nil)
+		}
 		req = req.WithContext(ctx)
 	}
 	// Custom header.
@@ -295,6 +278,14 @@ func (c *Client) prepareRequest(ctx context.Context, method, url string, data ..
 			req.Header.Set(k, v)
 		}
 	}
+	// Streaming multipart body.
+	if stream != nil {
+		req = setStreaming(req, stream)
+	}
+	// Request id for correlating logs among services, which is forwarded from context if it is not set.
+	if requestId := gbctx.RequestId(ctx); requestId != "" && req.Header.Get(httpHeaderRequestId) == "" {
+		req.Header.Set(httpHeaderRequestId, requestId)
+	}
 	// It's necessary set the req.Host if you want to custom the host value of the request.
 	// It uses the "Host" value from header if it's not empty.
 	if reqHeaderHost := req.Header.Get(httpHeaderHost); reqHeaderHost != "" {
@@ -320,6 +311,14 @@ func (c *Client) prepareRequest(ctx context.Context, method, url string, data ..
 	return req, nil
 }
 
+// send sends request `req` to the network, or through the cassette if it is set.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	if c.cassette != nil {
+		return c.cassette.do(req, c.Do)
+	}
+	return c.Do(req)
+}
+
 // callRequest sends request with give http.Request, and returns the responses object.
 // Note that the response object MUST be closed if it'll never be used.
 func (c *Client) callRequest(req *http.Request) (resp *Response, err error) {
@@ -329,18 +328,28 @@ func (c *Client) callRequest(req *http.Request) (resp *Response, err error) {
 	// Dump feature.
 	// The request body can be reused for dumping
 	// raw HTTP request-response procedure.
-	reqBodyContent, _ := io.ReadAll(req.Body)
-	resp.requestBody = reqBodyContent
-	for {
-		req.Body = utils.NewReadCloser(reqBodyContent, false)
-		if resp.Response, err = c.Do(req); err != nil {
+	// The streaming body is not buffered, which is reopened for retrying.
+	var reqBodyContent []byte
+	if !isStreamingRequest(req) {
+		reqBodyContent, _ = io.ReadAll(req.Body)
+		resp.requestBody = reqBodyContent
+	}
+	if c.retryPolicy != nil {
+		return c.callRequestWithPolicy(req, reqBodyContent, resp)
+	}
+	retryCount := c.retryCount
+	for attempt := 0; ; attempt++ {
+		if err = resetRequestBody(req, reqBodyContent, attempt); err != nil {
+			break
+		}
+		if resp.Response, err = c.send(req); err != nil {
 			err = gberror.Wrapf(err, `request failed`)
 			// The response might not be nil when err != nil.
 			if resp.Response != nil {
 				_ = resp.Response.Body.Close()
 			}
-			if c.retryCount > 0 {
-				c.retryCount--
+			if retryCount > 0 {
+				retryCount--
 				time.Sleep(c.retryInterval)
 			} else {
 				// return resp, err
@@ -0,0 +1,214 @@
+package gbclient
+
+import (
+	"context"
+	"errors"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetryMaxRetries      = 3
+	defaultRetryInitialInterval = 100 * time.Millisecond
+	defaultRetryMaxInterval     = 10 * time.Second
+	defaultRetryMultiplier      = 2
+	httpHeaderRetryAfter        = `Retry-After`
+	httpHeaderIdempotencyKey    = `Idempotency-Key`
+)
+
+var (
+	// defaultRetryMethods are the idempotent methods defined in RFC 9110, which are safe for retrying.
+	defaultRetryMethods = []string{
+		http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete,
+	}
+	// defaultRetryStatusCodes are the response status codes indicating the server is temporarily unavailable.
+	defaultRetryStatusCodes = []int{
+		http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+)
+
+// RetryPolicy is the retry policy for client, which retries with exponential backoff and full jitter,
+// so that the retries from many clients do not synchronize and amplify the load of server.
+//
+// The interval before the Nth retry is a random duration in [0, min(MaxInterval, InitialInterval*Multiplier^(N-1))),
+// or the duration of response header "Retry-After" if it is present. The response is returned without retrying
+// if "Retry-After" exceeds MaxInterval, or the retry would exceed MaxElapsedTime.
+type RetryPolicy struct {
+	// MaxRetries specifies the max retry count, which is 3 in default.
+	MaxRetries int
+	// InitialInterval specifies the backoff interval of first retry, which is 100 milliseconds in default.
+	InitialInterval time.Duration
+	// MaxInterval specifies the max backoff interval, which is 10 seconds in default.
+	MaxInterval time.Duration
+	// Multiplier specifies the growth of backoff interval for each retry, which is 2 in default.
+	Multiplier float64
+	// MaxElapsedTime specifies the max time since the first attempt, after which no retry is made.
+	// It is no limit if it is zero.
+	MaxElapsedTime time.Duration
+	// Methods specifies the methods that are retried, which are the idempotent methods in default.
+	// The request with header "Idempotency-Key" is also retried whatever its method is.
+	Methods []string
+	// StatusCodes specifies the response status codes that are retried, which are 429, 502, 503 and 504 in default.
+	StatusCodes []int
+	// RetryError checks whether the request error is retried,
+	// which retries all errors except the cancellation and deadline of context in default.
+	RetryError func(err error) bool
+}
+
+// SetRetryPolicy sets the retry policy, which takes precedence over SetRetry.
+func (c *Client) SetRetryPolicy(policy RetryPolicy) *Client {
+	if policy.MaxRetries <= 0 {
+		policy.MaxRetries = defaultRetryMaxRetries
+	}
+	if policy.InitialInterval <= 0 {
+		policy.InitialInterval = defaultRetryInitialInterval
+	}
+	if policy.MaxInterval <= 0 {
+		policy.MaxInterval = defaultRetryMaxInterval
+	}
+	if policy.Multiplier < 1 {
+		policy.Multiplier = defaultRetryMultiplier
+	}
+	if len(policy.Methods) == 0 {
+		policy.Methods = defaultRetryMethods
+	}
+	if len(policy.StatusCodes) == 0 {
+		policy.StatusCodes = defaultRetryStatusCodes
+	}
+	if policy.RetryError == nil {
+		policy.RetryError = defaultRetryError
+	}
+	c.retryPolicy = &policy
+	return c
+}
+
+// RetryWithPolicy is a chaining function,
+// which sets the retry policy for next request.
+func (c *Client) RetryWithPolicy(policy RetryPolicy) *Client {
+	newClient := c.Clone()
+	newClient.SetRetryPolicy(policy)
+	return newClient
+}
+
+// callRequestWithPolicy sends request `req` with body `body`, and retries it according to the retry policy.
+// The body is replayed for each attempt, and the failed response is closed before retrying.
+func (c *Client) callRequestWithPolicy(req *http.Request, body []byte, resp *Response) (*Response, error) {
+	var (
+		err    error
+		ctx    = req.Context()
+		policy = c.retryPolicy
+		start  = time.Now()
+		retry  = policy.isRetryableMethod(req.Method) || req.Header.Get(httpHeaderIdempotencyKey) != ""
+	)
+	for attempt := 0; ; attempt++ {
+		if err = resetRequestBody(req, body, attempt); err != nil {
+			break
+		}
+		resp.Response, err = c.send(req)
+		if !retry || attempt >= policy.MaxRetries || !policy.isRetryable(ctx, resp, err) {
+			break
+		}
+		interval := policy.backoff(attempt)
+		if resp.Response != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get(httpHeaderRetryAfter)); ok {
+				if retryAfter > policy.MaxInterval {
+					break
+				}
+				interval = retryAfter
+			}
+		}
+		if policy.MaxElapsedTime > 0 && time.Since(start)+interval > policy.MaxElapsedTime {
+			break
+		}
+		if resp.Response != nil {
+			_ = resp.Response.Body.Close()
+		}
+		if !sleepContext(ctx, interval) {
+			return resp, gberror.Wrapf(ctx.Err(), `request failed`)
+		}
+	}
+	if err != nil {
+		err = gberror.Wrapf(err, `request failed`)
+		// The response might not be nil when err != nil.
+		if resp.Response != nil {
+			_ = resp.Response.Body.Close()
+		}
+	}
+	return resp, err
+}
+
+// isRetryableMethod checks whether the request of `method` can be retried.
+func (p *RetryPolicy) isRetryableMethod(method string) bool {
+	for _, v := range p.Methods {
+		if v == method {
+			return true
+		}
+	}
+	return false
+}
+
+// isRetryable checks whether the result of request should be retried.
+func (p *RetryPolicy) isRetryable(ctx context.Context, resp *Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return p.RetryError(err)
+	}
+	for _, code := range p.StatusCodes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the interval with full jitter before the retry after `attempt`.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	interval := float64(p.InitialInterval) * math.Pow(p.Multiplier, float64(attempt))
+	if interval > float64(p.MaxInterval) {
+		interval = float64(p.MaxInterval)
+	}
+	return time.Duration(rand.Int63n(int64(interval) + 1))
+}
+
+// parseRetryAfter parses the header "Retry-After" in seconds or HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+// defaultRetryError retries all errors except the cancellation and deadline of context.
+func defaultRetryError(err error) bool {
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// sleepContext sleeps for `duration`, and returns false if the context is done during sleeping.
+func sleepContext(ctx context.Context, duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,258 @@
+package gbclient
+
+import (
+	"context"
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	"ghostbb.io/gb/internal/utils"
+	gbctx "ghostbb.io/gb/os/gb_ctx"
+	gbfile "ghostbb.io/gb/os/gb_file"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// streamingRequest is the context key marking the request whose body is streamed,
+// which is not buffered in memory by the client and the tracing middleware.
+const streamingRequest gbctx.StrKey = "MiddlewareClientStreaming"
+
+// ProgressFunc is the callback of transferring progress, which is called with the transferred bytes
+// and the total bytes. The total is -1 if it is unknown.
+type ProgressFunc func(transferred, total int64)
+
+// UploadFile is the file of multipart upload.
+type UploadFile struct {
+	Field string // Form field name of file.
+	Path  string // Path of file on disk.
+	Name  string // File name in form, which is the base name of Path in default.
+}
+
+// UploadOption is the option for Upload.
+type UploadOption struct {
+	Fields   map[string]string // Form fields besides files.
+	Files    []UploadFile      // Files streamed from disk.
+	Progress ProgressFunc      // Progress callback of uploading, which is called in the goroutine sending the body.
+}
+
+// multipartStream is the multipart body streamed from disk, which can be opened multiple times for retrying.
+type multipartStream struct {
+	boundary string
+	fields   [][2]string
+	files    []UploadFile
+	sizes    []int64
+	length   int64 // Content length of the whole body.
+	progress ProgressFunc
+}
+
+// lazyPipe is the reader of io.Pipe, which starts writing only when it is read for the first time,
+// so that no goroutine leaks if the body is never sent.
+type lazyPipe struct {
+	once     sync.Once
+	reader   *io.PipeReader
+	writer   *io.PipeWriter
+	write    func(w io.Writer) error
+	read     int64
+	total    int64
+	progress ProgressFunc
+}
+
+// Upload sends POST request of "multipart/form-data" to `url`, with the files streamed from disk
+// instead of building the body in memory. The body is replayed from disk if the request is retried.
+// Note that the response object MUST be closed if it'll never be used.
+func (c *Client) Upload(ctx context.Context, url string, option UploadOption) (*Response, error) {
+	fields := make([][2]string, 0, len(option.Fields))
+	for name, value := range option.Fields {
+		fields = append(fields, [2]string{name, value})
+	}
+	stream, err := newMultipartStream(fields, option.Files, option.Progress)
+	if err != nil {
+		return nil, err
+	}
+	return c.doStreamingRequest(ctx, http.MethodPost, url, stream)
+}
+
+// newMultipartStream creates the multipart body of form fields and files,
+// and calculates its content length using the file sizes.
+func newMultipartStream(fields [][2]string, files []UploadFile, progress ProgressFunc) (*multipartStream, error) {
+	stream := &multipartStream{
+		boundary: multipart.NewWriter(nil).Boundary(),
+		fields:   fields,
+		files:    make([]UploadFile, len(files)),
+		sizes:    make([]int64, len(files)),
+		progress: progress,
+	}
+	for i, file := range files {
+		info, err := os.Stat(file.Path)
+		if err != nil {
+			return nil, gberror.WrapCodef(gbcode.CodeInvalidParameter, err, `"%s" does not exist`, file.Path)
+		}
+		if info.IsDir() {
+			return nil, gberror.NewCodef(gbcode.CodeInvalidParameter, `"%s" is not a file`, file.Path)
+		}
+		if file.Name == "" {
+			file.Name = gbfile.Basename(file.Path)
+		}
+		stream.files[i] = file
+		stream.sizes[i] = info.Size()
+	}
+	counter := &countWriter{}
+	if err := stream.write(counter, false); err != nil {
+		return nil, err
+	}
+	stream.length = counter.n
+	return stream, nil
+}
+
+// contentType returns the Content-Type of multipart body.
+func (s *multipartStream) contentType() string {
+	return "multipart/form-data; boundary=" + s.boundary
+}
+
+// open returns a new reader of multipart body.
+func (s *multipartStream) open() (io.ReadCloser, error) {
+	return newLazyPipe(s.length, s.progress, func(w io.Writer) error {
+		return s.write(w, true)
+	}), nil
+}
+
+// setRequest sets the body of request `req` as the multipart body.
+func (s *multipartStream) setRequest(req *http.Request) {
+	req.Body, _ = s.open()
+	req.GetBody = s.open
+	req.ContentLength = s.length
+	req.Header.Set(httpHeaderContentType, s.contentType())
+}
+
+// write writes the multipart body to `w`, and the file contents are only counted if `withContent` is false.
+func (s *multipartStream) write(w io.Writer, withContent bool) error {
+	writer := multipart.NewWriter(w)
+	if err := writer.SetBoundary(s.boundary); err != nil {
+		return gberror.Wrap(err, `set multipart boundary failed`)
+	}
+	for _, field := range s.fields {
+		if err := writer.WriteField(field[0], field[1]); err != nil {
+			return gberror.Wrapf(err, `write form field failed with "%s", "%s"`, field[0], field[1])
+		}
+	}
+	for i, file := range s.files {
+		part, err := writer.CreateFormFile(file.Field, file.Name)
+		if err != nil {
+			return gberror.Wrapf(err, `CreateFormFile failed with "%s", "%s"`, file.Field, file.Name)
+		}
+		if !withContent {
+			// The writer is the countWriter when calculating the content length.
+			w.(*countWriter).n += s.sizes[i]
+			continue
+		}
+		if err = copyFile(part, file.Path, s.sizes[i]); err != nil {
+			return err
+		}
+	}
+	// Close finishes the multipart message and writes the trailing
+	// boundary end line to the output.
+	if err := writer.Close(); err != nil {
+		return gberror.Wrapf(err, `form writer close failed`)
+	}
+	return nil
+}
+
+// copyFile copies the content of file `path` to `w`, which should be of size `size` as the content length
+// is calculated with it.
+func copyFile(w io.Writer, path string, size int64) error {
+	f, err := gbfile.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	n, err := io.Copy(w, io.LimitReader(f, size))
+	if err != nil {
+		return gberror.Wrapf(err, `io.Copy failed from "%s"`, path)
+	}
+	if n != size {
+		return gberror.NewCodef(gbcode.CodeOperationFailed, `file "%s" is changed during uploading`, path)
+	}
+	return nil
+}
+
+// newLazyPipe creates and returns a lazyPipe with the content written by `write`.
+func newLazyPipe(total int64, progress ProgressFunc, write func(w io.Writer) error) *lazyPipe {
+	reader, writer := io.Pipe()
+	return &lazyPipe{
+		reader:   reader,
+		writer:   writer,
+		write:    write,
+		total:    total,
+		progress: progress,
+	}
+}
+
+// Read implements the interface io.Reader.
+func (p *lazyPipe) Read(b []byte) (int, error) {
+	p.once.Do(func() {
+		go func() {
+			_ = p.writer.CloseWithError(p.write(p.writer))
+		}()
+	})
+	n, err := p.reader.Read(b)
+	if n > 0 && p.progress != nil {
+		p.read += int64(n)
+		p.progress(p.read, p.total)
+	}
+	return n, err
+}
+
+// Close implements the interface io.Closer, which stops the writing goroutine.
+func (p *lazyPipe) Close() error {
+	return p.reader.Close()
+}
+
+// countWriter counts the bytes written, which is used as the part writer of multipart for calculating
+// the content length.
+type countWriter struct {
+	n int64
+}
+
+// Write implements the interface io.Writer.
+func (w *countWriter) Write(b []byte) (int, error) {
+	w.n += int64(len(b))
+	return len(b), nil
+}
+
+// setStreaming marks the body and response body of request `req` are streamed, and returns the marked request.
+// The body is set as the multipart `stream` if it is not nil.
+func setStreaming(req *http.Request, stream *multipartStream) *http.Request {
+	req = req.WithContext(context.WithValue(req.Context(), streamingRequest, true))
+	if stream != nil {
+		stream.setRequest(req)
+	}
+	return req
+}
+
+// isStreamingRequest checks whether the body of request `req` is streamed.
+func isStreamingRequest(req *http.Request) bool {
+	streaming, _ := req.Context().Value(streamingRequest).(bool)
+	return streaming
+}
+
+// resetRequestBody resets the body of request `req` for the attempt `attempt` of sending.
+// The streaming body is reopened using GetBody, or else the body is replayed from `content`.
+func resetRequestBody(req *http.Request, content []byte, attempt int) error {
+	if !isStreamingRequest(req) {
+		req.Body = utils.NewReadCloser(content, false)
+		return nil
+	}
+	if attempt == 0 {
+		return nil
+	}
+	if req.GetBody == nil {
+		return gberror.NewCodef(gbcode.CodeInvalidOperation, `streaming body of request "%s" cannot be replayed`, req.URL)
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	return nil
+}
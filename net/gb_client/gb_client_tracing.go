@@ -78,7 +78,7 @@ func internalMiddlewareTracing(c *Client, r *http.Request) (response *Response,
 	if err != nil {
 		span.SetStatus(codes.Error, fmt.Sprintf(`%+v`, err))
 	}
-	if response == nil || response.Response == nil {
+	if response == nil || response.Response == nil || isStreamingRequest(r) {
 		return
 	}
 
@@ -36,9 +36,12 @@ func newClientTrace(ctx context.Context, span trace.Span, request *http.Request)
 		headers: make(map[string]interface{}),
 	}
 
-	reqBodyContent, _ := io.ReadAll(ct.request.Body)
-	ct.requestBody = reqBodyContent
-	ct.request.Body = utils.NewReadCloser(reqBodyContent, false)
+	// The streaming body is not recorded, as it might be too large for memory.
+	if !isStreamingRequest(request) {
+		reqBodyContent, _ := io.ReadAll(ct.request.Body)
+		ct.requestBody = reqBodyContent
+		ct.request.Body = utils.NewReadCloser(reqBodyContent, false)
+	}
 
 	return &httptrace.ClientTrace{
 		GetConn:              ct.getConn,
@@ -3,7 +3,12 @@ package gbhttp
 import (
 	gbmap "ghostbb.io/gb/container/gb_map"
 	gbtype "ghostbb.io/gb/container/gb_type"
+	gboai "ghostbb.io/gb/net/gb_oai"
 	gbsvc "ghostbb.io/gb/net/gb_svc"
+	gbtls "ghostbb.io/gb/net/gb_tls"
+	gbws "ghostbb.io/gb/net/gb_ws"
+	gbsession "ghostbb.io/gb/os/gb_session"
+	gbtime "ghostbb.io/gb/os/gb_time"
 	"github.com/gin-gonic/gin"
 	"sync"
 )
@@ -35,19 +40,49 @@ var (
 	// allShutdownChan is the event for all servers have done its serving and exit.
 	// It is used for process blocking purpose.
 	allShutdownChan = make(chan struct{}, 1000)
+
+	// serverProcessInitialized is used for lazy initialization for server.
+	// The process can only be initialized once.
+	serverProcessInitialized = gbtype.NewBool()
+
+	// gracefulEnabled is used for a graceful reload feature, which is false in default.
+	// It is enabled for the whole process if any server enables it.
+	gracefulEnabled = gbtype.NewBool()
+
+	// processMessageHandling marks the process message handler is running.
+	processMessageHandling = gbtype.NewBool()
+
+	// serverActionLocker is the locker for server administration operations.
+	serverActionLocker sync.Mutex
+
+	// serverActionLastTime is timestamp in milliseconds of last administration operation.
+	serverActionLastTime = gbtype.NewInt64(gbtime.TimestampMilli())
 )
 
 type (
 	Server struct {
 		*gin.Engine
-		instance    string            // Instance name of current HTTP server.
-		config      ServerConfig      // Server configuration.
-		servers     []*internalServer // Underlying http.Server array.
-		serverCount *gbtype.Int       // Underlying http.Server number for internal usage.
-		closeChan   chan struct{}     // Used for underlying server closing event notification.
-		serviceMu   sync.Mutex        // Concurrent safety for operations of attribute service.
-		service     gbsvc.Service     // The service for Registry.
-		registrar   gbsvc.Registrar   // Registrar for service register.
+		instance       string                   // Instance name of current HTTP server.
+		config         ServerConfig             // Server configuration.
+		servers        []*internalServer        // Underlying http.Server array.
+		serverCount    *gbtype.Int              // Underlying http.Server number for internal usage.
+		closeChan      chan struct{}            // Used for underlying server closing event notification.
+		serviceMu      sync.Mutex               // Concurrent safety for operations of attribute service.
+		service        gbsvc.Service            // The service for Registry.
+		registrar      gbsvc.Registrar          // Registrar for service register.
+		openapi        *gboai.OpenApiV3         // The OpenAPI document template of the server.
+		sessionOnce    sync.Once                // Used for lazy initialization of session manager.
+		sessionManager *gbsession.Manager       // Session manager of the server.
+		wsMu           sync.Mutex               // Concurrent safety for attribute wsHubs.
+		wsHubs         []*gbws.Hub              // WebSocket hubs attached to the server.
+		certMu         sync.Mutex               // Concurrent safety for attribute certManager.
+		certManager    *gbtls.CertManager       // Certificate manager of HTTPS servers.
+		domainMu       sync.RWMutex             // Concurrent safety for attribute domains.
+		domains        map[string]*domainRouter // Domain patterns to routers for virtual hosts.
+		noRoute        []gin.HandlerFunc        // NoRoute handlers shared with the routers of domains.
+		noMethod       []gin.HandlerFunc        // NoMethod handlers shared with the routers of domains.
+		trustedProxies []string                 // Trusted proxies shared with the routers of domains.
+		builtinPaths   []string                 // Paths of built-in endpoints served for the requests of all domains.
 	}
 
 	// ServerStatus is the server status enum type.
@@ -3,13 +3,16 @@ package gbhttp
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"fmt"
 	gbtype "ghostbb.io/gb/container/gb_type"
 	gbcode "ghostbb.io/gb/errors/gb_code"
 	gberror "ghostbb.io/gb/errors/gb_error"
 	"ghostbb.io/gb/internal/intlog"
+	gboai "ghostbb.io/gb/net/gb_oai"
 	gbsvc "ghostbb.io/gb/net/gb_svc"
 	gbctx "ghostbb.io/gb/os/gb_ctx"
+	gbenv "ghostbb.io/gb/os/gb_env"
 	gbfile "ghostbb.io/gb/os/gb_file"
 	gblog "ghostbb.io/gb/os/gb_log"
 	gbproc "ghostbb.io/gb/os/gb_proc"
@@ -20,6 +23,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/olekukonko/tablewriter"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -40,19 +44,30 @@ func GetServer(name ...interface{}) *Server {
 			closeChan:   make(chan struct{}, 10000),
 			serverCount: gbtype.NewInt(),
 			registrar:   gbsvc.GetRegistry(),
+			openapi:     gboai.New(),
 		}
 		// Initialize the server using default configurations.
 		if err := s.SetConfig(NewConfig()); err != nil {
 			panic(gberror.WrapCode(gbcode.CodeInvalidConfiguration, err, ""))
 		}
 
-		e.Use(s.traceMiddleware(), s.loggerMiddleware(), s.terminal(), s.Recovery())
+		e.Use(s.defaultMiddlewares()...)
 		return s
 	})
 
 	return v.(*Server)
 }
 
+// defaultMiddlewares returns the built-in middlewares of the server,
+// which are also shared with the routers of domains.
+func (s *Server) defaultMiddlewares() []gin.HandlerFunc {
+	return []gin.HandlerFunc{
+		// The body limit is the first, as the tracing middleware reads the request body.
+		s.bodyLimitMiddleware(), s.requestIdMiddleware(), s.traceMiddleware(), s.metricMiddleware(), s.loggerMiddleware(),
+		s.terminal(), s.Recovery(), s.corsMiddleware(), s.securityHeadersMiddleware(),
+	}
+}
+
 // GetName returns the name of the server.
 func (s *Server) GetName() string {
 	return s.config.Name
@@ -74,6 +89,9 @@ func (s *Server) SetConfigWithMap(m map[string]interface{}) error {
 	if k, v := gbutil.MapPossibleItemByKey(m, "MaxHeaderBytes"); k != "" {
 		m[k] = gbfile.StrToSize(gbconv.String(v))
 	}
+	if k, v := gbutil.MapPossibleItemByKey(m, "ClientMaxBodySize"); k != "" {
+		m[k] = gbfile.StrToSize(gbconv.String(v))
+	}
 	// path
 	if k, _ := gbutil.MapPossibleItemByKey(m, "LogPath"); k == "" {
 		m["LogPath"] = s.Logger().GetPath()
@@ -103,13 +121,51 @@ func (s *Server) Start() error {
 		}
 	}
 
+	// Graceful reload feature is enabled for the whole process if any server enables it.
+	if s.config.Graceful {
+		gracefulEnabled.Set(true)
+	}
+	serverProcessInit()
+
+	// Administration endpoints.
+	s.initAdmin()
+
+	// Metrics endpoint.
+	s.initMetric()
+
+	// Health checker and probe endpoints.
+	s.initHealth()
+
+	// OpenAPI document and UI endpoints.
+	s.initOpenApi()
+
+	// Static files serving at root.
+	s.initStatic()
+
+	// Engine settings of domain routers.
+	s.initDomains()
+
 	// ================================================================================================
 	// Start the HTTP server.
+	// If it is reloaded by parent process, it uses the listener file descriptors passed from parent.
 	// ===============================================================================================
-	s.startServer()
+	reloaded := false
+	fdMapStr := gbenv.Get(adminActionReloadEnvKey).String()
+	if len(fdMapStr) > 0 {
+		sfm := bufferToServerFdMap([]byte(fdMapStr))
+		if v, ok := sfm[s.instance]; ok {
+			s.startServer(v)
+			reloaded = true
+		}
+	}
+	if !reloaded {
+		s.startServer(nil)
+	}
+	startProcessMessageHandler()
 
-	// If this is a child process, it then notifies its parent exit.
-	if gbproc.IsChild() {
+	// If this is a reloaded child process, it then notifies its parent exit
+	// after its servers have been serving for GracefulTimeout.
+	if reloaded && gbproc.IsChild() {
 		gbtimer.SetTimeout(ctx, time.Duration(s.config.GracefulTimeout)*time.Second, func(ctx context.Context) {
 			if err := gbproc.Send(gbproc.PPid(), []byte("exit"), adminGProcCommGroup); err != nil {
 				intlog.Errorf(ctx, `server error in process communication: %+v`, err)
@@ -123,13 +179,21 @@ func (s *Server) Start() error {
 	return nil
 }
 
-func (s *Server) startServer() {
+// startServer starts the underlying servers.
+// The parameter `fdMap` specifies the listener file descriptors passed from parent process,
+// which is nil if the server is not reloaded.
+func (s *Server) startServer(fdMap listenerFdMap) {
 	var (
 		ctx          = context.TODO()
 		httpsEnabled bool
+		tlsConfig    *tls.Config
 	)
 	// HTTPS
-	if s.config.HTTPSCertPath != "" && s.config.HTTPSKeyPath != "" {
+	if s.isHttpsConfigured() {
+		var err error
+		if tlsConfig, err = s.newTLSConfig(); err != nil {
+			s.Logger().Fatalf(ctx, `%+v`, err)
+		}
 		if len(s.config.HTTPSAddr) == 0 {
 			if len(s.config.Address) > 0 {
 				s.config.HTTPSAddr = s.config.Address
@@ -137,28 +201,39 @@ func (s *Server) startServer() {
 			} else {
 				s.config.HTTPSAddr = defaultHttpsAddr
 			}
-			httpsEnabled = len(s.config.HTTPSAddr) > 0
-
-			for _, v := range strings.Split(s.config.HTTPSAddr, ",") {
-				if len(v) == 0 {
-					continue
-				}
-
-				s.servers = append(s.servers, s.newInternalServer(v))
-				s.servers[len(s.servers)-1].isHttps = true
+		}
+		httpsEnabled = len(s.config.HTTPSAddr) > 0
+		var array []string
+		if v, ok := fdMap["https"]; ok && len(v) > 0 {
+			array = gbstr.SplitAndTrim(v, ",")
+		} else {
+			array = gbstr.SplitAndTrim(s.config.HTTPSAddr, ",")
+		}
+		for _, v := range array {
+			if len(v) == 0 {
+				continue
 			}
+			address, fd := parseListenerAddrAndFd(v)
+			s.servers = append(s.servers, s.newInternalServer(address, fd))
+			s.servers[len(s.servers)-1].isHttps = true
 		}
 	}
 	// HTTP
 	if !httpsEnabled && len(s.config.Address) == 0 {
 		s.config.Address = defaultHttpAddr
 	}
-
-	for _, v := range gbstr.SplitAndTrim(s.config.Address, ",") {
+	var array []string
+	if v, ok := fdMap["http"]; ok && len(v) > 0 {
+		array = gbstr.SplitAndTrim(v, ",")
+	} else {
+		array = gbstr.SplitAndTrim(s.config.Address, ",")
+	}
+	for _, v := range array {
 		if len(v) == 0 {
 			continue
 		}
-		s.servers = append(s.servers, s.newInternalServer(v))
+		address, fd := parseListenerAddrAndFd(v)
+		s.servers = append(s.servers, s.newInternalServer(address, fd))
 	}
 
 	// Start listening asynchronously.
@@ -172,7 +247,7 @@ func (s *Server) startServer() {
 			// Create listener.
 			if server.isHttps {
 				err = server.CreateListenerTLS(
-					s.config.HTTPSCertPath, s.config.HTTPSKeyPath, s.config.TLSConfig,
+					s.config.HTTPSCertPath, s.config.HTTPSKeyPath, tlsConfig,
 				)
 			} else {
 				err = server.CreateListener()
@@ -256,10 +331,14 @@ func (s *Server) doRouterMapDump() {
 	}
 
 	var (
-		headers = []string{"GROUP", "ADDRESS", "METHOD", "ROUTE", "HANDLER"}
-		routes  = s.GetRoutes()
+		headers      = []string{"GROUP", "ADDRESS", "METHOD", "ROUTE", "HANDLER"}
+		routes       = s.GetRoutes()
+		domainRoutes = s.getDomainRoutes()
 	)
-	if len(routes) > 0 {
+	if len(domainRoutes) > 0 {
+		headers = []string{"GROUP", "ADDRESS", "DOMAIN", "METHOD", "ROUTE", "HANDLER"}
+	}
+	if len(routes) > 0 || len(domainRoutes) > 0 {
 		buffer := bytes.NewBuffer(nil)
 		table := tablewriter.NewWriter(buffer)
 		table.SetHeader(headers)
@@ -270,10 +349,28 @@ func (s *Server) doRouterMapDump() {
 		s.config.Address = gbstr.Replace(s.config.Address, FreePortAddress, fmt.Sprintf(`:%d`, s.GetListenedPort()))
 
 		for _, route := range routes {
+			if len(domainRoutes) > 0 {
+				table.Append([]string{
+					s.instance, s.config.Address, "default", route.Method, route.Path, route.Handler,
+				})
+				continue
+			}
 			table.Append([]string{
 				s.instance, s.config.Address, route.Method, route.Path, route.Handler,
 			})
 		}
+		domains := make([]string, 0, len(domainRoutes))
+		for domain := range domainRoutes {
+			domains = append(domains, domain)
+		}
+		sort.Strings(domains)
+		for _, domain := range domains {
+			for _, route := range domainRoutes[domain] {
+				table.Append([]string{
+					s.instance, s.config.Address, domain, route.Method, route.Path, route.Handler,
+				})
+			}
+		}
 
 		table.Render()
 		s.Logger().Header(false).Stdout(true).Printf(context.TODO(), "\n%s", buffer.String())
@@ -309,6 +406,8 @@ func (s *Server) Bind(obj ...IBind) {
 func (s *Server) Shutdown() error {
 	var ctx = context.TODO()
 	s.doServiceDeregister()
+	s.closeWebSocketHubs(ctx)
+	s.closeCertManager()
 	// Only shut down current servers.
 	// It may have multiple underlying http servers.
 	for _, v := range s.servers {
@@ -0,0 +1,176 @@
+package gbhttp
+
+import (
+	"context"
+	"crypto/subtle"
+	"ghostbb.io/gb/internal/intlog"
+	gbproc "ghostbb.io/gb/os/gb_proc"
+	gbtimer "ghostbb.io/gb/os/gb_timer"
+	gbstr "ghostbb.io/gb/text/gb_str"
+	"github.com/gin-gonic/gin"
+	"net"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+// EnableAdmin enables the built-in administration endpoints for the server.
+// The optional parameter `path` specifies the route prefix, which is "/debug/admin" in default.
+// Note that it should be called before the server starts.
+func (s *Server) EnableAdmin(path ...string) {
+	s.config.AdminEnabled = true
+	if len(path) > 0 && path[0] != "" {
+		s.config.AdminPath = path[0]
+	}
+}
+
+// SetAdminToken sets the token which is required by the administration endpoints.
+// The token is passed using header "Authorization: Bearer xxx".
+// The administration endpoints are not registered if no token is set.
+func (s *Server) SetAdminToken(token string) {
+	s.config.AdminToken = token
+}
+
+// SetAdminAllowIps sets the IPs or CIDRs which are allowed to access the administration endpoints,
+// which are checked against the remote address of connection, not the forwarded headers.
+func (s *Server) SetAdminAllowIps(ips []string) {
+	s.config.AdminAllowIps = ips
+}
+
+// initAdmin registers the administration endpoints if it is enabled.
+// It refuses to register the endpoints without token, as they can restart and shut down the process.
+func (s *Server) initAdmin() {
+	if !s.config.AdminEnabled {
+		return
+	}
+	path := s.config.AdminPath
+	if path == "" {
+		path = defaultAdminPath
+	}
+	if s.config.AdminToken == "" {
+		s.Logger().Errorf(
+			context.TODO(),
+			`administration endpoints "%s" are not registered as no admin token is configured`,
+			path,
+		)
+		return
+	}
+	s.addBuiltinPath(path)
+	group := s.Group(path, s.adminAuthMiddleware())
+	group.POST("/restart", s.adminRestart)
+	group.POST("/shutdown", s.adminShutdown)
+	group.GET("/routes", s.adminRoutes)
+	group.GET("/status", s.adminStatus)
+}
+
+// adminAuthMiddleware checks the token and client ip for administration endpoints.
+func (s *Server) adminAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// The remote address is used instead of ClientIP, which can be forged using header "X-Forwarded-For".
+		if len(s.config.AdminAllowIps) > 0 && !isIpAllowed(c.RemoteIP(), s.config.AdminAllowIps) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "access denied"})
+			return
+		}
+		token := GetBearerToken(c)
+		if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.config.AdminToken)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "invalid token"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// adminRestart restarts all the servers of current process gracefully using the current binary.
+// The new binary file can only be specified using RestartAllServer programmatically.
+func (s *Server) adminRestart(c *gin.Context) {
+	if err := RestartAllServer(Ctx(c)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "server restarting"})
+}
+
+// adminShutdown shuts down all the servers of current process gracefully.
+func (s *Server) adminShutdown(c *gin.Context) {
+	if err := checkProcessStatus(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	// It shuts down the servers after 1 second,
+	// to ensure the response successfully written to the client.
+	gbtimer.SetTimeout(context.Background(), time.Second, func(ctx context.Context) {
+		if err := ShutdownAllServer(ctx); err != nil {
+			s.Logger().Errorf(ctx, `%+v`, err)
+		}
+	})
+	c.JSON(http.StatusOK, gin.H{"message": "server shutting down"})
+}
+
+// adminRoutes responds the route table of current server.
+func (s *Server) adminRoutes(c *gin.Context) {
+	var (
+		routes = s.GetRoutes()
+		items  = make([]gin.H, 0, len(routes))
+	)
+	for _, route := range routes {
+		items = append(items, gin.H{
+			"method":  route.Method,
+			"path":    route.Path,
+			"handler": route.Handler,
+		})
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// adminStatus responds the runtime status of current process and server.
+func (s *Server) adminStatus(c *gin.Context) {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	c.JSON(http.StatusOK, gin.H{
+		"name":       s.GetName(),
+		"pid":        gbproc.Pid(),
+		"ppid":       gbproc.PPid(),
+		"status":     s.Status(),
+		"ports":      s.GetListenedPorts(),
+		"graceful":   gracefulEnabled.Val(),
+		"startTime":  gbproc.StartTime().Format(time.RFC3339),
+		"uptime":     gbproc.Uptime().String(),
+		"goVersion":  runtime.Version(),
+		"goroutines": runtime.NumGoroutine(),
+		"memory": gin.H{
+			"alloc":      memStats.Alloc,
+			"totalAlloc": memStats.TotalAlloc,
+			"sys":        memStats.Sys,
+			"heapAlloc":  memStats.HeapAlloc,
+			"heapInuse":  memStats.HeapInuse,
+			"numGC":      memStats.NumGC,
+		},
+	})
+}
+
+// isIpAllowed checks whether given `ip` matches any item of `allowIps`,
+// which can be a single IP or a CIDR like "192.168.0.0/16".
+func isIpAllowed(ip string, allowIps []string) bool {
+	clientIp := net.ParseIP(ip)
+	if clientIp == nil {
+		return false
+	}
+	for _, item := range allowIps {
+		item = gbstr.Trim(item)
+		if gbstr.Contains(item, "/") {
+			_, ipNet, err := net.ParseCIDR(item)
+			if err != nil {
+				intlog.Errorf(context.TODO(), `invalid admin allow ip "%s": %+v`, item, err)
+				continue
+			}
+			if ipNet.Contains(clientIp) {
+				return true
+			}
+			continue
+		}
+		if allowIp := net.ParseIP(item); allowIp != nil && allowIp.Equal(clientIp) {
+			return true
+		}
+	}
+	return false
+}
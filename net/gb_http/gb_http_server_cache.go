@@ -0,0 +1,391 @@
+package gbhttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"ghostbb.io/gb/internal/json"
+	gbcache "ghostbb.io/gb/os/gb_cache"
+	gbctx "ghostbb.io/gb/os/gb_ctx"
+	gbuid "ghostbb.io/gb/util/gb_uid"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultResponseCachePrefix      = "gb:response:"
+	defaultResponseCacheTTL         = time.Minute
+	defaultResponseCacheMaxBodySize = 1 << 20
+	responseCacheRevalidateTimeout  = 30 * time.Second // Lock timeout of background revalidation.
+
+	// ctxKeyCacheRevalidate marks the request is the background revalidation of stale response.
+	ctxKeyCacheRevalidate gbctx.StrKey = "GbHttpCacheRevalidate"
+)
+
+// ResponseCacheOption is the option for ResponseCache.
+type ResponseCacheOption struct {
+	// Adapter specifies the storage of cached responses, which is in memory in default.
+	// Use gbcache.NewAdapterRedis for sharing among multiple instances.
+	Adapter gbcache.Adapter
+	// Prefix specifies the key prefix in Adapter, which is "gb:response:" in default.
+	Prefix string
+	// MaxBodySize specifies the max size of response body for caching, which is 1MB in default.
+	MaxBodySize int
+}
+
+// CacheRule is the caching rule of route for ResponseCache.Middleware.
+type CacheRule struct {
+	// TTL specifies how long the response is fresh, which is one minute in default.
+	TTL time.Duration
+	// StaleWhileRevalidate specifies how long the stale response is served after TTL,
+	// during which the response is revalidated in background.
+	StaleWhileRevalidate time.Duration
+	// Query specifies the query parameters composing the cache key, and the others are ignored.
+	Query []string
+	// Headers specifies the request headers composing the cache key, like "Accept-Language".
+	Headers []string
+	// Identity returns the identity of request composing the cache key, like the authenticated subject,
+	// which makes the response cached per user. The response is shared by all users if it is nil,
+	// and the request with header "Authorization" or "Cookie" is then not cached, unless its response
+	// has "Cache-Control" of "public" or "s-maxage".
+	Identity func(c *gin.Context) string
+	// Tags specifies the tags of cached response, which are purged by ResponseCache.Purge.
+	Tags []string
+	// TagFunc returns the tags of request besides Tags, like "user:1" for path "/users/1".
+	TagFunc func(c *gin.Context) []string
+}
+
+// ResponseCache caches the GET responses of routes in gbcache.Adapter.
+type ResponseCache struct {
+	server *Server
+	option ResponseCacheOption
+}
+
+// cachedResponse is the stored response.
+type cachedResponse struct {
+	Status   int               `json:"status"`
+	Header   http.Header       `json:"header"`
+	Body     []byte            `json:"body"`
+	StoredAt int64             `json:"storedAt"` // Storing time in milliseconds.
+	Tags     map[string]string `json:"tags"`     // Tags to their versions when the response is stored.
+}
+
+// responseCacheWriter is the response writer capturing the response for caching.
+type responseCacheWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	maxSize  int
+	overflow bool
+}
+
+// discardResponseWriter is the http.ResponseWriter for background revalidation, which discards the response.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+// NewResponseCache creates and returns a ResponseCache for routes of the server.
+func (s *Server) NewResponseCache(option ...ResponseCacheOption) *ResponseCache {
+	var o ResponseCacheOption
+	if len(option) > 0 {
+		o = option[0]
+	}
+	if o.Adapter == nil {
+		o.Adapter = gbcache.NewAdapterMemory()
+	}
+	if o.Prefix == "" {
+		o.Prefix = defaultResponseCachePrefix
+	}
+	if o.MaxBodySize <= 0 {
+		o.MaxBodySize = defaultResponseCacheMaxBodySize
+	}
+	return &ResponseCache{server: s, option: o}
+}
+
+// Middleware returns a middleware caching the GET responses with status 200 and no error of c.Errors using `rule`.
+//
+// The cache is bypassed if the request has header "Cache-Control: no-cache", whose response refreshes the cache,
+// or "Cache-Control: no-store", whose response is not cached. The response with header "Set-Cookie",
+// or "Cache-Control" of "no-store" or "private" is not cached either.
+// The request with credentials is neither served from nor stored to the cache shared by all users, see CacheRule.Identity.
+// The header "X-Cache" of response is "HIT", "STALE" or "MISS", and the header "Age" is set for cached response.
+//
+// Note that the middleware should be used after the authentication middleware,
+// or else the cached responses are served without authentication.
+func (rc *ResponseCache) Middleware(rule CacheRule) gin.HandlerFunc {
+	if rule.TTL <= 0 {
+		rule.TTL = defaultResponseCacheTTL
+	}
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+		var (
+			ctx          = Ctx(c)
+			key          = rc.option.Prefix + rc.cacheKey(c, rule)
+			tags         = rc.requestTags(c, rule)
+			noCache      = hasCacheDirective(c.Request.Header, "no-cache") || c.GetHeader("Pragma") == "no-cache"
+			noStore      = hasCacheDirective(c.Request.Header, "no-store")
+			revalidating = ctx.Value(ctxKeyCacheRevalidate) != nil
+			// The response of request with credentials is private for the shared cache, see RFC 9111 section 3.5.
+			private = rule.Identity == nil && (c.GetHeader("Authorization") != "" || c.GetHeader("Cookie") != "")
+		)
+		if !noCache && !noStore && !revalidating {
+			if cached := rc.get(ctx, key, tags); cached != nil && (!private || isPublicResponse(cached.Header)) {
+				age := time.Since(time.UnixMilli(cached.StoredAt))
+				if age < rule.TTL {
+					rc.write(c, cached, "HIT", age)
+					return
+				}
+				if age < rule.TTL+rule.StaleWhileRevalidate {
+					rc.write(c, cached, "STALE", age)
+					rc.revalidate(c, key)
+					return
+				}
+			}
+		}
+
+		// Tag versions are retrieved before handling, so that the purge during handling invalidates the response.
+		versions, err := rc.tagVersions(ctx, tags)
+		if err != nil {
+			rc.server.Logger().Errorf(ctx, `response cache failed for key "%s": %+v`, key, err)
+			c.Next()
+			return
+		}
+		writer := &responseCacheWriter{ResponseWriter: c.Writer, maxSize: rc.option.MaxBodySize}
+		writer.Header().Set("X-Cache", "MISS")
+		c.Writer = writer
+		c.Next()
+		// The response is stored after it is written, which is the envelope if ResponseMiddleware is enabled.
+		afterResponseWritten(c, func() {
+			c.Writer = writer.ResponseWriter
+			if revalidating {
+				// The lock of revalidation is released no matter whether the response is cached.
+				defer func() { _, _ = rc.option.Adapter.Remove(ctx, key+":revalidate") }()
+			}
+			if noStore || (private && !isPublicResponse(writer.Header())) {
+				return
+			}
+			rc.store(c, writer, key, rule, versions)
+		})
+	}
+}
+
+// store stores the response captured by `writer` to the cache if it is successful and cacheable.
+func (rc *ResponseCache) store(
+	c *gin.Context, writer *responseCacheWriter, key string, rule CacheRule, versions map[string]string,
+) {
+	if len(c.Errors) > 0 || !writer.Written() || writer.overflow ||
+		writer.Status() != http.StatusOK || !isCacheableResponse(writer.Header()) {
+		return
+	}
+	header := writer.Header().Clone()
+	for _, name := range []string{"Content-Length", "Date", "X-Cache", "Age", rc.server.requestIdHeader()} {
+		header.Del(name)
+	}
+	content, err := json.Marshal(cachedResponse{
+		Status:   writer.Status(),
+		Header:   header,
+		Body:     writer.body.Bytes(),
+		StoredAt: time.Now().UnixMilli(),
+		Tags:     versions,
+	})
+	ctx := Ctx(c)
+	if err == nil {
+		err = rc.option.Adapter.Set(ctx, key, string(content), rule.TTL+rule.StaleWhileRevalidate)
+	}
+	if err != nil {
+		rc.server.Logger().Errorf(ctx, `response cache failed for key "%s": %+v`, key, err)
+	}
+}
+
+// Purge invalidates all cached responses with any of `tags`.
+func (rc *ResponseCache) Purge(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		if err := rc.option.Adapter.Set(ctx, rc.tagKey(tag), gbuid.S(), 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// get returns the cached response of `key`, which is nil if it is absent or purged by any of `tags`.
+func (rc *ResponseCache) get(ctx context.Context, key string, tags []string) *cachedResponse {
+	v, err := rc.option.Adapter.Get(ctx, key)
+	if err != nil {
+		rc.server.Logger().Errorf(ctx, `response cache failed for key "%s": %+v`, key, err)
+		return nil
+	}
+	if v.IsNil() {
+		return nil
+	}
+	var cached cachedResponse
+	if err = json.Unmarshal(v.Bytes(), &cached); err != nil {
+		return nil
+	}
+	versions, err := rc.tagVersions(ctx, tags)
+	if err != nil {
+		return nil
+	}
+	for tag, version := range versions {
+		if cached.Tags[tag] != version {
+			return nil
+		}
+	}
+	return &cached
+}
+
+// write writes the cached response with cache status `status`.
+func (rc *ResponseCache) write(c *gin.Context, cached *cachedResponse, status string, age time.Duration) {
+	header := c.Writer.Header()
+	for name, values := range cached.Header {
+		header[name] = values
+	}
+	header.Set("X-Cache", status)
+	header.Set("Age", strconv.FormatInt(int64(age.Seconds()), 10))
+	c.Status(cached.Status)
+	_, _ = c.Writer.Write(cached.Body)
+	c.Abort()
+}
+
+// revalidate refreshes the stale response of `key` in background by serving a copy of current request,
+// which is done by only one request at the same time.
+func (rc *ResponseCache) revalidate(c *gin.Context, key string) {
+	ctx := Ctx(c)
+	ok, err := rc.option.Adapter.SetIfNotExist(ctx, key+":revalidate", gbuid.S(), responseCacheRevalidateTimeout)
+	if err != nil || !ok {
+		return
+	}
+	request := c.Request.Clone(context.WithValue(context.Background(), ctxKeyCacheRevalidate, true))
+	request.Header.Del("If-None-Match")
+	request.Header.Del("If-Modified-Since")
+	go rc.server.ServeHTTP(&discardResponseWriter{header: make(http.Header)}, request)
+}
+
+// cacheKey builds the cache key of request using the path, whitelisted query parameters,
+// selected headers and identity of `rule`.
+func (rc *ResponseCache) cacheKey(c *gin.Context, rule CacheRule) string {
+	var (
+		buffer = bytes.NewBufferString(c.Request.Host + " " + c.Request.URL.Path)
+		query  = c.Request.URL.Query()
+		names  = append([]string(nil), rule.Query...)
+	)
+	sort.Strings(names)
+	for _, name := range names {
+		buffer.WriteString("\nq:" + name + "=" + strings.Join(query[name], ","))
+	}
+	for _, name := range rule.Headers {
+		buffer.WriteString("\nh:" + name + "=" + strings.Join(c.Request.Header.Values(name), ","))
+	}
+	if rule.Identity != nil {
+		buffer.WriteString("\ni:" + rule.Identity(c))
+	}
+	hash := sha256.Sum256(buffer.Bytes())
+	return hex.EncodeToString(hash[:])
+}
+
+// requestTags returns the tags of request according to `rule`.
+func (rc *ResponseCache) requestTags(c *gin.Context, rule CacheRule) []string {
+	tags := append([]string(nil), rule.Tags...)
+	if rule.TagFunc != nil {
+		tags = append(tags, rule.TagFunc(c)...)
+	}
+	return tags
+}
+
+// tagVersions returns the current versions of `tags`, which is empty for the tag never purged.
+func (rc *ResponseCache) tagVersions(ctx context.Context, tags []string) (map[string]string, error) {
+	versions := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		v, err := rc.option.Adapter.Get(ctx, rc.tagKey(tag))
+		if err != nil {
+			return nil, err
+		}
+		versions[tag] = v.String()
+	}
+	return versions, nil
+}
+
+// tagKey returns the key of version of `tag`.
+func (rc *ResponseCache) tagKey(tag string) string {
+	return rc.option.Prefix + "tag:" + tag
+}
+
+// hasCacheDirective checks whether the header "Cache-Control" contains `directive`.
+func hasCacheDirective(header http.Header, directive string) bool {
+	for _, value := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), directive) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isCacheableResponse checks whether the response with `header` can be cached and shared.
+func isCacheableResponse(header http.Header) bool {
+	if header.Get("Set-Cookie") != "" {
+		return false
+	}
+	return !hasCacheDirective(header, "no-store") && !hasCacheDirective(header, "private")
+}
+
+// isPublicResponse checks whether the response with `header` is explicitly allowed to be stored by shared cache
+// for the request with credentials.
+func isPublicResponse(header http.Header) bool {
+	if hasCacheDirective(header, "public") {
+		return true
+	}
+	for _, value := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(value, ",") {
+			if name, _, _ := strings.Cut(strings.TrimSpace(part), "="); strings.EqualFold(name, "s-maxage") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Write writes `data` to the underlying writer and captures it.
+func (w *responseCacheWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString writes string `s` to the underlying writer and captures it.
+func (w *responseCacheWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// capture captures `data` until the max size is exceeded.
+func (w *responseCacheWriter) capture(data []byte) {
+	if w.overflow {
+		return
+	}
+	if w.body.Len()+len(data) > w.maxSize {
+		w.overflow = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(data)
+}
+
+// Header implements the interface http.ResponseWriter.
+func (w *discardResponseWriter) Header() http.Header {
+	return w.header
+}
+
+// Write implements the interface http.ResponseWriter.
+func (w *discardResponseWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+// WriteHeader implements the interface http.ResponseWriter.
+func (w *discardResponseWriter) WriteHeader(int) {}
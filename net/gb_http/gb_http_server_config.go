@@ -4,31 +4,41 @@ import (
 	"context"
 	"crypto/tls"
 	"ghostbb.io/gb/internal/intlog"
+	gbtls "ghostbb.io/gb/net/gb_tls"
 	gblog "ghostbb.io/gb/os/gb_log"
+	gbsession "ghostbb.io/gb/os/gb_session"
 	gbstr "ghostbb.io/gb/text/gb_str"
 	"net"
 	"time"
 )
 
 const (
-	defaultHttpAddr  = ":80"  // Default listening port for HTTP.
-	defaultHttpsAddr = ":443" // Default listening port for HTTPS.
+	defaultHttpAddr      = ":80"          // Default listening port for HTTP.
+	defaultHttpsAddr     = ":443"         // Default listening port for HTTPS.
+	defaultAdminPath     = "/debug/admin" // Default route prefix for administration endpoints.
+	defaultMetricPath    = "/metrics"     // Default route for Prometheus metrics endpoint.
+	defaultLivenessPath  = "/healthz"     // Default route for liveness probe endpoint.
+	defaultReadinessPath = "/readyz"      // Default route for readiness probe endpoint.
 )
 
 type ServerConfig struct {
-	Name           string         `json:"name"`
-	Address        string         `json:"address"`
-	HTTPSAddr      string         `json:"httpsAddr"`
-	Listeners      []net.Listener `json:"listeners"`
-	Endpoints      []string       `json:"endpoints"` // Endpoints are custom endpoints for service register, it uses Address if empty.
-	HTTPSCertPath  string         `json:"httpsCertPath"`
-	HTTPSKeyPath   string         `json:"httpsKeyPath"`
-	TLSConfig      *tls.Config    `json:"tlsConfig"`
-	ReadTimeout    time.Duration  `json:"read-timeout"`
-	WriteTimeout   time.Duration  `json:"write-timeout"`
-	IdleTimeout    time.Duration  `json:"idle-timeout"`
-	MaxHeaderBytes int            `json:"max-header-bytes"`
-	KeepAlive      bool           `json:"keep-alive"`
+	Name              string          `json:"name"`
+	Address           string          `json:"address"`
+	HTTPSAddr         string          `json:"httpsAddr"`
+	Listeners         []net.Listener  `json:"listeners"`
+	Endpoints         []string        `json:"endpoints"` // Endpoints are custom endpoints for service register, it uses Address if empty.
+	HTTPSCertPath     string          `json:"httpsCertPath"`
+	HTTPSKeyPath      string          `json:"httpsKeyPath"`
+	TLSConfig         *tls.Config     `json:"tlsConfig"`
+	TLSCertificates   []gbtls.KeyPair `json:"tlsCertificates"` // TLSCertificates are additional certificates chosen by SNI of client besides HTTPSCertPath.
+	TLSClientCA       string          `json:"tlsClientCA"`     // TLSClientCA is the CA bundle file for verifying client certificates, which enables mTLS.
+	TLSClientAuth     string          `json:"tlsClientAuth"`   // TLSClientAuth is the client certificate policy with TLSClientCA: "require" in default or "optional".
+	ReadTimeout       time.Duration   `json:"read-timeout"`
+	WriteTimeout      time.Duration   `json:"write-timeout"`
+	IdleTimeout       time.Duration   `json:"idle-timeout"`
+	MaxHeaderBytes    int             `json:"max-header-bytes"`
+	ClientMaxBodySize int64           `json:"clientMaxBodySize"` // ClientMaxBodySize specifies the max size of request body, which is no limit in default or if it is not positive.
+	KeepAlive         bool            `json:"keep-alive"`
 
 	// ======================================================================================================
 	// Logging.
@@ -43,6 +53,90 @@ type ServerConfig struct {
 	ErrorLogPattern  string        `json:"errorLogPattern"`  // ErrorLogPattern specifies the error log file pattern like: error-{Ymd}.log
 	AccessLogEnabled bool          `json:"accessLogEnabled"` // AccessLogEnabled enables access logging content to files.
 	AccessLogPattern string        `json:"accessLogPattern"` // AccessLogPattern specifies the access log file pattern like: access-{Ymd}.log
+	LogResponseBody  bool          `json:"logResponseBody"`  // LogResponseBody specifies whether logging the response body, which is limited by gbtrace.MaxContentLogSize.
+
+	// ======================================================================================================
+	// Admin.
+	// ======================================================================================================
+	AdminEnabled  bool     `json:"adminEnabled"`  // AdminEnabled enables the built-in administration endpoints.
+	AdminPath     string   `json:"adminPath"`     // AdminPath specifies the route prefix for administration endpoints.
+	AdminToken    string   `json:"adminToken"`    // AdminToken specifies the token required by administration endpoints, which are not registered without it.
+	AdminAllowIps []string `json:"adminAllowIps"` // AdminAllowIps specifies the IPs or CIDRs of remote address allowed to access administration endpoints.
+
+	// ======================================================================================================
+	// Metric.
+	// ======================================================================================================
+	MetricEnabled bool   `json:"metricEnabled"` // MetricEnabled enables the request metrics and the Prometheus metrics endpoint.
+	MetricPath    string `json:"metricPath"`    // MetricPath specifies the route of the Prometheus metrics endpoint.
+
+	// ======================================================================================================
+	// Health.
+	// ======================================================================================================
+	HealthEnabled bool   `json:"healthEnabled"` // HealthEnabled enables the liveness and readiness probe endpoints.
+	LivenessPath  string `json:"livenessPath"`  // LivenessPath specifies the route of the liveness probe endpoint.
+	ReadinessPath string `json:"readinessPath"` // ReadinessPath specifies the route of the readiness probe endpoint.
+
+	// HealthShutdownDelay specifies the delay between marking the process not ready and shutting down the servers,
+	// which gives the load balancer time to stop routing traffic to current process.
+	HealthShutdownDelay time.Duration `json:"healthShutdownDelay"`
+
+	// ======================================================================================================
+	// OpenAPI.
+	// ======================================================================================================
+	OpenApiPath     string `json:"openapiPath"`     // OpenApiPath specifies the route of the OpenAPI document, which is disabled if empty.
+	SwaggerPath     string `json:"swaggerPath"`     // SwaggerPath specifies the route of the API document UI, which is disabled if empty.
+	SwaggerUI       string `json:"swaggerUI"`       // SwaggerUI specifies the API document UI, which is "swagger" or "redoc".
+	SwaggerResource string `json:"swaggerResource"` // SwaggerResource specifies the gbres directory of the API document UI files packed by application, which is "/swagger-ui" or "/redoc" in default.
+
+	// ======================================================================================================
+	// Session.
+	// ======================================================================================================
+	SessionIdName       string            `json:"sessionIdName"`       // SessionIdName specifies the session id name in header and cookie.
+	SessionMaxAge       time.Duration     `json:"sessionMaxAge"`       // SessionMaxAge specifies the expiration of session.
+	SessionPath         string            `json:"sessionPath"`         // SessionPath specifies the directory of file storage, which uses memory storage if empty.
+	SessionStorage      gbsession.Storage `json:"sessionStorage"`      // SessionStorage specifies the custom session storage like gbsession.StorageRedis.
+	SessionCookieOutput bool              `json:"sessionCookieOutput"` // SessionCookieOutput specifies whether outputting the session id to cookie.
+	SessionCookieSecure bool              `json:"sessionCookieSecure"` // SessionCookieSecure specifies whether the session cookie is only sent over HTTPS.
+
+	// ======================================================================================================
+	// Static.
+	// ======================================================================================================
+	ServerRoot          string        `json:"serverRoot"`          // ServerRoot specifies the directory of static files served at root, which is searched in gbres first and then on disk.
+	IndexFiles          []string      `json:"indexFiles"`          // IndexFiles specifies the index files searched for static directory.
+	SpaFallback         bool          `json:"spaFallback"`         // SpaFallback serves the root index file for not found html request of path without extension, which is used for single page application.
+	SpaExcludePrefixes  []string      `json:"spaExcludePrefixes"`  // SpaExcludePrefixes specifies the path prefixes without SpaFallback, like "/api".
+	StaticMaxAge        time.Duration `json:"staticMaxAge"`        // StaticMaxAge specifies the max-age of Cache-Control header for static files except html.
+	StaticPrecompressed bool          `json:"staticPrecompressed"` // StaticPrecompressed serves the precompressed ".gz" variant of static file if it exists.
+	StaticDotFiles      bool          `json:"staticDotFiles"`      // StaticDotFiles serves the static files of path segment starting with ".", like ".well-known".
+
+	// ======================================================================================================
+	// CORS.
+	// ======================================================================================================
+	CorsEnabled          bool          `json:"corsEnabled"`          // CorsEnabled enables the CORS handling for cross-origin requests.
+	CorsAllowOrigins     []string      `json:"corsAllowOrigins"`     // CorsAllowOrigins specifies the allowed origins, supporting "*", wildcard like "https://*.example.com" and "regex:" prefixed pattern.
+	CorsAllowMethods     []string      `json:"corsAllowMethods"`     // CorsAllowMethods specifies the allowed methods for preflight requests.
+	CorsAllowHeaders     []string      `json:"corsAllowHeaders"`     // CorsAllowHeaders specifies the allowed request headers, "*" allows all requested headers.
+	CorsExposeHeaders    []string      `json:"corsExposeHeaders"`    // CorsExposeHeaders specifies the response headers exposed to the browser.
+	CorsAllowCredentials bool          `json:"corsAllowCredentials"` // CorsAllowCredentials specifies whether the request can include credentials like cookies.
+	CorsMaxAge           time.Duration `json:"corsMaxAge"`           // CorsMaxAge specifies how long the preflight result can be cached by the browser.
+
+	// ======================================================================================================
+	// Security Headers.
+	// ======================================================================================================
+	SecurityHeadersEnabled bool          `json:"securityHeadersEnabled"` // SecurityHeadersEnabled enables the security response headers.
+	HstsMaxAge             time.Duration `json:"hstsMaxAge"`             // HstsMaxAge specifies the max-age of Strict-Transport-Security header, which is not sent if it is zero.
+	HstsIncludeSubDomains  bool          `json:"hstsIncludeSubDomains"`  // HstsIncludeSubDomains adds the includeSubDomains directive to Strict-Transport-Security header.
+	HstsPreload            bool          `json:"hstsPreload"`            // HstsPreload adds the preload directive to Strict-Transport-Security header.
+	ContentSecurityPolicy  string        `json:"contentSecurityPolicy"`  // ContentSecurityPolicy specifies the Content-Security-Policy header, which is not sent if empty.
+	FrameOptions           string        `json:"frameOptions"`           // FrameOptions specifies the X-Frame-Options header like "DENY" or "SAMEORIGIN", which is not sent if empty.
+	ReferrerPolicy         string        `json:"referrerPolicy"`         // ReferrerPolicy specifies the Referrer-Policy header, which is not sent if empty.
+	ContentTypeNosniff     bool          `json:"contentTypeNosniff"`     // ContentTypeNosniff specifies whether sending "X-Content-Type-Options: nosniff" header.
+
+	// ======================================================================================================
+	// Request ID.
+	// ======================================================================================================
+	RequestIdEnabled bool   `json:"requestIdEnabled"` // RequestIdEnabled enables accepting or generating the request id for log correlation.
+	RequestIdHeader  string `json:"requestIdHeader"`  // RequestIdHeader specifies the header name of request id, which is "X-Request-Id" in default.
 
 	// DumpRouterMap specifies whether automatically dumps router map when server starts.
 	DumpRouterMap bool `json:"dumpRouterMap"`
@@ -76,6 +170,25 @@ func NewConfig() ServerConfig {
 		ErrorLogPattern:         "error-{Ymd}.log",
 		AccessLogEnabled:        false,
 		AccessLogPattern:        "access-{Ymd}.log",
+		AdminEnabled:            false,
+		AdminPath:               defaultAdminPath,
+		MetricEnabled:           false,
+		MetricPath:              defaultMetricPath,
+		HealthEnabled:           false,
+		LivenessPath:            defaultLivenessPath,
+		ReadinessPath:           defaultReadinessPath,
+		SwaggerUI:               SwaggerUISwagger,
+		SessionIdName:           defaultSessionIdName,
+		SessionMaxAge:           gbsession.DefaultTTL,
+		SessionCookieOutput:     true,
+		IndexFiles:              defaultIndexFiles,
+		CorsAllowMethods:        defaultCorsAllowMethods,
+		CorsAllowHeaders:        defaultCorsAllowHeaders,
+		FrameOptions:            "SAMEORIGIN",
+		ReferrerPolicy:          "strict-origin-when-cross-origin",
+		ContentTypeNosniff:      true,
+		RequestIdEnabled:        true,
+		RequestIdHeader:         defaultRequestIdHeader,
 		DumpRouterMap:           true,
 		Graceful:                false,
 		GracefulTimeout:         2, // seconds
@@ -90,6 +203,11 @@ func (s *Server) SetConfig(c ServerConfig) error {
 		s.config.Address = ":" + s.config.Address
 	}
 
+	// CORS.
+	if err := checkCorsAllowOrigins(s.config.CorsAllowOrigins); err != nil {
+		return err
+	}
+
 	// Logging.
 	if s.config.LogPath != "" && s.config.LogPath != s.config.Logger.GetPath() {
 		if err := s.config.Logger.SetPath(s.config.LogPath); err != nil {
@@ -0,0 +1,277 @@
+package gbhttp
+
+import (
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	"github.com/gin-gonic/gin"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	// ctxKeyDomain is the gin context key for the matched domain pattern of current request.
+	ctxKeyDomain = "GbHttpDomain"
+	// ctxKeyDomainParam is the gin context key for the subdomain captured by wildcard domain pattern.
+	ctxKeyDomainParam = "GbHttpDomainParam"
+)
+
+// domainRouter is the router serving the requests of one or more domain patterns.
+type domainRouter struct {
+	engine   *gin.Engine
+	patterns []string
+}
+
+// Domain returns the router group serving the requests whose Host header matches any of `domains`.
+//
+// The domain is either exact like "api.example.com", or wildcard like "*.tenant.example.com",
+// which matches a single subdomain label, and the captured label is retrieved by GetDomainParam.
+// The exact domain takes precedence over the wildcard one, and the requests matching no domain
+// are served by the routes of the server itself.
+//
+// The routes of domains are independent of the server's routes and each other, but the routers of domains
+// share the middlewares added by Server.Use, the handlers of Server.NoRoute and Server.NoMethod,
+// the trusted proxies and the engine settings of the server.
+// The built-in endpoints like health probes, metrics, administration and OpenAPI document are served
+// by the server for the requests of all domains.
+// Calling Domain with a registered domain returns the same router group.
+func (s *Server) Domain(domains ...string) *gin.RouterGroup {
+	if len(domains) == 0 {
+		panic(gberror.NewCode(gbcode.CodeInvalidParameter, `domain should not be empty`))
+	}
+	patterns := make([]string, len(domains))
+	for i, domain := range domains {
+		pattern := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if pattern == "" || strings.Contains(strings.TrimPrefix(pattern, "*."), "*") {
+			panic(gberror.NewCodef(gbcode.CodeInvalidParameter, `invalid domain "%s"`, domain))
+		}
+		patterns[i] = pattern
+	}
+
+	s.domainMu.Lock()
+	defer s.domainMu.Unlock()
+	if s.domains == nil {
+		s.domains = make(map[string]*domainRouter)
+	}
+	var router *domainRouter
+	for _, pattern := range patterns {
+		if r, ok := s.domains[pattern]; ok {
+			if router != nil && router != r {
+				panic(gberror.NewCodef(
+					gbcode.CodeInvalidParameter,
+					`domain "%s" is already registered with different domains`, pattern,
+				))
+			}
+			router = r
+		}
+	}
+	if router == nil {
+		router = &domainRouter{engine: s.newDomainEngine()}
+	}
+	for _, pattern := range patterns {
+		if _, ok := s.domains[pattern]; !ok {
+			s.domains[pattern] = router
+			router.patterns = append(router.patterns, pattern)
+		}
+	}
+	return &router.engine.RouterGroup
+}
+
+// Use adds the middlewares to the server and the routers of all domains, see gin.Engine.Use.
+// Note that it should be called before the routes are registered.
+func (s *Server) Use(middleware ...gin.HandlerFunc) gin.IRoutes {
+	s.domainMu.Lock()
+	defer s.domainMu.Unlock()
+	for _, router := range s.domainRouters() {
+		router.engine.Use(middleware...)
+	}
+	return s.Engine.Use(middleware...)
+}
+
+// NoRoute sets the handlers for not found routes of the server and the routers of all domains,
+// see gin.Engine.NoRoute.
+func (s *Server) NoRoute(handlers ...gin.HandlerFunc) {
+	s.domainMu.Lock()
+	defer s.domainMu.Unlock()
+	s.noRoute = handlers
+	for _, router := range s.domainRouters() {
+		router.engine.NoRoute(handlers...)
+	}
+	s.Engine.NoRoute(handlers...)
+}
+
+// NoMethod sets the handlers for not allowed methods of the server and the routers of all domains,
+// see gin.Engine.NoMethod.
+func (s *Server) NoMethod(handlers ...gin.HandlerFunc) {
+	s.domainMu.Lock()
+	defer s.domainMu.Unlock()
+	s.noMethod = handlers
+	for _, router := range s.domainRouters() {
+		router.engine.NoMethod(handlers...)
+	}
+	s.Engine.NoMethod(handlers...)
+}
+
+// SetTrustedProxies sets the trusted proxies of the server and the routers of all domains,
+// see gin.Engine.SetTrustedProxies.
+func (s *Server) SetTrustedProxies(trustedProxies []string) error {
+	s.domainMu.Lock()
+	defer s.domainMu.Unlock()
+	if err := s.Engine.SetTrustedProxies(trustedProxies); err != nil {
+		return err
+	}
+	s.trustedProxies = trustedProxies
+	for _, router := range s.domainRouters() {
+		_ = router.engine.SetTrustedProxies(trustedProxies)
+	}
+	return nil
+}
+
+// GetDomain returns the matched domain pattern of current request, which is empty if the
+// request is not served by the router group of Domain.
+func GetDomain(c *gin.Context) string {
+	return c.GetString(ctxKeyDomain)
+}
+
+// GetDomainParam returns the subdomain label captured by the wildcard domain pattern,
+// eg: "acme" for Host "acme.tenant.example.com" matching "*.tenant.example.com".
+// It returns empty string if the request matches an exact domain or no domain.
+func GetDomainParam(c *gin.Context) string {
+	return c.GetString(ctxKeyDomainParam)
+}
+
+// ServeHTTP dispatches the request to the router of matched domain,
+// or the routes of the server itself if no domain matches.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if router, _, _ := s.matchDomain(r.Host); router != nil && !s.isBuiltinPath(r.URL.Path) {
+		router.engine.ServeHTTP(w, r)
+		return
+	}
+	s.Engine.ServeHTTP(w, r)
+}
+
+// matchDomain returns the router of domain matching `host` along with the matched pattern
+// and the captured subdomain label.
+func (s *Server) matchDomain(host string) (router *domainRouter, pattern, param string) {
+	s.domainMu.RLock()
+	defer s.domainMu.RUnlock()
+	if len(s.domains) == 0 {
+		return nil, "", ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if router = s.domains[host]; router != nil {
+		return router, host, ""
+	}
+	if index := strings.Index(host, "."); index > 0 {
+		pattern = "*" + host[index:]
+		if router = s.domains[pattern]; router != nil {
+			return router, pattern, host[:index]
+		}
+	}
+	return nil, "", ""
+}
+
+// domainMiddleware stores the matched domain and captured subdomain label in the context.
+func (s *Server) domainMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, pattern, param := s.matchDomain(c.Request.Host); pattern != "" {
+			c.Set(ctxKeyDomain, pattern)
+			c.Set(ctxKeyDomainParam, param)
+		}
+		c.Next()
+	}
+}
+
+// getDomainRoutes returns the routes of all domains, which is keyed by the joined domain patterns.
+func (s *Server) getDomainRoutes() map[string]gin.RoutesInfo {
+	s.domainMu.RLock()
+	defer s.domainMu.RUnlock()
+	routes := make(map[string]gin.RoutesInfo)
+	for _, router := range s.domains {
+		key := strings.Join(router.patterns, ",")
+		if _, ok := routes[key]; !ok {
+			routes[key] = router.engine.Routes()
+		}
+	}
+	return routes
+}
+
+// newDomainEngine creates the engine of domain router, which shares the middlewares,
+// the NoRoute and NoMethod handlers and the trusted proxies of the server.
+func (s *Server) newDomainEngine() *gin.Engine {
+	engine := gin.New()
+	engine.Use(s.domainMiddleware())
+	// The handlers of server are the built-in middlewares and the ones added by Server.Use.
+	engine.Use(s.Engine.Handlers...)
+	if len(s.noRoute) > 0 {
+		engine.NoRoute(s.noRoute...)
+	}
+	if len(s.noMethod) > 0 {
+		engine.NoMethod(s.noMethod...)
+	}
+	if s.trustedProxies != nil {
+		_ = engine.SetTrustedProxies(s.trustedProxies)
+	}
+	return engine
+}
+
+// initDomains copies the engine settings of the server to the routers of all domains,
+// which is called when the server starts, as the settings may be changed after Domain is called.
+func (s *Server) initDomains() {
+	s.domainMu.Lock()
+	defer s.domainMu.Unlock()
+	for _, router := range s.domainRouters() {
+		e := router.engine
+		e.RedirectTrailingSlash = s.Engine.RedirectTrailingSlash
+		e.RedirectFixedPath = s.Engine.RedirectFixedPath
+		e.HandleMethodNotAllowed = s.Engine.HandleMethodNotAllowed
+		e.ForwardedByClientIP = s.Engine.ForwardedByClientIP
+		e.RemoteIPHeaders = s.Engine.RemoteIPHeaders
+		e.TrustedPlatform = s.Engine.TrustedPlatform
+		e.UseRawPath = s.Engine.UseRawPath
+		e.UnescapePathValues = s.Engine.UnescapePathValues
+		e.RemoveExtraSlash = s.Engine.RemoveExtraSlash
+		e.MaxMultipartMemory = s.Engine.MaxMultipartMemory
+		e.ContextWithFallback = s.Engine.ContextWithFallback
+		e.HTMLRender = s.Engine.HTMLRender
+		e.FuncMap = s.Engine.FuncMap
+	}
+}
+
+// domainRouters returns the distinct routers of all domains, which should be called with domainMu locked.
+func (s *Server) domainRouters() []*domainRouter {
+	var (
+		routers = make([]*domainRouter, 0, len(s.domains))
+		seen    = make(map[*domainRouter]bool, len(s.domains))
+	)
+	for _, router := range s.domains {
+		if !seen[router] {
+			seen[router] = true
+			routers = append(routers, router)
+		}
+	}
+	return routers
+}
+
+// addBuiltinPath marks the routes under `path` as the built-in endpoints,
+// which are served by the server for the requests of all domains.
+func (s *Server) addBuiltinPath(path string) {
+	s.domainMu.Lock()
+	defer s.domainMu.Unlock()
+	s.builtinPaths = append(s.builtinPaths, "/"+strings.Trim(path, "/"))
+}
+
+// isBuiltinPath checks whether `path` is of the built-in endpoints.
+func (s *Server) isBuiltinPath(path string) bool {
+	s.domainMu.RLock()
+	defer s.domainMu.RUnlock()
+	for _, builtin := range s.builtinPaths {
+		if path == builtin || strings.HasPrefix(path, builtin+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,393 @@
+package gbhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	"ghostbb.io/gb/internal/json"
+	gbctx "ghostbb.io/gb/os/gb_ctx"
+	gbstr "ghostbb.io/gb/text/gb_str"
+	gbconv "ghostbb.io/gb/util/gb_conv"
+	gbmeta "ghostbb.io/gb/util/gb_meta"
+	gbtag "ghostbb.io/gb/util/gb_tag"
+	gbutil "ghostbb.io/gb/util/gb_util"
+	gbvalid "ghostbb.io/gb/util/gb_valid"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"io"
+	"net/http"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const (
+	ctxKeyGinContext       gbctx.StrKey = "GbHttpGinContext"      // Context key for the gin.Context of current request.
+	ctxKeyHandlerResponse               = "GbHttpHandlerResponse" // Gin context key for the response object of typed handler.
+	defaultMultipartMemory              = 32 << 20                // Max memory for parsing multipart form, 32MB.
+)
+
+// HandlerItem is the information of a registered typed handler,
+// which is commonly used for route documentation like OpenAPI.
+type HandlerItem struct {
+	Method   string            // Method of the route, which is empty if it accepts all methods.
+	Path     string            // Full path of the route like "/api/user/:id".
+	Request  reflect.Type      // Struct type of the request object.
+	Response reflect.Type      // Type of the response object.
+	Meta     map[string]string // Meta data of the request object from g.Meta.
+}
+
+var (
+	// handlerItems stores all registered typed handlers of current process.
+	handlerItems   = make([]HandlerItem, 0)
+	handlerItemsMu sync.RWMutex
+
+	// Reflect types for typed handler signature checks.
+	reflectTypeContext = reflect.TypeOf((*context.Context)(nil)).Elem()
+	reflectTypeError   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// BindHandler registers typed handlers to the root group of the server.
+// See package function BindHandler.
+func (s *Server) BindHandler(object ...interface{}) {
+	BindHandler(&s.RouterGroup, object...)
+}
+
+// BindHandler registers typed handlers to `group`.
+//
+// The parameter `object` can be a function or an object whose exported methods are handlers.
+// The handler should be defined as:
+//
+//	func(ctx context.Context, req *XxxReq) (res *XxxRes, err error)
+//
+// in which XxxReq embeds g.Meta with route information like:
+//
+//	type XxxReq struct {
+//		g.Meta `path:"/user/:id" method:"get" tags:"User" summary:"Get user"`
+//		Id     int `v:"required"`
+//	}
+//
+// The request object is automatically bound from path, query, form and JSON body,
+// filled with `d` tag default values and validated using `v` tag rules.
+// The method of object not matching the handler definition is ignored,
+// but it panics if the handler definition has no route path in g.Meta.
+func BindHandler(group *gin.RouterGroup, object ...interface{}) {
+	for _, o := range object {
+		reflectValue := reflect.ValueOf(o)
+		if reflectValue.Kind() == reflect.Func {
+			if err := checkHandlerFunc(reflectValue.Type()); err != nil {
+				panic(err)
+			}
+			bindHandlerFunc(group, reflectValue)
+			continue
+		}
+		for i := 0; i < reflectValue.NumMethod(); i++ {
+			method := reflectValue.Method(i)
+			if checkHandlerFunc(method.Type()) != nil {
+				continue
+			}
+			bindHandlerFunc(group, method)
+		}
+	}
+}
+
+// GetHandlerItems returns all registered typed handlers of current process.
+func GetHandlerItems() []HandlerItem {
+	handlerItemsMu.RLock()
+	defer handlerItemsMu.RUnlock()
+	items := make([]HandlerItem, len(handlerItems))
+	copy(items, handlerItems)
+	return items
+}
+
+// GetHandlerResponse returns the response object of typed handler for current request.
+// It returns nil if the request is not handled by typed handler or the handler returns nil.
+func GetHandlerResponse(c *gin.Context) interface{} {
+	v, _ := c.Get(ctxKeyHandlerResponse)
+	return v
+}
+
+// GinContext retrieves and returns the gin.Context from the context of typed handler.
+// It returns nil if the context is not from typed handler.
+func GinContext(ctx context.Context) *gin.Context {
+	if c, ok := ctx.Value(ctxKeyGinContext).(*gin.Context); ok {
+		return c
+	}
+	return nil
+}
+
+// checkHandlerFunc checks whether `reflectType` is a typed handler function.
+func checkHandlerFunc(reflectType reflect.Type) error {
+	if reflectType.NumIn() != 2 || reflectType.NumOut() != 2 {
+		return gberror.NewCodef(
+			gbcode.CodeInvalidParameter,
+			`invalid handler "%s", it should be defined as "func(context.Context, *XxxReq) (*XxxRes, error)"`,
+			reflectType.String(),
+		)
+	}
+	if reflectType.In(0) != reflectTypeContext {
+		return gberror.NewCodef(
+			gbcode.CodeInvalidParameter,
+			`invalid handler "%s", the first input parameter should be type of "context.Context"`,
+			reflectType.String(),
+		)
+	}
+	if reflectType.In(1).Kind() != reflect.Ptr || reflectType.In(1).Elem().Kind() != reflect.Struct {
+		return gberror.NewCodef(
+			gbcode.CodeInvalidParameter,
+			`invalid handler "%s", the second input parameter should be type of pointer to struct like "*XxxReq"`,
+			reflectType.String(),
+		)
+	}
+	if reflectType.Out(1) != reflectTypeError {
+		return gberror.NewCodef(
+			gbcode.CodeInvalidParameter,
+			`invalid handler "%s", the last output parameter should be type of "error"`,
+			reflectType.String(),
+		)
+	}
+	return nil
+}
+
+// bindHandlerFunc registers the typed handler function to `group` using its g.Meta route information.
+func bindHandlerFunc(group *gin.RouterGroup, fn reflect.Value) {
+	var (
+		reqType  = fn.Type().In(1)
+		meta     = gbmeta.Data(reflect.New(reqType.Elem()).Interface())
+		route    = meta[gbtag.Path]
+		methods  = gbstr.SplitAndTrim(meta[gbtag.Method], ",")
+		handler  = newTypedHandler(fn)
+		fullPath = joinPaths(group.BasePath(), route)
+	)
+	if route == "" {
+		panic(gberror.NewCodef(
+			gbcode.CodeInvalidParameter,
+			`invalid handler "%s", missing route path in g.Meta of "%s"`,
+			fn.Type().String(), reqType.Elem().String(),
+		))
+	}
+	if len(methods) == 0 {
+		group.Any(route, handler)
+		addHandlerItem("", fullPath, fn.Type(), meta)
+		return
+	}
+	for _, method := range methods {
+		method = strings.ToUpper(method)
+		group.Handle(method, route, handler)
+		addHandlerItem(method, fullPath, fn.Type(), meta)
+	}
+}
+
+// addHandlerItem stores the registered typed handler information.
+func addHandlerItem(method, fullPath string, fnType reflect.Type, meta map[string]string) {
+	handlerItemsMu.Lock()
+	defer handlerItemsMu.Unlock()
+	handlerItems = append(handlerItems, HandlerItem{
+		Method:   method,
+		Path:     fullPath,
+		Request:  fnType.In(1).Elem(),
+		Response: fnType.Out(0),
+		Meta:     meta,
+	})
+}
+
+// newTypedHandler creates and returns a gin.HandlerFunc calling typed handler `fn`.
+func newTypedHandler(fn reflect.Value) gin.HandlerFunc {
+	reqType := fn.Type().In(1).Elem()
+	return func(c *gin.Context) {
+		var (
+			ctx = context.WithValue(Ctx(c), ctxKeyGinContext, c)
+			req = reflect.New(reqType)
+		)
+		if err := Parse(c, req.Interface()); err != nil {
+			writeHandlerResponse(c, nil, err)
+			return
+		}
+		var (
+			results = fn.Call([]reflect.Value{reflect.ValueOf(ctx), req})
+			res     interface{}
+			err     error
+		)
+		if !isNilValue(results[0]) {
+			res = results[0].Interface()
+		}
+		if !isNilValue(results[1]) {
+			err = results[1].Interface().(error)
+		}
+		writeHandlerResponse(c, res, err)
+	}
+}
+
+// Parse binds the request parameters from path, query, form and JSON body to `pointer`,
+// fills it with `d` tag default values and validates it using `v` tag rules.
+// The path parameters have the highest priority, then the body, and the query the lowest.
+func Parse(c *gin.Context, pointer interface{}) error {
+	params, err := getRequestParams(c)
+	if err != nil {
+		return err
+	}
+	if err = gbutil.FillStructWithDefault(pointer); err != nil {
+		return err
+	}
+	if err = gbconv.Struct(params, pointer); err != nil {
+		return gberror.WrapCode(gbcode.CodeInvalidParameter, err, "Parse request parameters failed")
+	}
+	if err = gbvalid.New().Data(pointer).Assoc(params).Run(Ctx(c)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// getRequestParams retrieves and merges all request parameters as map.
+func getRequestParams(c *gin.Context) (map[string]interface{}, error) {
+	params := make(map[string]interface{})
+	// Query.
+	for k, v := range c.Request.URL.Query() {
+		params[k] = queryValue(v)
+	}
+	// Body.
+	if c.Request.Body != nil && c.Request.ContentLength != 0 {
+		switch c.ContentType() {
+		case binding.MIMEJSON:
+			body, err := io.ReadAll(c.Request.Body)
+			if err != nil {
+				return nil, wrapReadBodyError(err)
+			}
+			// Restore the body for the later usage.
+			c.Request.Body = io.NopCloser(strings.NewReader(string(body)))
+			if len(body) > 0 {
+				bodyMap := make(map[string]interface{})
+				if err = json.UnmarshalUseNumber(body, &bodyMap); err != nil {
+					return nil, gberror.WrapCode(gbcode.CodeInvalidRequest, err, "Parse request json failed")
+				}
+				for k, v := range bodyMap {
+					params[k] = v
+				}
+			}
+
+		case binding.MIMEMultipartPOSTForm:
+			if err := c.Request.ParseMultipartForm(defaultMultipartMemory); err != nil {
+				if isBodyTooLarge(err) {
+					return nil, wrapReadBodyError(err)
+				}
+				return nil, gberror.WrapCode(gbcode.CodeInvalidRequest, err, "Parse request multipart form failed")
+			}
+			for k, v := range c.Request.MultipartForm.Value {
+				params[k] = queryValue(v)
+			}
+			for k, v := range c.Request.MultipartForm.File {
+				if len(v) == 1 {
+					params[k] = v[0]
+				} else {
+					params[k] = v
+				}
+			}
+
+		case binding.MIMEPOSTForm:
+			if err := c.Request.ParseForm(); err != nil {
+				if isBodyTooLarge(err) {
+					return nil, wrapReadBodyError(err)
+				}
+				return nil, gberror.WrapCode(gbcode.CodeInvalidRequest, err, "Parse request form failed")
+			}
+			for k, v := range c.Request.PostForm {
+				params[k] = queryValue(v)
+			}
+		}
+	}
+	// Path.
+	for _, param := range c.Params {
+		params[param.Key] = param.Value
+	}
+	return params, nil
+}
+
+// writeHandlerResponse writes the result of typed handler to the response.
+// It writes the response object as JSON if success,
+// or else the error message with status according to the error code, see CodeToStatus.
+func writeHandlerResponse(c *gin.Context, res interface{}, err error) {
+	c.Set(ctxKeyHandlerResponse, res)
+	if err != nil {
+		_ = c.Error(err)
+	}
+	// The response is written by ResponseMiddleware if it is enabled.
+	if c.Writer.Written() || isResponseMiddlewareEnabled(c) {
+		return
+	}
+	if err != nil {
+		c.JSON(errorToStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// queryValue returns the only element if `values` has one element, or else the slice itself.
+func queryValue(values []string) interface{} {
+	if len(values) == 1 {
+		return values[0]
+	}
+	return values
+}
+
+// isNilValue checks whether the reflect value of interface or pointer is nil.
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	}
+	return false
+}
+
+// joinPaths joins the group base path and relative path like gin does.
+func joinPaths(absolutePath, relativePath string) string {
+	if relativePath == "" {
+		return absolutePath
+	}
+	finalPath := path.Join(absolutePath, relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(finalPath, "/") {
+		return finalPath + "/"
+	}
+	return finalPath
+}
+
+// String implements fmt.Stringer for HandlerItem.
+func (item HandlerItem) String() string {
+	return fmt.Sprintf(`%s %s => %s`, item.Method, item.Path, item.Request.String())
+}
+
+// bodyLimitMiddleware limits the size of request body to ClientMaxBodySize,
+// so that reading the body of a large request fails instead of exhausting the memory.
+func (s *Server) bodyLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s.config.ClientMaxBodySize > 0 && c.Request.Body != nil && c.Request.Body != http.NoBody {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, s.config.ClientMaxBodySize)
+		}
+		c.Next()
+	}
+}
+
+// errorToStatus returns the HTTP status of `err`, which is 413 for the request body exceeding ClientMaxBodySize,
+// or else the status of its error code, see CodeToStatus.
+func errorToStatus(err error) int {
+	if isBodyTooLarge(err) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return CodeToStatus(gberror.Code(err))
+}
+
+// isBodyTooLarge checks whether `err` is caused by the request body exceeding ClientMaxBodySize.
+func isBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+// wrapReadBodyError wraps the error of reading request body.
+func wrapReadBodyError(err error) error {
+	if isBodyTooLarge(err) {
+		return gberror.WrapCode(gbcode.CodeInvalidRequest, err, "Request body too large")
+	}
+	return gberror.WrapCode(gbcode.CodeInvalidRequest, err, "Read request body failed")
+}
@@ -0,0 +1,65 @@
+package gbhttp
+
+import (
+	"context"
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	gbhealth "ghostbb.io/gb/os/gb_health"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+const (
+	healthCheckerPrefix = "server." // Name prefix of the health checker for each server.
+)
+
+// SetHealthEnabled enables or disables the liveness and readiness probe endpoints.
+// Note that it should be called before the server starts.
+func (s *Server) SetHealthEnabled(enabled bool) {
+	s.config.HealthEnabled = enabled
+}
+
+// initHealth registers the health checker of current server,
+// and the probe endpoints if it is enabled.
+func (s *Server) initHealth() {
+	gbhealth.RegisterFunc(healthCheckerPrefix+s.instance, s.checkHealth)
+	if !s.config.HealthEnabled {
+		return
+	}
+	livenessPath := s.config.LivenessPath
+	if livenessPath == "" {
+		livenessPath = defaultLivenessPath
+	}
+	readinessPath := s.config.ReadinessPath
+	if readinessPath == "" {
+		readinessPath = defaultReadinessPath
+	}
+	s.addBuiltinPath(livenessPath)
+	s.addBuiltinPath(readinessPath)
+	s.GET(livenessPath, func(c *gin.Context) {
+		writeHealthResult(c, gbhealth.Liveness(Ctx(c)))
+	})
+	s.GET(readinessPath, func(c *gin.Context) {
+		writeHealthResult(c, gbhealth.Readiness(Ctx(c)))
+	})
+}
+
+// checkHealth checks whether current server is running.
+// Note that the readiness probe fails once the process starts shutting down,
+// which is controlled by gbhealth.SetReady in shutdownWebServersGracefully.
+func (s *Server) checkHealth(ctx context.Context) error {
+	if s.Status() != ServerStatusRunning {
+		return gberror.NewCode(gbcode.CodeInvalidOperation, "server is not running")
+	}
+	return nil
+}
+
+// writeHealthResult writes the probe result as JSON,
+// with status 200 if it is up, or else 503.
+func writeHealthResult(c *gin.Context, result gbhealth.Result) {
+	status := http.StatusOK
+	if result.Status != gbhealth.StatusUp {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, result)
+}
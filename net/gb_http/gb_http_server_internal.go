@@ -12,6 +12,7 @@ import (
 	gbstr "ghostbb.io/gb/text/gb_str"
 	"net"
 	"net/http"
+	"os"
 	"strconv"
 	"sync"
 	"time"
@@ -19,6 +20,7 @@ import (
 
 type internalServer struct {
 	server      *Server      // Belonged server.
+	fd          uintptr      // File descriptor inherited from parent process for graceful reload.
 	address     string       // Listening address like:":80", ":8080".
 	httpServer  *http.Server // Underlying http.Server.
 	rawListener net.Listener // Underlying net.Listener.
@@ -28,7 +30,9 @@ type internalServer struct {
 	listener    net.Listener // Wrapped net.Listener.
 }
 
-func (s *Server) newInternalServer(address string) *internalServer {
+// newInternalServer creates and returns an internalServer with given address.
+// The optional parameter `fd` specifies the file descriptor which is passed from parent process.
+func (s *Server) newInternalServer(address string, fd ...int) *internalServer {
 	// Change port to address like: 80 -> :80
 	if gbstr.IsNumeric(address) {
 		address = ":" + address
@@ -40,6 +44,9 @@ func (s *Server) newInternalServer(address string) *internalServer {
 		httpServer: s.newHttpServer(address),
 		status:     gbtype.NewInt(),
 	}
+	if len(fd) > 0 && fd[0] > 0 {
+		is.fd = uintptr(fd[0])
+	}
 
 	if s.config.Listeners != nil {
 		addrArray := gbstr.SplitAndTrim(address, ":")
@@ -60,7 +67,7 @@ func (s *Server) newInternalServer(address string) *internalServer {
 func (s *Server) newHttpServer(address string) *http.Server {
 	server := &http.Server{
 		Addr:           address,
-		Handler:        s.Engine,
+		Handler:        s,
 		ReadTimeout:    s.config.ReadTimeout,
 		WriteTimeout:   s.config.WriteTimeout,
 		IdleTimeout:    s.config.IdleTimeout,
@@ -86,7 +93,7 @@ func (s *internalServer) CreateListenerTLS(certFile, keyFile string, tlsConfig .
 		config.NextProtos = []string{"http/1.1"}
 	}
 	var err error
-	if len(config.Certificates) == 0 {
+	if len(config.Certificates) == 0 && config.GetCertificate == nil {
 		config.Certificates = make([]tls.Certificate, 1)
 		if gbres.Contains(certFile) && gbres.Contains(keyFile) {
 			config.Certificates[0], err = tls.X509KeyPair(
@@ -115,8 +122,23 @@ func (s *internalServer) getNetListener() (net.Listener, error) {
 	if s.rawListener != nil {
 		return s.rawListener, nil
 	}
+	var (
+		ln  net.Listener
+		err error
+	)
+	if s.fd > 0 {
+		// The listener is inherited from parent process.
+		f := os.NewFile(s.fd, "")
+		ln, err = net.FileListener(f)
+		if err != nil {
+			return nil, gberror.Wrap(err, "net.FileListener failed")
+		}
+		// The net.FileListener duplicates the descriptor, so the inherited one should be closed.
+		_ = f.Close()
+		return ln, nil
+	}
 
-	ln, err := net.Listen("tcp", s.httpServer.Addr)
+	ln, err = net.Listen("tcp", s.httpServer.Addr)
 	if err != nil {
 		err = gberror.Wrapf(err, `net.Listen address "%s" failed`, s.httpServer.Addr)
 	}
@@ -145,6 +167,9 @@ func (s *internalServer) Serve(ctx context.Context) error {
 	}
 
 	action := "started"
+	if s.fd != 0 {
+		action = "reloaded"
+	}
 
 	s.server.Logger().Infof(
 		ctx,
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"ghostbb.io/gb/internal/instance"
+	gbtrace "ghostbb.io/gb/net/gb_trace"
 	gblog "ghostbb.io/gb/os/gb_log"
 	gbstr "ghostbb.io/gb/text/gb_str"
 	"github.com/gin-gonic/gin"
@@ -29,8 +30,15 @@ func (s *Server) loggerMiddleware() gin.HandlerFunc {
 			}
 			ctx = Ctx(c)
 		)
+		var recorder *ResponseRecorder
+		if s.config.LogResponseBody {
+			recorder = RecordResponse(c, gbtrace.MaxContentLogSize())
+		}
 		c.Next()
 		m["body"], _ = c.GetRawData()
+		if recorder != nil {
+			m["response"] = recorder.BodyString()
+		}
 		err := gbstr.TrimRight(errorsString(c.Errors.ByType(gin.ErrorTypePrivate)), "\n")
 
 		logger := instance.GetOrSetFuncLock(loggerInstanceKey, func() interface{} {
@@ -0,0 +1,192 @@
+package gbhttp
+
+import (
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	gbregex "ghostbb.io/gb/text/gb_regex"
+	gbstr "ghostbb.io/gb/text/gb_str"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	corsOriginAny         = "*"      // Allows any origin.
+	corsOriginRegexPrefix = "regex:" // Prefix of origin pattern which is a regular expression.
+)
+
+var (
+	// defaultCorsAllowMethods is the default allowed methods for preflight requests.
+	defaultCorsAllowMethods = []string{
+		http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodHead, http.MethodOptions,
+	}
+	// defaultCorsAllowHeaders is the default allowed request headers for preflight requests.
+	defaultCorsAllowHeaders = []string{
+		"Origin", "Accept", "Content-Type", "Authorization", "X-Requested-With",
+	}
+)
+
+// SetCorsEnabled enables or disables the CORS handling for cross-origin requests.
+// The optional parameter `origins` specifies the allowed origins, see SetCorsAllowOrigins.
+func (s *Server) SetCorsEnabled(enabled bool, origins ...string) error {
+	s.config.CorsEnabled = enabled
+	if len(origins) > 0 {
+		return s.SetCorsAllowOrigins(origins...)
+	}
+	return nil
+}
+
+// SetCorsAllowOrigins sets the allowed origins for CORS.
+// The origin can be "*" for any origin, wildcard like "https://*.example.com",
+// or regular expression prefixed with "regex:" like "regex:^https://[a-z]+\.example\.com$".
+func (s *Server) SetCorsAllowOrigins(origins ...string) error {
+	if err := checkCorsAllowOrigins(origins); err != nil {
+		return err
+	}
+	s.config.CorsAllowOrigins = origins
+	return nil
+}
+
+// SetCorsAllowCredentials sets whether the cross-origin request can include credentials like cookies.
+func (s *Server) SetCorsAllowCredentials(enabled bool) {
+	s.config.CorsAllowCredentials = enabled
+}
+
+// corsMiddleware handles the CORS preflight requests and adds the CORS headers to the
+// cross-origin requests whose origin is allowed.
+//
+// The preflight request is responded directly without being passed to the route handler,
+// and it is rejected with 403 if its origin or method is not allowed.
+// Other requests from not allowed origins are passed without CORS headers,
+// so that the browser blocks the response, and the same-origin requests with Origin header still work.
+func (s *Server) corsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.config.CorsEnabled {
+			c.Next()
+			return
+		}
+		var (
+			header    = c.Writer.Header()
+			origin    = c.GetHeader("Origin")
+			preflight = c.Request.Method == http.MethodOptions &&
+				c.GetHeader("Access-Control-Request-Method") != ""
+		)
+		if origin == "" {
+			c.Next()
+			return
+		}
+		allowOrigin, ok := s.corsAllowOrigin(origin)
+		// The response differs by the request origin if it does not allow any origin.
+		if allowOrigin != corsOriginAny {
+			header.Add("Vary", "Origin")
+		}
+		if preflight {
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+			if !ok || !s.corsAllowMethod(c.GetHeader("Access-Control-Request-Method")) {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			s.setCorsOriginHeaders(c, allowOrigin)
+			c.Header("Access-Control-Allow-Methods", strings.Join(s.config.CorsAllowMethods, ","))
+			if allowHeaders := s.corsAllowHeaders(c.GetHeader("Access-Control-Request-Headers")); allowHeaders != "" {
+				c.Header("Access-Control-Allow-Headers", allowHeaders)
+			}
+			if s.config.CorsMaxAge > 0 {
+				c.Header("Access-Control-Max-Age", strconv.FormatInt(int64(s.config.CorsMaxAge/time.Second), 10))
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		if ok {
+			s.setCorsOriginHeaders(c, allowOrigin)
+			if len(s.config.CorsExposeHeaders) > 0 {
+				c.Header("Access-Control-Expose-Headers", strings.Join(s.config.CorsExposeHeaders, ","))
+			}
+		}
+		c.Next()
+	}
+}
+
+// setCorsOriginHeaders sets the allowed origin and credentials headers.
+func (s *Server) setCorsOriginHeaders(c *gin.Context, allowOrigin string) {
+	c.Header("Access-Control-Allow-Origin", allowOrigin)
+	if s.config.CorsAllowCredentials {
+		c.Header("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// corsAllowOrigin checks whether the `origin` is allowed, and returns the value of
+// Access-Control-Allow-Origin header for it.
+// Note that it returns the request origin instead of "*" if credentials are allowed,
+// as browsers reject "*" for credentialed requests.
+func (s *Server) corsAllowOrigin(origin string) (string, bool) {
+	for _, pattern := range s.config.CorsAllowOrigins {
+		if pattern == corsOriginAny {
+			if s.config.CorsAllowCredentials {
+				return origin, true
+			}
+			return corsOriginAny, true
+		}
+		if corsMatchOrigin(pattern, origin) {
+			return origin, true
+		}
+	}
+	return "", false
+}
+
+// corsAllowMethod checks whether the requested `method` is allowed.
+func (s *Server) corsAllowMethod(method string) bool {
+	for _, v := range s.config.CorsAllowMethods {
+		if v == corsOriginAny || strings.EqualFold(v, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// corsAllowHeaders returns the value of Access-Control-Allow-Headers header for the `requested` headers.
+// The requested headers are reflected if "*" is configured.
+func (s *Server) corsAllowHeaders(requested string) string {
+	for _, v := range s.config.CorsAllowHeaders {
+		if v == corsOriginAny {
+			return requested
+		}
+	}
+	return strings.Join(s.config.CorsAllowHeaders, ",")
+}
+
+// corsMatchOrigin checks whether the `origin` matches the wildcard or regular expression `pattern`.
+func corsMatchOrigin(pattern, origin string) bool {
+	if strings.HasPrefix(pattern, corsOriginRegexPrefix) {
+		return gbregex.IsMatchString(pattern[len(corsOriginRegexPrefix):], origin)
+	}
+	if !gbstr.Contains(pattern, "*") {
+		return strings.EqualFold(pattern, origin)
+	}
+	return gbregex.IsMatchString(corsWildcardToRegex(pattern), origin)
+}
+
+// corsWildcardToRegex converts the wildcard origin pattern to regular expression,
+// in which the "*" matches one or more host name labels.
+func corsWildcardToRegex(pattern string) string {
+	return `(?i)^` + strings.ReplaceAll(gbregex.Quote(pattern), `\*`, `[a-z0-9-]+(\.[a-z0-9-]+)*`) + `$`
+}
+
+// checkCorsAllowOrigins checks whether the regular expressions in `origins` are valid.
+func checkCorsAllowOrigins(origins []string) error {
+	for _, pattern := range origins {
+		if !strings.HasPrefix(pattern, corsOriginRegexPrefix) {
+			continue
+		}
+		if err := gbregex.Validate(pattern[len(corsOriginRegexPrefix):]); err != nil {
+			return gberror.WrapCodef(
+				gbcode.CodeInvalidConfiguration, err, `invalid CORS origin pattern "%s"`, pattern,
+			)
+		}
+	}
+	return nil
+}
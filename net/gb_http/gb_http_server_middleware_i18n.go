@@ -0,0 +1,139 @@
+package gbhttp
+
+import (
+	gbi18n "ghostbb.io/gb/i18n/gb_i18n"
+	"github.com/gin-gonic/gin"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// CtxKeyI18nLanguage is the gin context key for the negotiated language, retrieved by GetI18nLanguage.
+	CtxKeyI18nLanguage = "GbHttpI18nLanguage"
+
+	defaultI18nQueryName  = "lang"
+	defaultI18nCookieName = "lang"
+	maxAcceptLanguages    = 16
+)
+
+// I18nOption is the option for I18nMiddleware.
+type I18nOption struct {
+	// Manager specifies the i18n manager whose loaded languages are matched, which is gbi18n.Instance() in default.
+	Manager *gbi18n.Manager
+	// QueryName specifies the query parameter name of language, which is "lang" in default.
+	QueryName string
+	// CookieName specifies the cookie name of language, which is "lang" in default.
+	CookieName string
+	// Default specifies the language used if no requested language is loaded,
+	// which is the default language of Manager in default.
+	Default string
+}
+
+// acceptLanguage is one language range of header "Accept-Language".
+type acceptLanguage struct {
+	tag     string
+	quality float64
+}
+
+// GetI18nLanguage returns the negotiated language of current request.
+// It returns empty string if the I18nMiddleware is not used.
+func GetI18nLanguage(c *gin.Context) string {
+	return c.GetString(CtxKeyI18nLanguage)
+}
+
+// I18nMiddleware returns a middleware which negotiates the language of request and injects it into
+// the request context using gbi18n.WithLanguage, so that the messages of gbvalid and the views of gbview
+// are localized automatically when they are called with Ctx(c).
+//
+// The language is picked from the query parameter, the cookie, and then header "Accept-Language"
+// ordered by q-value, and the first one matching the languages loaded by Manager is used
+// with fallback like "zh-TW" to "zh", see gbi18n.Manager.MatchLanguage. It uses the default language if none matches.
+// The negotiated language is sent in response header "Content-Language".
+func (s *Server) I18nMiddleware(option ...I18nOption) gin.HandlerFunc {
+	o := newI18nOption(option...)
+	return func(c *gin.Context) {
+		language := negotiateLanguage(c, o)
+		if language == "" {
+			language = o.Default
+		}
+		if language == "" {
+			language = o.Manager.GetLanguage()
+		}
+		c.Set(CtxKeyI18nLanguage, language)
+		c.Request = c.Request.WithContext(gbi18n.WithLanguage(c.Request.Context(), language))
+		c.Header("Content-Language", language)
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Next()
+	}
+}
+
+// newI18nOption returns the I18nOption with defaults filled.
+func newI18nOption(option ...I18nOption) I18nOption {
+	var o I18nOption
+	if len(option) > 0 {
+		o = option[0]
+	}
+	if o.Manager == nil {
+		o.Manager = gbi18n.Instance()
+	}
+	if o.QueryName == "" {
+		o.QueryName = defaultI18nQueryName
+	}
+	if o.CookieName == "" {
+		o.CookieName = defaultI18nCookieName
+	}
+	return o
+}
+
+// negotiateLanguage returns the first requested language that matches the loaded languages of manager.
+func negotiateLanguage(c *gin.Context, o I18nOption) string {
+	if language := o.Manager.MatchLanguage(c.Query(o.QueryName)); language != "" {
+		return language
+	}
+	if cookie, err := c.Cookie(o.CookieName); err == nil {
+		if language := o.Manager.MatchLanguage(cookie); language != "" {
+			return language
+		}
+	}
+	for _, item := range parseAcceptLanguage(c.GetHeader("Accept-Language")) {
+		if language := o.Manager.MatchLanguage(item.tag); language != "" {
+			return language
+		}
+	}
+	return ""
+}
+
+// parseAcceptLanguage parses header "Accept-Language" like "zh-TW,zh;q=0.9,en;q=0.8",
+// and returns the language ranges ordered by q-value descending.
+// The wildcard "*", the ranges with q-value 0 and the invalid q-values are ignored.
+func parseAcceptLanguage(header string) []acceptLanguage {
+	if header == "" {
+		return nil
+	}
+	var languages []acceptLanguage
+	for _, part := range strings.Split(header, ",") {
+		if len(languages) >= maxAcceptLanguages {
+			break
+		}
+		tag, params, _ := strings.Cut(part, ";")
+		if tag = strings.TrimSpace(tag); tag == "" || tag == "*" {
+			continue
+		}
+		item := acceptLanguage{tag: tag, quality: 1}
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			quality, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || quality < 0 || quality > 1 {
+				continue
+			}
+			item.quality = quality
+		}
+		if item.quality > 0 {
+			languages = append(languages, item)
+		}
+	}
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+	return languages
+}
@@ -0,0 +1,294 @@
+package gbhttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	"ghostbb.io/gb/internal/json"
+	gbcache "ghostbb.io/gb/os/gb_cache"
+	gbstr "ghostbb.io/gb/text/gb_str"
+	gbuid "ghostbb.io/gb/util/gb_uid"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultIdempotencyHeader      = "Idempotency-Key"
+	defaultIdempotencyTTL         = 24 * time.Hour
+	defaultIdempotencyLockTimeout = time.Minute
+	defaultIdempotencyMaxBodySize = 1 << 20
+	defaultIdempotencyKeyPrefix   = "gb:idempotency:"
+	maxIdempotencyKeyLength       = 255
+)
+
+// IdempotencyOption is the option for IdempotencyMiddleware.
+type IdempotencyOption struct {
+	// Adapter specifies the storage of locks and responses, which is in memory in default.
+	// Use gbcache.NewAdapterRedis for sharing among multiple instances.
+	Adapter gbcache.Adapter
+	// Header specifies the header name of idempotency key, which is "Idempotency-Key" in default.
+	Header string
+	// TTL specifies how long the response is stored for replaying, which is 24 hours in default.
+	TTL time.Duration
+	// LockTimeout specifies the expiration of lock, which releases the key if the process crashes
+	// during handling the request. It is one minute in default.
+	LockTimeout time.Duration
+	// Methods specifies the methods that the middleware applies to, which are POST and PATCH in default.
+	Methods []string
+	// Required specifies whether the idempotency key is required, which responds status 400 if it is missing.
+	Required bool
+	// Scope returns the scope of idempotency key, like the authenticated subject, so that the same key
+	// of different clients does not conflict. It is the value of CtxKeyJwtSubject in default.
+	Scope func(c *gin.Context) string
+	// MaxBodySize specifies the max size of response body for storing, which is 1MB in default.
+	// The response exceeding the size is not stored, and the key is released.
+	MaxBodySize int
+}
+
+// idempotencyRecord is the stored record of idempotency key,
+// which is either a lock of request in progress or a completed response.
+type idempotencyRecord struct {
+	Lock        string      `json:"lock,omitempty"`   // Lock is the unique token of request holding the key.
+	Fingerprint string      `json:"fingerprint"`      // Fingerprint is the hash of request method, path and body.
+	Status      int         `json:"status,omitempty"` // Status is the response status.
+	Header      http.Header `json:"header,omitempty"` // Header is the response header.
+	Body        []byte      `json:"body,omitempty"`   // Body is the response body.
+}
+
+// idempotencyWriter is the response writer capturing the response for storing.
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	maxSize  int
+	overflow bool
+}
+
+// IdempotencyMiddleware returns a middleware which ensures the request with the same idempotency key
+// is handled only once, for safely retrying non-idempotent requests like payments.
+//
+// The first request locks the key, and its response status, headers and body are stored for TTL.
+// The duplicate requests get the stored response replayed with header "Idempotent-Replayed: true",
+// or status 409 if the first request is still in progress, or status 422 if the request body differs.
+// The response with status 5xx or errors of c.Errors is not stored and the key is released,
+// so that the request can be retried.
+// The rejections are written as the error envelope with the same status by ResponseMiddleware if it is enabled.
+// Note that the request is handled without idempotency if the storage fails.
+func (s *Server) IdempotencyMiddleware(option ...IdempotencyOption) gin.HandlerFunc {
+	var o IdempotencyOption
+	if len(option) > 0 {
+		o = option[0]
+	}
+	if o.Adapter == nil {
+		o.Adapter = gbcache.NewAdapterMemory()
+	}
+	if o.Header == "" {
+		o.Header = defaultIdempotencyHeader
+	}
+	if o.TTL <= 0 {
+		o.TTL = defaultIdempotencyTTL
+	}
+	if o.LockTimeout <= 0 {
+		o.LockTimeout = defaultIdempotencyLockTimeout
+	}
+	if len(o.Methods) == 0 {
+		o.Methods = []string{http.MethodPost, http.MethodPatch}
+	}
+	if o.Scope == nil {
+		o.Scope = func(c *gin.Context) string {
+			return c.GetString(CtxKeyJwtSubject)
+		}
+	}
+	if o.MaxBodySize <= 0 {
+		o.MaxBodySize = defaultIdempotencyMaxBodySize
+	}
+	return func(c *gin.Context) {
+		if !gbstr.InArray(o.Methods, c.Request.Method) {
+			c.Next()
+			return
+		}
+		key := c.GetHeader(o.Header)
+		if key == "" {
+			if o.Required {
+				abortWithError(c, http.StatusBadRequest, gberror.NewCode(
+					gbcode.CodeMissingParameter, "missing "+o.Header+" header",
+				))
+				return
+			}
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			abortWithError(c, http.StatusBadRequest, gberror.NewCode(
+				gbcode.CodeInvalidParameter, "invalid "+o.Header+" header",
+			))
+			return
+		}
+		fingerprint, err := idempotencyFingerprint(c)
+		if err != nil {
+			err = wrapReadBodyError(err)
+			abortWithError(c, errorToStatus(err), err)
+			return
+		}
+		var (
+			ctx      = Ctx(c)
+			cacheKey = defaultIdempotencyKeyPrefix + o.Scope(c) + ":" + key
+			lock     = idempotencyRecord{Lock: gbuid.S(), Fingerprint: fingerprint}
+		)
+		acquired, record, err := acquireIdempotencyKey(ctx, o.Adapter, cacheKey, lock, o.LockTimeout)
+		if err != nil {
+			s.Logger().Errorf(ctx, `idempotency storage failed for key "%s": %+v`, key, err)
+			c.Next()
+			return
+		}
+		if !acquired {
+			switch {
+			case record.Fingerprint != fingerprint:
+				abortWithError(c, http.StatusUnprocessableEntity, gberror.NewCode(
+					gbcode.CodeInvalidRequest, o.Header+" is already used by a different request",
+				))
+			case record.Lock != "":
+				abortWithError(c, http.StatusConflict, gberror.NewCode(
+					gbcode.CodeOperationFailed, "request with the same "+o.Header+" is in progress",
+				))
+			default:
+				replayIdempotencyRecord(c, record)
+			}
+			return
+		}
+
+		writer := &idempotencyWriter{ResponseWriter: c.Writer, maxSize: o.MaxBodySize}
+		c.Writer = writer
+		defer func() {
+			// The key is released if the handler panics, and the panic is handled by the recovery middleware.
+			if exception := recover(); exception != nil {
+				_, _ = o.Adapter.Remove(ctx, cacheKey)
+				panic(exception)
+			}
+		}()
+		c.Next()
+		// The response is stored after it is written, which is the envelope if ResponseMiddleware is enabled.
+		afterResponseWritten(c, func() {
+			c.Writer = writer.ResponseWriter
+			s.storeIdempotencyRecord(c, writer, o, key, cacheKey, fingerprint)
+		})
+	}
+}
+
+// storeIdempotencyRecord stores the response captured by `writer` for replaying,
+// or releases the key if the request failed or the response is not stored.
+func (s *Server) storeIdempotencyRecord(
+	c *gin.Context, writer *idempotencyWriter, o IdempotencyOption, key, cacheKey, fingerprint string,
+) {
+	var (
+		ctx    = Ctx(c)
+		status = writer.Status()
+	)
+	if len(c.Errors) > 0 || !writer.Written() || status >= http.StatusInternalServerError || writer.overflow {
+		if _, err := o.Adapter.Remove(ctx, cacheKey); err != nil {
+			s.Logger().Errorf(ctx, `idempotency storage failed for key "%s": %+v`, key, err)
+		}
+		return
+	}
+	header := writer.Header().Clone()
+	header.Del("Content-Length")
+	header.Del("Date")
+	header.Del(s.requestIdHeader())
+	content, err := json.Marshal(idempotencyRecord{
+		Fingerprint: fingerprint,
+		Status:      status,
+		Header:      header,
+		Body:        writer.body.Bytes(),
+	})
+	if err == nil {
+		err = o.Adapter.Set(ctx, cacheKey, string(content), o.TTL)
+	}
+	if err != nil {
+		s.Logger().Errorf(ctx, `idempotency storage failed for key "%s": %+v`, key, err)
+	}
+}
+
+// acquireIdempotencyKey locks `cacheKey` with `lock`, and returns the existing record if it is not acquired.
+// The lock is checked after setting, as the set-if-not-exist of some adapters is not atomic,
+// but the existing value is never overwritten.
+func acquireIdempotencyKey(
+	ctx context.Context, adapter gbcache.Adapter, cacheKey string, lock idempotencyRecord, timeout time.Duration,
+) (acquired bool, record idempotencyRecord, err error) {
+	content, err := json.Marshal(lock)
+	if err != nil {
+		return false, record, err
+	}
+	if _, err = adapter.SetIfNotExist(ctx, cacheKey, string(content), timeout); err != nil {
+		return false, record, err
+	}
+	v, err := adapter.Get(ctx, cacheKey)
+	if err != nil {
+		return false, record, err
+	}
+	if v.IsNil() {
+		// The record expires right now, which is rare and handled as in progress.
+		return false, idempotencyRecord{Lock: "-", Fingerprint: lock.Fingerprint}, nil
+	}
+	if err = json.Unmarshal(v.Bytes(), &record); err != nil {
+		return false, record, err
+	}
+	return record.Lock == lock.Lock, record, nil
+}
+
+// replayIdempotencyRecord writes the stored response of `record`.
+func replayIdempotencyRecord(c *gin.Context, record idempotencyRecord) {
+	header := c.Writer.Header()
+	for name, values := range record.Header {
+		header[name] = values
+	}
+	header.Set("Idempotent-Replayed", "true")
+	c.Status(record.Status)
+	_, _ = c.Writer.Write(record.Body)
+	c.Abort()
+}
+
+// idempotencyFingerprint returns the hash of request method, path and body, which identifies the request.
+// The request body is restored after reading.
+func idempotencyFingerprint(c *gin.Context) (string, error) {
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+	if c.Request.Body != nil && c.Request.Body != http.NoBody {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return "", err
+		}
+		_ = c.Request.Body.Close()
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		hash.Write(body)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Write writes `data` to the underlying writer and captures it.
+func (w *idempotencyWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString writes string `s` to the underlying writer and captures it.
+func (w *idempotencyWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// capture captures `data` until the max size is exceeded.
+func (w *idempotencyWriter) capture(data []byte) {
+	if w.overflow {
+		return
+	}
+	if w.body.Len()+len(data) > w.maxSize {
+		w.overflow = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(data)
+}
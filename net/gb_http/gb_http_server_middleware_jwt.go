@@ -0,0 +1,85 @@
+package gbhttp
+
+import (
+	"fmt"
+	gbjwt "ghostbb.io/gb/crypto/gb_jwt"
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+const (
+	// CtxKeyJwtClaims is the gin context key for the claims of verified token, retrieved by Get.
+	CtxKeyJwtClaims = "GbHttpJwtClaims"
+	// CtxKeyJwtSubject is the gin context key for the "sub" claim of verified token,
+	// which is usually used with RateLimitKeyBySubject.
+	CtxKeyJwtSubject = "GbHttpJwtSubject"
+	// CtxKeyJwtToken is the gin context key for the verified *gbjwt.Token.
+	CtxKeyJwtToken = "GbHttpJwtToken"
+)
+
+// JwtOption is the option for JwtMiddleware.
+type JwtOption struct {
+	// Verifier verifies the token of request, which is required.
+	Verifier *gbjwt.Verifier
+	// Claims creates the pointer of claims that the token claims are decoded into, like *MyClaims.
+	// The claims are stored as gbjwt.MapClaims if it is nil.
+	Claims func() interface{}
+	// Token retrieves the token of request, which is GetBearerToken in default.
+	Token func(c *gin.Context) string
+	// Optional specifies whether the request without token is allowed,
+	// in which case the claims are absent in context. The request with invalid token is always rejected.
+	Optional bool
+}
+
+// JwtMiddleware returns a middleware which authenticates the request using JSON Web Token.
+//
+// The claims of verified token are stored in gin context with key CtxKeyJwtClaims,
+// which is retrieved like gbhttp.Get(c, gbhttp.CtxKeyJwtClaims).Map() for the default gbjwt.MapClaims,
+// or gbhttp.Get(c, gbhttp.CtxKeyJwtClaims).Interface().(*MyClaims) for the custom claims.
+// It responds status 401 with header "WWW-Authenticate" if the token is missing or invalid,
+// whose error of code gbcode.CodeNotAuthorized is written by ResponseMiddleware if it is enabled.
+func (s *Server) JwtMiddleware(option JwtOption) gin.HandlerFunc {
+	if option.Verifier == nil {
+		panic(gberror.NewCode(gbcode.CodeMissingConfiguration, `jwt verifier should not be nil`))
+	}
+	getToken := option.Token
+	if getToken == nil {
+		getToken = GetBearerToken
+	}
+	return func(c *gin.Context) {
+		raw := getToken(c)
+		if raw == "" {
+			if option.Optional {
+				c.Next()
+				return
+			}
+			c.Header("WWW-Authenticate", "Bearer")
+			abortWithError(c, http.StatusUnauthorized, gberror.NewCode(gbcode.CodeNotAuthorized, "missing token"))
+			return
+		}
+		var claims interface{} = &gbjwt.MapClaims{}
+		if option.Claims != nil {
+			claims = option.Claims()
+		}
+		token, err := option.Verifier.Verify(Ctx(c), raw, claims)
+		if err != nil {
+			if gberror.Code(err) != gbcode.CodeNotAuthorized {
+				s.Logger().Errorf(Ctx(c), `jwt verification failed: %+v`, err)
+			}
+			message := strings.ReplaceAll(gberror.Current(err).Error(), `"`, `'`)
+			c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="invalid_token", error_description="%s"`, message))
+			abortWithError(c, http.StatusUnauthorized, gberror.NewCode(gbcode.CodeNotAuthorized, "invalid token"))
+			return
+		}
+		if mapClaims, ok := claims.(*gbjwt.MapClaims); ok {
+			claims = *mapClaims
+		}
+		c.Set(CtxKeyJwtToken, token)
+		c.Set(CtxKeyJwtClaims, claims)
+		c.Set(CtxKeyJwtSubject, token.Claims.Subject)
+		c.Next()
+	}
+}
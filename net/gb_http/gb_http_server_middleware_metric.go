@@ -0,0 +1,96 @@
+package gbhttp
+
+import (
+	gbmetric "ghostbb.io/gb/os/gb_metric"
+	gbconv "ghostbb.io/gb/util/gb_conv"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+const (
+	metricNameRequestsTotal   = "http_server_requests_total"
+	metricNameRequestDuration = "http_server_request_duration_seconds"
+	metricNameRequestsFlight  = "http_server_requests_in_flight"
+	metricRouteUnmatched      = "unmatched" // Route label for requests matching no route, to limit label cardinality.
+)
+
+var (
+	metricRequestsTotal = gbmetric.NewCounter(metricNameRequestsTotal, gbmetric.Option{
+		Help: "Total number of HTTP requests handled by the server.",
+	})
+	metricRequestDuration = gbmetric.NewHistogram(metricNameRequestDuration, gbmetric.Option{
+		Help:    "Duration of HTTP requests handled by the server in seconds.",
+		Unit:    "s",
+		Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	})
+	metricRequestsInFlight = gbmetric.NewUpDownCounter(metricNameRequestsFlight, gbmetric.Option{
+		Help: "Number of HTTP requests currently being handled by the server.",
+	})
+)
+
+// SetMetricEnabled enables or disables the request metrics and the Prometheus metrics endpoint.
+// The optional parameter `path` specifies the route of the metrics endpoint, which is "/metrics" in default.
+// Note that it should be called before the server starts.
+func (s *Server) SetMetricEnabled(enabled bool, path ...string) {
+	s.config.MetricEnabled = enabled
+	if len(path) > 0 && path[0] != "" {
+		s.config.MetricPath = path[0]
+	}
+}
+
+// initMetric registers the Prometheus metrics endpoint if it is enabled.
+func (s *Server) initMetric() {
+	if !s.config.MetricEnabled {
+		return
+	}
+	path := s.config.MetricPath
+	if path == "" {
+		path = defaultMetricPath
+	}
+	s.addBuiltinPath(path)
+	s.GET(path, func(c *gin.Context) {
+		c.Header("Content-Type", gbmetric.PrometheusContentType)
+		c.Status(http.StatusOK)
+		if err := gbmetric.WritePrometheus(Ctx(c), c.Writer); err != nil {
+			_ = c.Error(err)
+		}
+	})
+}
+
+// metricMiddleware records the request count, latency and in-flight requests
+// labeled by route template, method and status.
+func (s *Server) metricMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.config.MetricEnabled {
+			c.Next()
+			return
+		}
+		var (
+			ctx   = Ctx(c)
+			start = time.Now()
+			route = c.FullPath()
+		)
+		if route == "" {
+			route = metricRouteUnmatched
+		}
+		flightAttributes := gbmetric.Attributes{
+			"server": s.instance,
+			"method": c.Request.Method,
+			"route":  route,
+		}
+		metricRequestsInFlight.Inc(ctx, flightAttributes)
+		defer metricRequestsInFlight.Dec(ctx, flightAttributes)
+
+		c.Next()
+
+		attributes := gbmetric.Attributes{
+			"server": s.instance,
+			"method": c.Request.Method,
+			"route":  route,
+			"status": gbconv.String(c.Writer.Status()),
+		}
+		metricRequestsTotal.Inc(ctx, attributes)
+		metricRequestDuration.Observe(ctx, time.Since(start).Seconds(), attributes)
+	}
+}
@@ -0,0 +1,82 @@
+package gbhttp
+
+import (
+	"fmt"
+	gblimiter "ghostbb.io/gb/os/gb_limiter"
+	gbconv "ghostbb.io/gb/util/gb_conv"
+	"github.com/gin-gonic/gin"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimitKeyFunc returns the rate limiting key of the request.
+// The request is not limited if it returns empty string.
+type RateLimitKeyFunc func(c *gin.Context) string
+
+// RateLimitKeyByIP is the RateLimitKeyFunc using the remote ip of connection as the key.
+// It does not use ClientIP, which can be forged using header "X-Forwarded-For" to bypass the limit.
+// For the server behind reverse proxy, use a custom RateLimitKeyFunc with ClientIP
+// after configuring the trusted proxies of the gin engine.
+func RateLimitKeyByIP(c *gin.Context) string {
+	return "ip:" + c.RemoteIP()
+}
+
+// RateLimitKeyBySubject returns a RateLimitKeyFunc using the authenticated subject as the key,
+// which is the value of gin context `key` set by authentication middleware.
+// It uses the remote ip as the key if the subject is absent, see RateLimitKeyByIP.
+func RateLimitKeyBySubject(key string) RateLimitKeyFunc {
+	return func(c *gin.Context) string {
+		if v, ok := c.Get(key); ok && v != nil {
+			if subject := gbconv.String(v); subject != "" {
+				return "subject:" + subject
+			}
+		}
+		return RateLimitKeyByIP(c)
+	}
+}
+
+// RateLimitMiddleware returns a middleware which limits the requests using `limiter`.
+//
+// The optional parameter `keyFunc` specifies the limiting key of the request, which is RateLimitKeyByIP in default.
+// It sets the headers "RateLimit-Limit", "RateLimit-Remaining" and "RateLimit-Reset" for every limited request,
+// and responds status 429 with header "Retry-After" if the request is not allowed.
+// Note that the request is allowed if the limiter fails, as the limiting should not make the service unavailable.
+func (s *Server) RateLimitMiddleware(limiter gblimiter.Limiter, keyFunc ...RateLimitKeyFunc) gin.HandlerFunc {
+	getKey := RateLimitKeyFunc(RateLimitKeyByIP)
+	if len(keyFunc) > 0 && keyFunc[0] != nil {
+		getKey = keyFunc[0]
+	}
+	return func(c *gin.Context) {
+		key := getKey(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+		result, err := limiter.Allow(Ctx(c), key)
+		if err != nil {
+			s.Logger().Errorf(Ctx(c), `rate limit failed for key "%s": %+v`, key, err)
+			c.Next()
+			return
+		}
+		header := c.Writer.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", ceilSeconds(result.ResetAfter))
+		header.Set("RateLimit-Policy", fmt.Sprintf(
+			`%d;w=%s`, limiter.GetConfig().Limit, ceilSeconds(limiter.GetConfig().Period),
+		))
+		if !result.Allowed {
+			header.Set("Retry-After", ceilSeconds(result.RetryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"message": "too many requests"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// ceilSeconds returns the seconds of `d` rounded up as string, which is used in rate limiting headers.
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
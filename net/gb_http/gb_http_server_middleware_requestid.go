@@ -0,0 +1,80 @@
+package gbhttp
+
+import (
+	gbctx "ghostbb.io/gb/os/gb_ctx"
+	gbuid "ghostbb.io/gb/util/gb_uid"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// CtxKeyRequestId is the gin context key for the request id, retrieved by GetRequestId.
+	CtxKeyRequestId = "GbHttpRequestId"
+
+	defaultRequestIdHeader = "X-Request-Id"
+	maxRequestIdLength     = 128
+)
+
+// SetRequestIdEnabled enables or disables the request id handling.
+func (s *Server) SetRequestIdEnabled(enabled bool) {
+	s.config.RequestIdEnabled = enabled
+}
+
+// SetRequestIdHeader sets the header name of request id, which is "X-Request-Id" in default.
+func (s *Server) SetRequestIdHeader(header string) {
+	s.config.RequestIdHeader = header
+}
+
+// GetRequestId returns the request id of current request.
+// It returns empty string if the request id handling is disabled.
+func GetRequestId(c *gin.Context) string {
+	return c.GetString(CtxKeyRequestId)
+}
+
+// requestIdMiddleware accepts the request id from request header, or generates one if it is missing or invalid,
+// and echoes it in the response header.
+//
+// The request id is stored in both gin context and the request context with key gbctx.CtxKeyRequestId,
+// so that it is included in the logs of gblog, forwarded by gbclient and logged in the SQL logs of gbdb
+// when they are called with Ctx(c).
+func (s *Server) requestIdMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.config.RequestIdEnabled {
+			c.Next()
+			return
+		}
+		header := s.requestIdHeader()
+		id := c.GetHeader(header)
+		if !isValidRequestId(id) {
+			id = gbuid.S()
+		}
+		c.Set(CtxKeyRequestId, id)
+		c.Request = c.Request.WithContext(gbctx.WithRequestId(c.Request.Context(), id))
+		c.Header(header, id)
+		c.Next()
+	}
+}
+
+// requestIdHeader returns the header name of request id.
+func (s *Server) requestIdHeader() string {
+	if s.config.RequestIdHeader == "" {
+		return defaultRequestIdHeader
+	}
+	return s.config.RequestIdHeader
+}
+
+// isValidRequestId checks whether the request id from client is safe for logging and forwarding,
+// which contains only visible ASCII characters except space, quotes and backslash.
+func isValidRequestId(id string) bool {
+	if id == "" || len(id) > maxRequestIdLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		switch b := id[i]; {
+		case b <= ' ' || b >= 0x7f:
+			return false
+		case b == '"' || b == '\'' || b == '\\':
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,227 @@
+package gbhttp
+
+import (
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	gbi18n "ghostbb.io/gb/i18n/gb_i18n"
+	gbconv "ghostbb.io/gb/util/gb_conv"
+	gbmode "ghostbb.io/gb/util/gb_mode"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sync"
+)
+
+const (
+	// ctxKeyResponseMiddleware marks the response is written by the response middleware.
+	ctxKeyResponseMiddleware = "GbHttpResponseMiddleware"
+	// ctxKeyResponseWrittenHooks is the functions called after the response middleware writes the response.
+	ctxKeyResponseWrittenHooks = "GbHttpResponseWrittenHooks"
+	// ctxKeyErrorStatus is the gin context key for the HTTP status of error aborted by abortWithError.
+	ctxKeyErrorStatus = "GbHttpErrorStatus"
+)
+
+// DefaultResponse is the unified JSON response envelope written by ResponseMiddleware.
+type DefaultResponse struct {
+	Code    int         `json:"code"`            // Error code, which is 0 if success.
+	Message string      `json:"message"`         // Error message, which is translated using gbi18n.
+	Data    interface{} `json:"data"`            // Result data of the handler.
+	Stack   string      `json:"stack,omitempty"` // Error stack, which is hidden in product mode.
+}
+
+var (
+	// codeStatusMapping is the mapping from error code to HTTP status.
+	codeStatusMapping = map[int]int{
+		gbcode.CodeOK.Code():                       http.StatusOK,
+		gbcode.CodeValidationFailed.Code():         http.StatusBadRequest,
+		gbcode.CodeInvalidParameter.Code():         http.StatusBadRequest,
+		gbcode.CodeMissingParameter.Code():         http.StatusBadRequest,
+		gbcode.CodeInvalidRequest.Code():           http.StatusBadRequest,
+		gbcode.CodeBusinessValidationFailed.Code(): http.StatusBadRequest,
+		gbcode.CodeNotAuthorized.Code():            http.StatusUnauthorized,
+		gbcode.CodeSecurityReason.Code():           http.StatusForbidden,
+		gbcode.CodeNotFound.Code():                 http.StatusNotFound,
+		gbcode.CodeNotImplemented.Code():           http.StatusNotImplemented,
+		gbcode.CodeNotSupported.Code():             http.StatusNotImplemented,
+		gbcode.CodeServerBusy.Code():               http.StatusServiceUnavailable,
+	}
+	codeStatusMu sync.RWMutex
+)
+
+// SetCodeStatus sets the HTTP status for error code `code`, which is used by ResponseMiddleware.
+func SetCodeStatus(code gbcode.Code, status int) {
+	codeStatusMu.Lock()
+	defer codeStatusMu.Unlock()
+	codeStatusMapping[code.Code()] = status
+}
+
+// CodeToStatus returns the HTTP status of error code `code`.
+// The framework reserved code (< 1000) without mapping is treated as internal error with status 500,
+// and the custom business code without mapping is treated as client error with status 400.
+func CodeToStatus(code gbcode.Code) int {
+	codeStatusMu.RLock()
+	defer codeStatusMu.RUnlock()
+	if status, ok := codeStatusMapping[code.Code()]; ok {
+		return status
+	}
+	if code.Code() >= 1000 {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// ResponseMiddleware returns a middleware which wraps the handler result and the errors of
+// current request into the unified JSON envelope DefaultResponse.
+//
+// The data is the response object of typed handler (see BindHandler), the error is the last one of c.Errors
+// or the panic of the handler, and the HTTP status is mapped from the error code using CodeToStatus.
+// The status set by the handler is kept if there is no error, and no body is written for status 204 and 304.
+// The error message is translated using gbi18n with the language negotiated by I18nMiddleware, or negotiated
+// the same way from the query, cookie and header "Accept-Language" if I18nMiddleware is not used.
+// The message of internal error is replaced with the message of its code and the stack is hidden in product mode.
+// It does nothing if the handler has already written the response.
+// Note that it should be used before the routes are registered, like other gin middlewares.
+func (s *Server) ResponseMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(ctxKeyResponseMiddleware, true)
+		defer func() {
+			if exception := recover(); exception != nil {
+				var err error
+				if v, ok := exception.(error); ok {
+					if err = v; gberror.Code(err) == gbcode.CodeNil {
+						err = gberror.WrapCodeSkip(gbcode.CodeInternalPanic, 1, v)
+					}
+				} else {
+					err = gberror.NewCodeSkip(gbcode.CodeInternalPanic, 1, gbconv.String(exception))
+				}
+				_ = c.Error(err)
+				c.Abort()
+			}
+			s.writeResponse(c)
+			if hooks, ok := c.Get(ctxKeyResponseWrittenHooks); ok {
+				for _, hook := range hooks.([]func()) {
+					hook()
+				}
+			}
+		}()
+		c.Next()
+	}
+}
+
+// writeResponse writes the unified JSON envelope of current request if it is not written.
+func (s *Server) writeResponse(c *gin.Context) {
+	if c.Writer.Written() {
+		return
+	}
+	var (
+		ctx  = Ctx(c)
+		err  error
+		code gbcode.Code = gbcode.CodeOK
+	)
+	if last := c.Errors.Last(); last != nil {
+		err = last.Err
+		if code = gberror.Code(err); code == gbcode.CodeNil {
+			code = gbcode.CodeInternalError
+		}
+	} else {
+		switch c.Writer.Status() {
+		case http.StatusNotFound:
+			code = gbcode.CodeNotFound
+			err = gberror.NewCode(code, code.Message())
+		case http.StatusMethodNotAllowed:
+			code = gbcode.CodeNotSupported
+			err = gberror.NewCode(code, http.StatusText(http.StatusMethodNotAllowed))
+		}
+	}
+	var (
+		status   = CodeToStatus(code)
+		response = DefaultResponse{
+			Code:    code.Code(),
+			Message: code.Message(),
+		}
+	)
+	if err == nil {
+		// The status set by the handler without writing body is kept, like 201 and 204.
+		status = c.Writer.Status()
+		if !bodyAllowedForStatus(status) {
+			c.Writer.WriteHeaderNow()
+			return
+		}
+		response.Data = GetHandlerResponse(c)
+	} else {
+		if c.Writer.Status() == http.StatusMethodNotAllowed {
+			status = http.StatusMethodNotAllowed
+		}
+		if isBodyTooLarge(err) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		if v := c.GetInt(ctxKeyErrorStatus); v != 0 {
+			status = v
+		}
+		if status < http.StatusInternalServerError || !gbmode.IsProduct() {
+			response.Message = err.Error()
+		}
+		if !gbmode.IsProduct() && gberror.HasStack(err) {
+			response.Stack = gberror.Stack(err)
+		}
+	}
+	if language := requestLanguage(c); language != "" {
+		ctx = gbi18n.WithLanguage(ctx, language)
+	}
+	response.Message = gbi18n.T(ctx, response.Message)
+	c.JSON(status, response)
+}
+
+// bodyAllowedForStatus checks whether the response of `status` can have body, see RFC 9110.
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
+
+// isResponseMiddlewareEnabled checks whether the response of current request is written by ResponseMiddleware.
+func isResponseMiddlewareEnabled(c *gin.Context) bool {
+	return c.GetBool(ctxKeyResponseMiddleware)
+}
+
+// abortWithError aborts current request with HTTP `status` and error `err`, which is used by the built-in middlewares
+// rejecting the request. The error is written as the unified envelope with `status` by ResponseMiddleware
+// if it is enabled, or else as JSON {"message": "..."}.
+func abortWithError(c *gin.Context, status int, err error) {
+	if !isResponseMiddlewareEnabled(c) {
+		c.AbortWithStatusJSON(status, gin.H{"message": err.Error()})
+		return
+	}
+	c.Set(ctxKeyErrorStatus, status)
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// afterResponseWritten calls `f` after the response of current request is written, which should be called after c.Next.
+// It is deferred until ResponseMiddleware writes the response if the middleware is enabled, or else called right now,
+// so that the middlewares capturing the response like IdempotencyMiddleware get the final response.
+// The deferred functions are called in the order they are added, as the middlewares return from c.Next.
+func afterResponseWritten(c *gin.Context, f func()) {
+	if !isResponseMiddlewareEnabled(c) {
+		f()
+		return
+	}
+	var hooks []func()
+	if v, ok := c.Get(ctxKeyResponseWrittenHooks); ok {
+		hooks = v.([]func())
+	}
+	c.Set(ctxKeyResponseWrittenHooks, append(hooks, f))
+}
+
+// requestLanguage returns the language of current request from context, which is set by I18nMiddleware,
+// or else the language negotiated the same way as I18nMiddleware with default option.
+// It returns empty string if none matches, and the default language of gbi18n is used.
+func requestLanguage(c *gin.Context) string {
+	if language := gbi18n.LanguageFromCtx(Ctx(c)); language != "" {
+		return language
+	}
+	return negotiateLanguage(c, newI18nOption())
+}
@@ -0,0 +1,76 @@
+package gbhttp
+
+import (
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SetSecurityHeadersEnabled enables or disables the security response headers.
+func (s *Server) SetSecurityHeadersEnabled(enabled bool) {
+	s.config.SecurityHeadersEnabled = enabled
+}
+
+// SetHsts sets the max-age and directives of Strict-Transport-Security header.
+// The header is not sent if `maxAge` is zero.
+func (s *Server) SetHsts(maxAge time.Duration, includeSubDomains, preload bool) {
+	s.config.HstsMaxAge = maxAge
+	s.config.HstsIncludeSubDomains = includeSubDomains
+	s.config.HstsPreload = preload
+}
+
+// SetContentSecurityPolicy sets the Content-Security-Policy header.
+func (s *Server) SetContentSecurityPolicy(policy string) {
+	s.config.ContentSecurityPolicy = policy
+}
+
+// securityHeadersMiddleware adds the configured security headers to all responses.
+//
+// The Strict-Transport-Security header is only sent for HTTPS requests,
+// including the requests forwarded by TLS terminating proxy with "X-Forwarded-Proto: https",
+// as browsers ignore it over plain HTTP.
+func (s *Server) securityHeadersMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.config.SecurityHeadersEnabled {
+			c.Next()
+			return
+		}
+		if s.config.HstsMaxAge > 0 && isHttpsRequest(c) {
+			c.Header("Strict-Transport-Security", s.hstsValue())
+		}
+		if s.config.ContentSecurityPolicy != "" {
+			c.Header("Content-Security-Policy", s.config.ContentSecurityPolicy)
+		}
+		if s.config.FrameOptions != "" {
+			c.Header("X-Frame-Options", s.config.FrameOptions)
+		}
+		if s.config.ReferrerPolicy != "" {
+			c.Header("Referrer-Policy", s.config.ReferrerPolicy)
+		}
+		if s.config.ContentTypeNosniff {
+			c.Header("X-Content-Type-Options", "nosniff")
+		}
+		c.Next()
+	}
+}
+
+// hstsValue returns the value of Strict-Transport-Security header.
+func (s *Server) hstsValue() string {
+	value := "max-age=" + strconv.FormatInt(int64(s.config.HstsMaxAge/time.Second), 10)
+	if s.config.HstsIncludeSubDomains {
+		value += "; includeSubDomains"
+	}
+	if s.config.HstsPreload {
+		value += "; preload"
+	}
+	return value
+}
+
+// isHttpsRequest checks whether the request is over HTTPS, directly or through proxy.
+func isHttpsRequest(c *gin.Context) bool {
+	if c.Request.TLS != nil {
+		return true
+	}
+	return strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https")
+}
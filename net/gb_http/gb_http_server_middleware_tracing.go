@@ -1,7 +1,6 @@
 package gbhttp
 
 import (
-	"compress/gzip"
 	"context"
 	"fmt"
 	"ghostbb.io/gb"
@@ -20,7 +19,6 @@ import (
 	"go.opentelemetry.io/otel/trace"
 	"io"
 	"net/http"
-	"strings"
 )
 
 const (
@@ -30,6 +28,7 @@ const (
 	tracingEventHttpRequestBaggage               = "http.request.baggage"
 	tracingEventHttpRequestBody                  = "http.request.body"
 	tracingEventHttpResponse                     = "http.response"
+	tracingEventHttpResponseStatus               = "http.response.status"
 	tracingEventHttpResponseHeaders              = "http.response.headers"
 	tracingEventHttpResponseBody                 = "http.response.body"
 	tracingEventHttpRequestUrl                   = "http.request.url"
@@ -81,6 +80,9 @@ func (s *Server) traceMiddleware() gin.HandlerFunc {
 		if err != nil {
 			_ = c.Error(gberror.Wrap(err, `read request body failed`))
 			span.SetStatus(codes.Error, fmt.Sprintf(`%+v`, err))
+			if isBodyTooLarge(err) {
+				c.AbortWithStatus(http.StatusRequestEntityTooLarge)
+			}
 			return
 		}
 		c.Request.Body = utils.NewReadCloser(reqBodyContentBytes, false)
@@ -96,46 +98,23 @@ func (s *Server) traceMiddleware() gin.HandlerFunc {
 			)),
 		))
 
+		// The response is recorded for the span event, as c.Request.Response is nil on server side.
+		recorder := RecordResponse(c, gbtrace.MaxContentLogSize())
+
 		// Continue executing.
 		c.Next()
 
 		// Error logging.
 		if err = c.Err(); err != nil {
 			span.SetStatus(codes.Error, fmt.Sprintf(`%+v`, err))
+		} else if recorder.Status() >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.Status()))
 		}
 		// Response content logging.
-		bodyByte := make([]byte, 0)
-		_, _ = c.Request.Response.Body.Read(bodyByte)
-		var resBodyContent = gbstr.StrLimit(string(bodyByte), gbtrace.MaxContentLogSize(), "...")
-		if gzipAccepted(c.Request.Response.Header) {
-			reader, err := gzip.NewReader(c.Request.Response.Body)
-			if err != nil {
-				span.SetStatus(codes.Error, fmt.Sprintf(`read gzip response err:%+v`, err))
-			}
-			defer reader.Close()
-			uncompressed, err := io.ReadAll(reader)
-			if err != nil {
-				span.SetStatus(codes.Error, fmt.Sprintf(`get uncompress value err:%+v`, err))
-			}
-			resBodyContent = gbstr.StrLimit(string(uncompressed), gbtrace.MaxContentLogSize(), "...")
-		}
-
 		span.AddEvent(tracingEventHttpResponse, trace.WithAttributes(
-			attribute.String(tracingEventHttpResponseHeaders, gbconv.String(httputil.HeaderToMap(c.Request.Response.Header))),
-			attribute.String(tracingEventHttpResponseBody, resBodyContent),
+			attribute.Int(tracingEventHttpResponseStatus, recorder.Status()),
+			attribute.String(tracingEventHttpResponseHeaders, gbconv.String(httputil.HeaderToMap(recorder.Header()))),
+			attribute.String(tracingEventHttpResponseBody, recorder.BodyString()),
 		))
 	}
 }
-
-// gzipAccepted returns whether the client will accept gzip-encoded content.
-func gzipAccepted(header http.Header) bool {
-	a := header.Get("Content-Encoding")
-	parts := strings.Split(a, ",")
-	for _, part := range parts {
-		part = strings.TrimSpace(part)
-		if part == "gzip" || strings.HasPrefix(part, "gzip;") {
-			return true
-		}
-	}
-	return false
-}
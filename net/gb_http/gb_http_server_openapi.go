@@ -0,0 +1,232 @@
+package gbhttp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	gboai "ghostbb.io/gb/net/gb_oai"
+	gbres "ghostbb.io/gb/os/gb_res"
+	gbstr "ghostbb.io/gb/text/gb_str"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+const (
+	SwaggerUISwagger = "swagger" // SwaggerUISwagger is the Swagger UI for API document.
+	SwaggerUIRedoc   = "redoc"   // SwaggerUIRedoc is the Redoc UI for API document.
+
+	// swaggerUIPlaceholder is the placeholder of OpenAPI document url in the UI index file.
+	swaggerUIPlaceholder = "{OpenApiPath}"
+	// swaggerPathPlaceholder is the placeholder of UI route in the built-in index file,
+	// which is the prefix of the UI asset files.
+	swaggerPathPlaceholder = "{SwaggerPath}"
+
+	// defaultSwaggerResourceSwagger is the default gbres directory of Swagger UI asset files,
+	// which are the "swagger-ui.css" and "swagger-ui-bundle.js" of npm package "swagger-ui-dist".
+	defaultSwaggerResourceSwagger = "/swagger-ui"
+	// defaultSwaggerResourceRedoc is the default gbres directory of Redoc asset files,
+	// which is the "redoc.standalone.js" of npm package "redoc".
+	defaultSwaggerResourceRedoc = "/redoc"
+)
+
+const (
+	swaggerUITemplateSwagger = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8" />
+	<meta name="viewport" content="width=device-width, initial-scale=1" />
+	<title>API Reference</title>
+	<link rel="stylesheet" href="{SwaggerPath}/swagger-ui.css" />
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="{SwaggerPath}/swagger-ui-bundle.js"></script>
+<script>
+	window.onload = () => {
+		window.ui = SwaggerUIBundle({url: '{OpenApiPath}', dom_id: '#swagger-ui'});
+	};
+</script>
+</body>
+</html>`
+
+	swaggerUITemplateRedoc = `<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8" />
+	<meta name="viewport" content="width=device-width, initial-scale=1" />
+	<title>API Reference</title>
+</head>
+<body>
+<redoc spec-url="{OpenApiPath}"></redoc>
+<script src="{SwaggerPath}/redoc.standalone.js"></script>
+</body>
+</html>`
+)
+
+var (
+	// swaggerUIAssets are the asset files required by the built-in index file of each UI.
+	swaggerUIAssets = map[string][]string{
+		SwaggerUISwagger: {"swagger-ui.css", "swagger-ui-bundle.js"},
+		SwaggerUIRedoc:   {"redoc.standalone.js"},
+	}
+)
+
+// SetOpenApiPath sets the route of the OpenAPI document, which is disabled if empty.
+// Note that it should be called before the server starts.
+func (s *Server) SetOpenApiPath(path string) {
+	s.config.OpenApiPath = path
+}
+
+// SetSwaggerPath sets the route of the API document UI, which is disabled if empty.
+// The UI requires the OpenAPI document, see SetOpenApiPath.
+// The UI files are not shipped with the framework, which should be packed into gbres by the application,
+// like the "swagger-ui.css" and "swagger-ui-bundle.js" of npm package "swagger-ui-dist" into directory "/swagger-ui",
+// or the "redoc.standalone.js" of npm package "redoc" into directory "/redoc", see ServerConfig.SwaggerResource.
+// Note that it should be called before the server starts.
+func (s *Server) SetSwaggerPath(path string) {
+	s.config.SwaggerPath = path
+}
+
+// GetOpenApi returns the OpenAPI document template of the server,
+// which can be used for customizing the information like Info, Servers and Components.SecuritySchemes.
+// The paths and schemas are generated from the typed handlers of the server when the document is requested.
+func (s *Server) GetOpenApi() *gboai.OpenApiV3 {
+	return s.openapi
+}
+
+// initOpenApi registers the OpenAPI document and UI endpoints if they are enabled.
+func (s *Server) initOpenApi() {
+	if s.config.OpenApiPath == "" {
+		return
+	}
+	s.addBuiltinPath(s.config.OpenApiPath)
+	s.GET(s.config.OpenApiPath, func(c *gin.Context) {
+		c.JSON(http.StatusOK, s.buildOpenApi(Ctx(c)))
+	})
+	if s.config.SwaggerPath == "" {
+		return
+	}
+	s.addBuiltinPath(s.config.SwaggerPath)
+	// The UI files are served from gbres, so that no third-party script is loaded from network.
+	// The UI responds error instead of a blank page if its files are not packed.
+	if missing := s.missingSwaggerAssets(); len(missing) > 0 {
+		message := fmt.Sprintf(
+			`API document UI files %s are not found in gbres directory "%s", please pack them into resource`,
+			strings.Join(missing, ", "), s.swaggerResource(),
+		)
+		s.Logger().Error(context.TODO(), message)
+		s.GET(s.config.SwaggerPath, func(c *gin.Context) {
+			c.String(http.StatusInternalServerError, message)
+		})
+		return
+	}
+	swaggerPath := strings.TrimRight(s.config.SwaggerPath, "/")
+	s.GET(s.config.SwaggerPath, s.serveSwaggerResource)
+	s.GET(swaggerPath+"/*filepath", s.serveSwaggerResource)
+}
+
+// buildOpenApi generates the OpenAPI document from the typed handlers of current server.
+func (s *Server) buildOpenApi(ctx context.Context) *gboai.OpenApiV3 {
+	var (
+		oai    = gboai.New()
+		routes = make(map[string][]string)
+	)
+	oai.Info = s.openapi.Info
+	oai.Servers = s.openapi.Servers
+	oai.Security = s.openapi.Security
+	oai.Tags = s.openapi.Tags
+	oai.Components.SecuritySchemes = s.openapi.Components.SecuritySchemes
+	for k, v := range s.openapi.Components.Schemas {
+		oai.Components.Schemas[k] = v
+	}
+	for _, route := range s.GetRoutes() {
+		routes[route.Path] = append(routes[route.Path], route.Method)
+	}
+	// The handler items are of the whole process, so it only uses the ones registered to current server.
+	for _, item := range GetHandlerItems() {
+		for _, method := range routes[item.Path] {
+			if item.Method != "" && item.Method != method {
+				continue
+			}
+			err := oai.Add(gboai.AddInput{
+				Path:     item.Path,
+				Method:   method,
+				Request:  item.Request,
+				Response: item.Response,
+			})
+			if err != nil {
+				s.Logger().Errorf(ctx, `%+v`, err)
+			}
+		}
+	}
+	return oai
+}
+
+// missingSwaggerAssets returns the UI files required by the built-in index file which are not found in gbres.
+// It returns nil if the resource directory has its own index file.
+func (s *Server) missingSwaggerAssets() []string {
+	resource := s.swaggerResource()
+	if gbres.Contains(resource + "/index.html") {
+		return nil
+	}
+	var missing []string
+	for _, name := range swaggerUIAssets[s.swaggerUI()] {
+		if !gbres.Contains(resource + "/" + name) {
+			missing = append(missing, `"`+name+`"`)
+		}
+	}
+	return missing
+}
+
+// swaggerUI returns the configured API document UI, which is SwaggerUISwagger if it is unknown.
+func (s *Server) swaggerUI() string {
+	if s.config.SwaggerUI == SwaggerUIRedoc {
+		return SwaggerUIRedoc
+	}
+	return SwaggerUISwagger
+}
+
+// swaggerResource returns the gbres directory of the API document UI files.
+func (s *Server) swaggerResource() string {
+	if s.config.SwaggerResource != "" {
+		return strings.TrimRight(s.config.SwaggerResource, "/")
+	}
+	if s.swaggerUI() == SwaggerUIRedoc {
+		return defaultSwaggerResourceRedoc
+	}
+	return defaultSwaggerResourceSwagger
+}
+
+// serveSwaggerResource serves the API document UI files packed into gbres.
+// The index file is the "index.html" of the resource directory if it exists, or else the built-in one
+// referencing the asset files of the resource directory.
+// The placeholder "{OpenApiPath}" in the index file is replaced with the route of the OpenAPI document.
+func (s *Server) serveSwaggerResource(c *gin.Context) {
+	var (
+		name     = strings.Trim(c.Param("filepath"), "/")
+		filePath = s.swaggerResource() + "/" + name
+		file     = gbres.GetWithIndex(filePath, []string{"index.html"})
+	)
+	if name == "" && (file == nil || file.FileInfo().IsDir()) {
+		template := swaggerUITemplateSwagger
+		if s.swaggerUI() == SwaggerUIRedoc {
+			template = swaggerUITemplateRedoc
+		}
+		content := gbstr.ReplaceByMap(template, map[string]string{
+			swaggerUIPlaceholder:   s.config.OpenApiPath,
+			swaggerPathPlaceholder: strings.TrimRight(s.config.SwaggerPath, "/"),
+		})
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(content))
+		return
+	}
+	if file == nil || file.FileInfo().IsDir() {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	content := file.Content()
+	if strings.HasSuffix(file.Name(), ".html") {
+		content = bytes.ReplaceAll(content, []byte(swaggerUIPlaceholder), []byte(s.config.OpenApiPath))
+	}
+	http.ServeContent(c.Writer, c.Request, file.Name(), file.FileInfo().ModTime(), bytes.NewReader(content))
+}
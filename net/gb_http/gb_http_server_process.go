@@ -1,11 +1,27 @@
 package gbhttp
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	gbtype "ghostbb.io/gb/container/gb_type"
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	"ghostbb.io/gb/internal/intlog"
+	"ghostbb.io/gb/internal/json"
+	gbenv "ghostbb.io/gb/os/gb_env"
+	gbfile "ghostbb.io/gb/os/gb_file"
+	gbhealth "ghostbb.io/gb/os/gb_health"
 	gblog "ghostbb.io/gb/os/gb_log"
 	gbproc "ghostbb.io/gb/os/gb_proc"
+	gbtime "ghostbb.io/gb/os/gb_time"
+	gbtimer "ghostbb.io/gb/os/gb_timer"
+	gbstr "ghostbb.io/gb/text/gb_str"
+	gbconv "ghostbb.io/gb/util/gb_conv"
+	"net"
 	"os"
+	"runtime"
+	"time"
 )
 
 const (
@@ -17,6 +33,8 @@ const (
 	adminActionReloadEnvKey  = "GB_SERVER_RELOAD"
 	adminActionRestartEnvKey = "GB_SERVER_RESTART"
 	adminGProcCommGroup      = "GB_GPROC_HTTP_SERVER"
+	// Extra time waiting for the exit notification of reloaded child process besides GracefulTimeout.
+	adminActionReloadTimeoutMargin = 10 * time.Second
 )
 
 var (
@@ -24,6 +42,256 @@ var (
 	serverProcessStatus = gbtype.NewInt()
 )
 
+// RestartAllServer restarts all the servers of the process gracefully.
+// The optional parameter `newExeFilePath` specifies the new binary file for creating process.
+func RestartAllServer(ctx context.Context, newExeFilePath ...string) error {
+	if !gracefulEnabled.Val() {
+		return gberror.NewCode(gbcode.CodeInvalidOperation, "graceful reload feature is disabled")
+	}
+	serverActionLocker.Lock()
+	defer serverActionLocker.Unlock()
+	if err := checkProcessStatus(); err != nil {
+		return err
+	}
+	if err := checkActionFrequency(); err != nil {
+		return err
+	}
+	var path string
+	if len(newExeFilePath) > 0 {
+		path = newExeFilePath[0]
+	}
+	return restartWebServers(ctx, nil, path)
+}
+
+// ShutdownAllServer shuts down all servers of current process gracefully.
+func ShutdownAllServer(ctx context.Context) error {
+	serverActionLocker.Lock()
+	defer serverActionLocker.Unlock()
+	if err := checkProcessStatus(); err != nil {
+		return err
+	}
+	if err := checkActionFrequency(); err != nil {
+		return err
+	}
+	shutdownWebServersGracefully(ctx, nil)
+	return nil
+}
+
+// serverProcessInit initializes some process configurations, which can only be done once.
+func serverProcessInit() {
+	var ctx = context.TODO()
+	if !serverProcessInitialized.Cas(false, true) {
+		return
+	}
+	// This means it is a restart server. It should kill its parent before starting its listening,
+	// to avoid duplicated port listening in two processes.
+	if !gbenv.Get(adminActionRestartEnvKey).IsEmpty() {
+		if p, err := os.FindProcess(gbproc.PPid()); err == nil {
+			if err = p.Kill(); err != nil {
+				intlog.Errorf(ctx, `%+v`, err)
+			}
+			if _, err = p.Wait(); err != nil {
+				intlog.Errorf(ctx, `%+v`, err)
+			}
+		} else {
+			gblog.Error(ctx, err)
+		}
+	}
+
+	// It's an ugly calling for better initializing the main package path
+	// in source development environment. It is useful only be used in main goroutine.
+	// It fails to retrieve the main package path in asynchronous goroutines.
+	gbfile.MainPkgPath()
+}
+
+// startProcessMessageHandler starts the process message handler if the graceful feature is enabled.
+func startProcessMessageHandler() {
+	if !gracefulEnabled.Val() || !processMessageHandling.Cas(false, true) {
+		return
+	}
+	intlog.Printf(context.TODO(), "pid[%d]: graceful reload feature is enabled", gbproc.Pid())
+	go handleProcessMessage()
+}
+
+// checkProcessStatus checks the server status of current process.
+func checkProcessStatus() error {
+	switch serverProcessStatus.Val() {
+	case adminActionRestarting:
+		return gberror.NewCode(gbcode.CodeInvalidOperation, "server is restarting")
+
+	case adminActionShuttingDown:
+		return gberror.NewCode(gbcode.CodeInvalidOperation, "server is shutting down")
+	}
+	return nil
+}
+
+// checkActionFrequency checks the operation frequency.
+// It returns error if it is too frequency.
+func checkActionFrequency() error {
+	interval := gbtime.TimestampMilli() - serverActionLastTime.Val()
+	if interval < adminActionIntervalLimit {
+		return gberror.NewCodef(
+			gbcode.CodeInvalidOperation,
+			"too frequent action, please retry in %d ms",
+			adminActionIntervalLimit-interval,
+		)
+	}
+	serverActionLastTime.Set(gbtime.TimestampMilli())
+	return nil
+}
+
+// forkReloadProcess creates a new child process and copies the listener fd to child process.
+// The fd mapping is passed to the child process through environment `adminActionReloadEnvKey`.
+func forkReloadProcess(ctx context.Context, newExeFilePath ...string) error {
+	var (
+		path = os.Args[0]
+	)
+	if len(newExeFilePath) > 0 && newExeFilePath[0] != "" {
+		path = newExeFilePath[0]
+	}
+	var (
+		p   = gbproc.NewProcess(path, os.Args, os.Environ())
+		sfm = make(map[string]listenerFdMap)
+	)
+	serverMapping.RLockFunc(func(m map[string]interface{}) {
+		for name, v := range m {
+			fdMap := listenerFdMap{
+				"http":  "",
+				"https": "",
+			}
+			for _, server := range v.(*Server).servers {
+				// The fd in child process is the index of ExtraFiles plus 3 (stdin, stdout and stderr).
+				var fd = 0
+				if file := server.File(); file != nil {
+					fd = 3 + len(p.ExtraFiles)
+					p.ExtraFiles = append(p.ExtraFiles, file)
+				}
+				proto := server.getProto()
+				if len(fdMap[proto]) > 0 {
+					fdMap[proto] += ","
+				}
+				fdMap[proto] += fmt.Sprintf("%s#%d", server.address, fd)
+			}
+			sfm[name] = fdMap
+		}
+	})
+	// The parent process does not need these duplicated descriptors after the child started.
+	defer func() {
+		for _, file := range p.ExtraFiles {
+			_ = file.Close()
+		}
+	}()
+	buffer, _ := json.Marshal(sfm)
+	p.Env = append(p.Env, adminActionReloadEnvKey+"="+string(buffer))
+	if _, err := p.Start(ctx); err != nil {
+		gblog.Errorf(
+			ctx,
+			"%d: fork process failed, error:%s, %s",
+			gbproc.Pid(), err.Error(), string(buffer),
+		)
+		return err
+	}
+	return nil
+}
+
+// forkRestartProcess creates a new server process.
+// It is used in the OS which does not support listener fd passing, like Windows.
+func forkRestartProcess(ctx context.Context, newExeFilePath ...string) error {
+	var (
+		path = os.Args[0]
+	)
+	if len(newExeFilePath) > 0 && newExeFilePath[0] != "" {
+		path = newExeFilePath[0]
+	}
+	if err := os.Unsetenv(adminActionReloadEnvKey); err != nil {
+		intlog.Errorf(ctx, `%+v`, err)
+	}
+	env := os.Environ()
+	env = append(env, adminActionRestartEnvKey+"=1")
+	p := gbproc.NewProcess(path, os.Args, env)
+	if _, err := p.Start(ctx); err != nil {
+		gblog.Errorf(
+			ctx,
+			`%d: fork process failed, error:%s, are you running using "go run"?`,
+			gbproc.Pid(), err.Error(),
+		)
+		return err
+	}
+	return nil
+}
+
+// bufferToServerFdMap converts binary content to fd map.
+func bufferToServerFdMap(buffer []byte) map[string]listenerFdMap {
+	sfm := make(map[string]listenerFdMap)
+	if len(buffer) > 0 {
+		if err := json.Unmarshal(buffer, &sfm); err != nil {
+			intlog.Errorf(context.TODO(), `%+v`, err)
+		}
+	}
+	return sfm
+}
+
+// parseListenerAddrAndFd parses the item like ":8080#3" to its address and fd.
+// The fd is always 0 in the OS which does not support fd passing.
+func parseListenerAddrAndFd(item string) (address string, fd int) {
+	array := gbstr.SplitAndTrim(item, "#")
+	if len(array) == 0 {
+		return "", 0
+	}
+	address = array[0]
+	if len(array) > 1 && runtime.GOOS != "windows" {
+		fd = gbconv.Int(array[1])
+	}
+	return
+}
+
+// restartWebServers restarts all servers.
+func restartWebServers(ctx context.Context, signal os.Signal, newExeFilePath string) error {
+	serverProcessStatus.Set(adminActionRestarting)
+	if runtime.GOOS == "windows" {
+		if signal != nil {
+			// Controlled by signal.
+			forceCloseWebServers(ctx)
+			if err := forkRestartProcess(ctx, newExeFilePath); err != nil {
+				intlog.Errorf(ctx, `%+v`, err)
+			}
+			return nil
+		}
+		// Controlled by api.
+		// It should ensure the response wrote to client and then close all servers gracefully.
+		gbtimer.SetTimeout(ctx, time.Second, func(ctx context.Context) {
+			forceCloseWebServers(ctx)
+			if err := forkRestartProcess(ctx, newExeFilePath); err != nil {
+				intlog.Errorf(ctx, `%+v`, err)
+			}
+		})
+		return nil
+	}
+	if err := forkReloadProcess(ctx, newExeFilePath); err != nil {
+		gblog.Printf(ctx, "%d: server restarts failed", gbproc.Pid())
+		serverProcessStatus.Set(adminActionNone)
+		return err
+	}
+	if signal != nil {
+		gblog.Printf(ctx, "%d: server restarting by signal: %s", gbproc.Pid(), signal)
+	} else {
+		gblog.Printf(ctx, "%d: server restarting by api", gbproc.Pid())
+	}
+	// The child process notifies exit after serving for GracefulTimeout. If it crashes or never notifies,
+	// the restarting status is reset, so that the later restart and shutdown are not rejected forever.
+	timeout := getGracefulTimeout() + adminActionReloadTimeoutMargin
+	gbtimer.SetTimeout(ctx, timeout, func(ctx context.Context) {
+		if serverProcessStatus.Cas(adminActionRestarting, adminActionNone) {
+			gblog.Errorf(
+				ctx,
+				"%d: server restarts failed, no exit notification from child process in %s",
+				gbproc.Pid(), timeout,
+			)
+		}
+	})
+	return nil
+}
+
 // shutdownWebServersGracefully gracefully shuts down all servers.
 func shutdownWebServersGracefully(ctx context.Context, signal os.Signal) {
 	serverProcessStatus.Set(adminActionShuttingDown)
@@ -36,13 +304,92 @@ func shutdownWebServersGracefully(ctx context.Context, signal os.Signal) {
 	} else {
 		gblog.Printf(ctx, "%d: server gracefully shutting down by api", gbproc.Pid())
 	}
+	// Mark the process not ready before shutting down,
+	// so that the load balancer stops routing traffic to it.
+	gbhealth.SetReady(false)
+	if delay := getHealthShutdownDelay(); delay > 0 {
+		gblog.Printf(ctx, "%d: server marked not ready, shutting down in %s", gbproc.Pid(), delay)
+		time.Sleep(delay)
+	}
 	serverMapping.RLockFunc(func(m map[string]interface{}) {
 		for _, v := range m {
 			server := v.(*Server)
 			server.doServiceDeregister()
+			server.closeWebSocketHubs(ctx)
+			server.closeCertManager()
 			for _, s := range server.servers {
 				s.shutdown(ctx)
 			}
 		}
 	})
 }
+
+// getGracefulTimeout returns the max GracefulTimeout of all servers.
+func getGracefulTimeout() time.Duration {
+	var timeout time.Duration
+	serverMapping.RLockFunc(func(m map[string]interface{}) {
+		for _, v := range m {
+			if t := time.Duration(v.(*Server).config.GracefulTimeout) * time.Second; t > timeout {
+				timeout = t
+			}
+		}
+	})
+	return timeout
+}
+
+// getHealthShutdownDelay returns the max HealthShutdownDelay of all servers.
+func getHealthShutdownDelay() time.Duration {
+	var delay time.Duration
+	serverMapping.RLockFunc(func(m map[string]interface{}) {
+		for _, v := range m {
+			if d := v.(*Server).config.HealthShutdownDelay; d > delay {
+				delay = d
+			}
+		}
+	})
+	return delay
+}
+
+// forceCloseWebServers forced shuts down all servers.
+func forceCloseWebServers(ctx context.Context) {
+	serverMapping.RLockFunc(func(m map[string]interface{}) {
+		for _, v := range m {
+			for _, s := range v.(*Server).servers {
+				s.close(ctx)
+			}
+		}
+	})
+}
+
+// handleProcessMessage receives and handles the message from processes,
+// which are commonly used for graceful reloading feature.
+func handleProcessMessage() {
+	var ctx = context.TODO()
+	for {
+		if msg := gbproc.Receive(adminGProcCommGroup); msg != nil {
+			if bytes.EqualFold(msg.Data, []byte("exit")) {
+				intlog.Printf(ctx, "%d: process message: exit", gbproc.Pid())
+				shutdownWebServersGracefully(ctx, nil)
+				allShutdownChan <- struct{}{}
+				intlog.Printf(ctx, "%d: process message: exit done", gbproc.Pid())
+				return
+			}
+		}
+	}
+}
+
+// File returns a duplicated file of the underlying TCP listener,
+// which is used for passing the listener to child process.
+// It returns nil if the listener is not a TCP listener or any error occurs.
+func (s *internalServer) File() *os.File {
+	ln, ok := s.getRawListener().(*net.TCPListener)
+	if !ok {
+		return nil
+	}
+	file, err := ln.File()
+	if err != nil {
+		intlog.Errorf(context.TODO(), `%+v`, err)
+		return nil
+	}
+	return file
+}
@@ -23,7 +23,7 @@ func (s *Server) doServiceRegister() {
 		insecure = true
 		err      error
 	)
-	if s.config.TLSConfig != nil {
+	if s.config.TLSConfig != nil || s.isHttpsConfigured() {
 		protocol = `https`
 		insecure = false
 	}
@@ -0,0 +1,127 @@
+package gbhttp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// ctxKeyResponseRecorder is the gin context key for the response recorder of current request.
+	ctxKeyResponseRecorder = "GbHttpResponseRecorder"
+)
+
+// ResponseRecorder is a gin.ResponseWriter which records a bounded copy of the response body,
+// along with the status and headers of the underlying writer.
+// The streaming features like Flush and Hijack are passed through to the underlying writer.
+type ResponseRecorder struct {
+	gin.ResponseWriter
+	limit     int          // Max size of the recorded body.
+	body      bytes.Buffer // Recorded body, which is at most limit bytes.
+	truncated bool         // Marks the body is larger than limit.
+}
+
+// RecordResponse installs a ResponseRecorder recording at most `limit` bytes of response body
+// for current request, and returns it.
+//
+// It returns the installed one if the recorder is already installed by other middleware,
+// so that the tracing and logging middlewares share a single recorder.
+// It should be called before the response is written, usually before calling c.Next.
+func RecordResponse(c *gin.Context, limit int) *ResponseRecorder {
+	if v, ok := c.Get(ctxKeyResponseRecorder); ok {
+		return v.(*ResponseRecorder)
+	}
+	recorder := &ResponseRecorder{
+		ResponseWriter: c.Writer,
+		limit:          limit,
+	}
+	c.Writer = recorder
+	c.Set(ctxKeyResponseRecorder, recorder)
+	return recorder
+}
+
+// GetResponseRecorder returns the ResponseRecorder installed for current request,
+// or nil if it is not installed.
+func GetResponseRecorder(c *gin.Context) *ResponseRecorder {
+	if v, ok := c.Get(ctxKeyResponseRecorder); ok {
+		return v.(*ResponseRecorder)
+	}
+	return nil
+}
+
+// Write writes `data` to the underlying writer and records it.
+func (r *ResponseRecorder) Write(data []byte) (int, error) {
+	r.record(data)
+	return r.ResponseWriter.Write(data)
+}
+
+// WriteString writes string `s` to the underlying writer and records it.
+func (r *ResponseRecorder) WriteString(s string) (int, error) {
+	r.record([]byte(s))
+	return r.ResponseWriter.WriteString(s)
+}
+
+// Body returns the recorded raw body, which may be compressed according to the Content-Encoding header.
+func (r *ResponseRecorder) Body() []byte {
+	return r.body.Bytes()
+}
+
+// IsTruncated checks whether the recorded body is truncated as the response body exceeds the limit.
+func (r *ResponseRecorder) IsTruncated() bool {
+	return r.truncated
+}
+
+// BodyString returns the recorded body as string, which is decompressed if it is gzip encoded.
+// The decompressed content is also limited, and "..." is appended if the body is truncated.
+func (r *ResponseRecorder) BodyString() string {
+	var (
+		content   = r.body.Bytes()
+		truncated = r.truncated
+	)
+	if isGzipEncoded(r.Header()) && len(content) > 0 {
+		if reader, err := gzip.NewReader(bytes.NewReader(content)); err == nil {
+			// The recorded gzip stream may be truncated, so the partially decompressed content is used.
+			decompressed, _ := io.ReadAll(io.LimitReader(reader, int64(r.limit)+1))
+			_ = reader.Close()
+			content = decompressed
+			if len(content) > r.limit {
+				content = content[:r.limit]
+				truncated = true
+			}
+		}
+	}
+	if truncated {
+		return string(content) + "..."
+	}
+	return string(content)
+}
+
+// record records `data` until the limit is reached.
+func (r *ResponseRecorder) record(data []byte) {
+	remaining := r.limit - r.body.Len()
+	if remaining <= 0 {
+		if len(data) > 0 {
+			r.truncated = true
+		}
+		return
+	}
+	if len(data) > remaining {
+		data = data[:remaining]
+		r.truncated = true
+	}
+	r.body.Write(data)
+}
+
+// isGzipEncoded checks whether the content is gzip encoded according to the Content-Encoding header.
+func isGzipEncoded(header http.Header) bool {
+	for _, part := range strings.Split(header.Get("Content-Encoding"), ",") {
+		part = strings.TrimSpace(part)
+		if part == "gzip" || strings.HasPrefix(part, "gzip;") {
+			return true
+		}
+	}
+	return false
+}
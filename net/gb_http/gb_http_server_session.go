@@ -0,0 +1,162 @@
+package gbhttp
+
+import (
+	gbsession "ghostbb.io/gb/os/gb_session"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSessionIdName = "gbsessionid" // Default session id name in cookie and header.
+
+	// ctxKeySession is the gin context key for the session of current request.
+	ctxKeySession = "GbHttpSession"
+)
+
+// sessionResponseWriter is the response writer which outputs the session id
+// before the response header is written.
+type sessionResponseWriter struct {
+	gin.ResponseWriter
+	once     sync.Once
+	outputId func()
+}
+
+// SetSessionMaxAge sets the SessionMaxAge for server.
+func (s *Server) SetSessionMaxAge(ttl time.Duration) {
+	s.config.SessionMaxAge = ttl
+}
+
+// SetSessionIdName sets the SessionIdName for server.
+func (s *Server) SetSessionIdName(name string) {
+	s.config.SessionIdName = name
+}
+
+// SetSessionStorage sets the SessionStorage for server.
+func (s *Server) SetSessionStorage(storage gbsession.Storage) {
+	s.config.SessionStorage = storage
+}
+
+// SetSessionCookieOutput sets the SessionCookieOutput for server.
+func (s *Server) SetSessionCookieOutput(enabled bool) {
+	s.config.SessionCookieOutput = enabled
+}
+
+// GetSessionManager returns the session manager of the server,
+// which is created using the session configurations when it is firstly used.
+func (s *Server) GetSessionManager() *gbsession.Manager {
+	s.sessionOnce.Do(func() {
+		storage := s.config.SessionStorage
+		if storage == nil {
+			if s.config.SessionPath != "" {
+				storage = gbsession.NewStorageFile(s.config.SessionPath)
+			} else {
+				storage = gbsession.NewStorageMemory()
+			}
+		}
+		s.sessionManager = gbsession.New(s.config.SessionMaxAge, storage)
+	})
+	return s.sessionManager
+}
+
+// SessionMiddleware returns a middleware which loads the session of current request
+// using the session id from header or cookie named SessionIdName, and saves it after the request done.
+//
+// The session id is output in both header and cookie (if SessionCookieOutput is enabled)
+// once it is created or regenerated, and the cookie is expired once the session is removed.
+// Note that the session id is output before the response is written,
+// so the session should be created before writing the response.
+func (s *Server) SessionMiddleware() gin.HandlerFunc {
+	var (
+		manager = s.GetSessionManager()
+		idName  = s.config.SessionIdName
+	)
+	if idName == "" {
+		idName = defaultSessionIdName
+	}
+	return func(c *gin.Context) {
+		requestId := c.GetHeader(idName)
+		if requestId == "" {
+			requestId, _ = c.Cookie(idName)
+		}
+		var (
+			session = manager.New(Ctx(c), requestId)
+			writer  = &sessionResponseWriter{ResponseWriter: c.Writer}
+		)
+		writer.outputId = func() {
+			s.outputSessionId(c, idName, requestId, session.CurrentId())
+		}
+		c.Set(ctxKeySession, session)
+		c.Writer = writer
+		defer func() {
+			c.Writer = writer.ResponseWriter
+			if err := session.Close(); err != nil {
+				s.Logger().Errorf(Ctx(c), `session close failed: %+v`, err)
+			}
+		}()
+		c.Next()
+		// The response header is not written if the handler only sets the status.
+		if !writer.Written() {
+			writer.once.Do(writer.outputId)
+		}
+	}
+}
+
+// GetSession returns the session of current request, which is set by SessionMiddleware.
+// It returns nil if SessionMiddleware is not used.
+func GetSession(c *gin.Context) *gbsession.Session {
+	if v, ok := c.Get(ctxKeySession); ok {
+		return v.(*gbsession.Session)
+	}
+	return nil
+}
+
+// outputSessionId outputs the session id to response header and cookie if it is changed.
+func (s *Server) outputSessionId(c *gin.Context, idName, requestId, sessionId string) {
+	if sessionId == requestId {
+		return
+	}
+	maxAge := int(s.config.SessionMaxAge.Seconds())
+	if sessionId == "" {
+		// The session is removed or invalid.
+		maxAge = -1
+	} else {
+		c.Header(idName, sessionId)
+	}
+	if s.config.SessionCookieOutput {
+		http.SetCookie(c.Writer, &http.Cookie{
+			Name:     idName,
+			Value:    sessionId,
+			Path:     "/",
+			MaxAge:   maxAge,
+			Secure:   s.config.SessionCookieSecure || c.Request.TLS != nil,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+}
+
+// WriteHeaderNow outputs the session id before writing the response header.
+func (w *sessionResponseWriter) WriteHeaderNow() {
+	w.once.Do(w.outputId)
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Write outputs the session id before writing the response.
+func (w *sessionResponseWriter) Write(data []byte) (int, error) {
+	w.once.Do(w.outputId)
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString outputs the session id before writing the response.
+func (w *sessionResponseWriter) WriteString(data string) (int, error) {
+	w.once.Do(w.outputId)
+	return w.ResponseWriter.WriteString(data)
+}
+
+// Flush outputs the session id before flushing the response.
+func (w *sessionResponseWriter) Flush() {
+	w.once.Do(w.outputId)
+	w.ResponseWriter.Flush()
+}
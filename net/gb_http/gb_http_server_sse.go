@@ -0,0 +1,181 @@
+package gbhttp
+
+import (
+	"bytes"
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	"ghostbb.io/gb/internal/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSSEKeepAlive = 15 * time.Second // Default interval of keep-alive comments.
+)
+
+// SSEEvent is an event of Server-Sent Events.
+type SSEEvent struct {
+	Id    string        // Id is the event id, which is sent back by client as Last-Event-ID header on reconnection.
+	Event string        // Event is the event type, which is "message" for client if empty.
+	Data  interface{}   // Data is the event data, which is encoded as JSON if it is not string or []byte.
+	Retry time.Duration // Retry specifies the reconnection time of client if it is not zero.
+}
+
+// SSEWriter is the streaming writer of Server-Sent Events for a single request.
+type SSEWriter struct {
+	mu      sync.Mutex
+	c       *gin.Context
+	closed  bool
+	stop    chan struct{}  // Stops the keep-alive goroutine.
+	stopped sync.WaitGroup // Waits for the keep-alive goroutine.
+}
+
+// NewSSEWriter creates and returns a Server-Sent Events writer for current request,
+// which writes the response header for event stream immediately.
+//
+// The writer should be closed before the handler returns, as the request context
+// is not valid anymore after the handler returns.
+func NewSSEWriter(c *gin.Context) *SSEWriter {
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// Disables the response buffering of reverse proxy like nginx.
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+	return &SSEWriter{
+		c:    c,
+		stop: make(chan struct{}),
+	}
+}
+
+// LastEventId returns the Last-Event-ID header sent by client on reconnection.
+func (w *SSEWriter) LastEventId() string {
+	return w.c.GetHeader("Last-Event-ID")
+}
+
+// Done returns a channel that is closed when the client disconnects.
+func (w *SSEWriter) Done() <-chan struct{} {
+	return w.c.Request.Context().Done()
+}
+
+// Send writes `event` to client and flushes it immediately.
+// It returns error if the client disconnects or the writer is closed.
+func (w *SSEWriter) Send(event SSEEvent) error {
+	var buffer bytes.Buffer
+	if event.Id != "" {
+		buffer.WriteString("id: " + sseLine(event.Id) + "\n")
+	}
+	if event.Event != "" {
+		buffer.WriteString("event: " + sseLine(event.Event) + "\n")
+	}
+	if event.Retry > 0 {
+		buffer.WriteString("retry: " + strconv.FormatInt(event.Retry.Milliseconds(), 10) + "\n")
+	}
+	data, err := sseData(event.Data)
+	if err != nil {
+		return err
+	}
+	// Multiple lines data is sent as multiple "data" fields, which are joined by client.
+	for _, line := range strings.Split(data, "\n") {
+		buffer.WriteString("data: " + strings.TrimSuffix(line, "\r") + "\n")
+	}
+	buffer.WriteString("\n")
+	return w.write(buffer.Bytes())
+}
+
+// Comment writes a comment line to client, which is ignored by client
+// and usually used for keeping the connection alive.
+func (w *SSEWriter) Comment(text string) error {
+	return w.write([]byte(": " + sseLine(text) + "\n\n"))
+}
+
+// KeepAlive starts a goroutine writing comments to client with `interval`,
+// which prevents the idle connection from being closed by proxies.
+// The goroutine stops when the writer is closed or the client disconnects.
+func (w *SSEWriter) KeepAlive(interval ...time.Duration) {
+	d := defaultSSEKeepAlive
+	if len(interval) > 0 && interval[0] > 0 {
+		d = interval[0]
+	}
+	w.stopped.Add(1)
+	go func() {
+		defer w.stopped.Done()
+		ticker := time.NewTicker(d)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := w.Comment("keep-alive"); err != nil {
+					return
+				}
+			case <-w.stop:
+				return
+			case <-w.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the keep-alive goroutine and marks the writer closed.
+// It does not close the connection, which is done after the handler returns.
+func (w *SSEWriter) Close() {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.closed = true
+	close(w.stop)
+	w.mu.Unlock()
+	w.stopped.Wait()
+}
+
+// write writes `data` to client and flushes it in concurrent safety.
+func (w *SSEWriter) write(data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return gberror.NewCode(gbcode.CodeInvalidOperation, `sse writer is closed`)
+	}
+	select {
+	case <-w.Done():
+		return gberror.WrapCode(gbcode.CodeOperationFailed, w.c.Request.Context().Err(), `sse client disconnected`)
+	default:
+	}
+	if _, err := w.c.Writer.Write(data); err != nil {
+		return gberror.Wrap(err, `sse write failed`)
+	}
+	w.c.Writer.Flush()
+	return nil
+}
+
+// sseData converts `data` to string for the event data field.
+func sseData(data interface{}) (string, error) {
+	switch v := data.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", gberror.Wrap(err, `sse data marshal failed`)
+		}
+		return string(b), nil
+	}
+}
+
+// sseLine removes the line breaks in single line field, which would break the event stream.
+func sseLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
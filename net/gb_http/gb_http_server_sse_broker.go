@@ -0,0 +1,179 @@
+package gbhttp
+
+import (
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSSEBufferSize = 100 // Default size of replay buffer of broker.
+	defaultSSEQueueSize  = 64  // Default size of event queue of each subscriber.
+)
+
+// SSEBrokerOption is the option for SSEBroker.
+type SSEBrokerOption struct {
+	BufferSize int           // BufferSize specifies the count of recent events kept for Last-Event-ID resumption.
+	QueueSize  int           // QueueSize specifies the size of event queue of each subscriber.
+	KeepAlive  time.Duration // KeepAlive specifies the interval of keep-alive comments.
+}
+
+// SSEBroker publishes Server-Sent Events to many subscribers,
+// and replays the missed events for reconnected subscribers using Last-Event-ID.
+type SSEBroker struct {
+	mu          sync.RWMutex
+	option      SSEBrokerOption
+	subscribers map[chan SSEEvent]struct{} // Event queues of subscribers.
+	buffer      []SSEEvent                 // Replay buffer of recent events in publishing order.
+	sequence    uint64                     // Sequence for generating event id.
+	closed      bool
+	done        chan struct{} // Closed when the broker is closed.
+}
+
+// NewSSEBroker creates and returns a new Server-Sent Events broker.
+func NewSSEBroker(option ...SSEBrokerOption) *SSEBroker {
+	var o SSEBrokerOption
+	if len(option) > 0 {
+		o = option[0]
+	}
+	if o.BufferSize <= 0 {
+		o.BufferSize = defaultSSEBufferSize
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = defaultSSEQueueSize
+	}
+	if o.KeepAlive <= 0 {
+		o.KeepAlive = defaultSSEKeepAlive
+	}
+	return &SSEBroker{
+		option:      o,
+		subscribers: make(map[chan SSEEvent]struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Publish publishes `event` to all subscribers and keeps it in replay buffer.
+// The event id is generated in sequence if it is empty.
+//
+// The subscriber whose queue is full is disconnected, as it cannot keep up with the events,
+// and it resumes from the replay buffer after reconnecting.
+func (b *SSEBroker) Publish(event SSEEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.sequence++
+	if event.Id == "" {
+		event.Id = strconv.FormatUint(b.sequence, 10)
+	}
+	b.buffer = append(b.buffer, event)
+	if len(b.buffer) > b.option.BufferSize {
+		b.buffer = b.buffer[len(b.buffer)-b.option.BufferSize:]
+	}
+	for queue := range b.subscribers {
+		select {
+		case queue <- event:
+		default:
+			delete(b.subscribers, queue)
+			close(queue)
+		}
+	}
+}
+
+// Size returns the subscriber count of the broker.
+func (b *SSEBroker) Size() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subscribers)
+}
+
+// Close closes the broker and disconnects all subscribers.
+func (b *SSEBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for queue := range b.subscribers {
+		delete(b.subscribers, queue)
+		close(queue)
+	}
+	close(b.done)
+}
+
+// Serve is the handler streaming the events of the broker to client,
+// which replays the events after Last-Event-ID before streaming new events.
+// It returns when the client disconnects or the broker is closed.
+func (b *SSEBroker) Serve(c *gin.Context) {
+	writer := NewSSEWriter(c)
+	defer writer.Close()
+
+	queue, replay := b.subscribe(writer.LastEventId())
+	if queue == nil {
+		return
+	}
+	defer b.unsubscribe(queue)
+	for _, event := range replay {
+		if err := writer.Send(event); err != nil {
+			return
+		}
+	}
+	ticker := time.NewTicker(b.option.KeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case event, ok := <-queue:
+			if !ok {
+				return
+			}
+			if err := writer.Send(event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := writer.Comment("keep-alive"); err != nil {
+				return
+			}
+		case <-writer.Done():
+			return
+		}
+	}
+}
+
+// subscribe registers a new subscriber and returns its queue along with the events to replay.
+// The events after `lastEventId` are replayed, or all buffered events are replayed if
+// `lastEventId` is not in the buffer, which means the client has missed more events than buffered.
+// It returns nil queue if the broker is closed.
+func (b *SSEBroker) subscribe(lastEventId string) (chan SSEEvent, []SSEEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, nil
+	}
+	var replay []SSEEvent
+	if lastEventId != "" {
+		replay = b.buffer
+		for i := len(b.buffer) - 1; i >= 0; i-- {
+			if b.buffer[i].Id == lastEventId {
+				replay = b.buffer[i+1:]
+				break
+			}
+		}
+		replay = append([]SSEEvent(nil), replay...)
+	}
+	queue := make(chan SSEEvent, b.option.QueueSize)
+	b.subscribers[queue] = struct{}{}
+	return queue, replay
+}
+
+// unsubscribe removes the subscriber of `queue` if it is not removed.
+func (b *SSEBroker) unsubscribe(queue chan SSEEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[queue]; ok {
+		delete(b.subscribers, queue)
+		close(queue)
+	}
+}
@@ -0,0 +1,278 @@
+package gbhttp
+
+import (
+	"bytes"
+	"fmt"
+	gbfile "ghostbb.io/gb/os/gb_file"
+	gbres "ghostbb.io/gb/os/gb_res"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// defaultIndexFiles is the default index files for static directory.
+	defaultIndexFiles = []string{"index.html", "index.htm"}
+)
+
+// StaticOption is the option for static file serving.
+type StaticOption struct {
+	Path          string          // Path specifies the directory of static files, which is searched in resource first and then on disk.
+	Resource      *gbres.Resource // Resource specifies the packed resource, which uses the default gbres instance if nil.
+	IndexFiles    []string        // IndexFiles specifies the index files searched for directory, which are "index.html" and "index.htm" in default.
+	SpaFallback   bool            // SpaFallback serves the root index file for not found html request of path without extension, which is used for single page application.
+	SpaExclude    []string        // SpaExclude specifies the path prefixes without SpaFallback, like "/api", which respond 404 if not found.
+	MaxAge        time.Duration   // MaxAge specifies the max-age of Cache-Control header for static files except html, which is not sent if it is zero.
+	Precompressed bool            // Precompressed serves the precompressed ".gz" variant of file if it exists and the client accepts gzip.
+	DotFiles      bool            // DotFiles serves the files of path segment starting with ".", which respond 404 in default.
+}
+
+// staticHandler serves the static files from packed resource or disk.
+type staticHandler struct {
+	option   StaticOption
+	resource *gbres.Resource
+	realPath string // Real path of the directory on disk with symlinks resolved, which is empty if it does not exist.
+}
+
+// staticFile is the opened static file from packed resource or disk.
+type staticFile struct {
+	path    string // Path relative to the static directory.
+	modTime time.Time
+	size    int64
+	content io.ReadSeeker
+}
+
+// SetServerRoot sets the directory of static files served at root route.
+// The directory is searched in gbres first and then on disk.
+// Note that it should be called before the server starts.
+func (s *Server) SetServerRoot(root string) {
+	s.config.ServerRoot = root
+}
+
+// SetSpaFallback enables or disables the single page application fallback for ServerRoot,
+// which is not applied to the paths of `excludePrefixes` like "/api".
+func (s *Server) SetSpaFallback(enabled bool, excludePrefixes ...string) {
+	s.config.SpaFallback = enabled
+	s.config.SpaExcludePrefixes = excludePrefixes
+}
+
+// AddStaticPath serves the static files under `prefix` route using `option`.
+//
+// The static files are served as fallback using NoRoute if `prefix` is "/",
+// as the catch-all route at root conflicts with other routes.
+func (s *Server) AddStaticPath(prefix string, option StaticOption) {
+	handler := StaticHandler(option)
+	prefix = strings.TrimRight(prefix, "/")
+	if prefix == "" {
+		s.NoRoute(handler)
+		return
+	}
+	s.GET(prefix+"/*filepath", handler)
+	s.HEAD(prefix+"/*filepath", handler)
+}
+
+// StaticHandler returns a handler serving the static files using `option`,
+// which can be bound to route with "*filepath" parameter or used as NoRoute handler.
+//
+// It supports ETag and Last-Modified validation, Range requests, index files,
+// precompressed ".gz" variants and single page application fallback.
+// The hidden files of path segment starting with "." are not served unless StaticOption.DotFiles is enabled,
+// and the symbolic links on disk are not followed, so that the files outside the directory are not exposed.
+// The single page application fallback only applies to the request accepting "text/html" like browser navigation.
+func StaticHandler(option StaticOption) gin.HandlerFunc {
+	h := &staticHandler{
+		option:   option,
+		resource: option.Resource,
+	}
+	if option.Path != "" {
+		if h.realPath = gbfile.RealPath(option.Path); h.realPath != "" {
+			if realPath, err := filepath.EvalSymlinks(h.realPath); err == nil {
+				h.realPath = realPath
+			}
+		}
+	}
+	if h.resource == nil {
+		h.resource = gbres.Instance()
+	}
+	if len(h.option.IndexFiles) == 0 {
+		h.option.IndexFiles = defaultIndexFiles
+	}
+	return h.serve
+}
+
+// initStatic registers the static files serving for ServerRoot if it is configured.
+func (s *Server) initStatic() {
+	if s.config.ServerRoot == "" {
+		return
+	}
+	s.AddStaticPath("/", StaticOption{
+		Path:          s.config.ServerRoot,
+		IndexFiles:    s.config.IndexFiles,
+		SpaFallback:   s.config.SpaFallback,
+		SpaExclude:    s.config.SpaExcludePrefixes,
+		MaxAge:        s.config.StaticMaxAge,
+		Precompressed: s.config.StaticPrecompressed,
+		DotFiles:      s.config.StaticDotFiles,
+	})
+}
+
+// serve serves the static file of current request.
+func (h *staticHandler) serve(c *gin.Context) {
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	filePath := c.Param("filepath")
+	if filePath == "" {
+		filePath = c.Request.URL.Path
+	}
+	// Cleaning the absolute path prevents directory traversal.
+	filePath = path.Clean("/" + filePath)
+	if !h.option.DotFiles && strings.Contains(filePath, "/.") {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	file := h.resolve(filePath)
+	if file == nil && h.isSpaFallback(c, filePath) {
+		file = h.resolve("/")
+	}
+	if file == nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	defer func() {
+		closeStaticFile(file)
+	}()
+
+	var (
+		header = c.Writer.Header()
+		name   = path.Base(file.path)
+		isHtml = strings.HasSuffix(name, ".html") || strings.HasSuffix(name, ".htm")
+	)
+	if h.option.Precompressed {
+		header.Add("Vary", "Accept-Encoding")
+		if acceptsGzip(c.GetHeader("Accept-Encoding")) {
+			if gzFile, _ := h.open(file.path + ".gz"); gzFile != nil {
+				closeStaticFile(file)
+				file = gzFile
+				header.Set("Content-Encoding", "gzip")
+			}
+		}
+	}
+	header.Set("ETag", fmt.Sprintf(`"%x-%x"`, file.modTime.Unix(), file.size))
+	switch {
+	case isHtml:
+		// The html files like index.html of single page application reference
+		// the hashed assets, which should be always revalidated.
+		header.Set("Cache-Control", "no-cache")
+	case h.option.MaxAge > 0:
+		header.Set("Cache-Control", "public, max-age="+strconv.FormatInt(int64(h.option.MaxAge/time.Second), 10))
+	}
+	// The name of original file is used for detecting the content type of precompressed variant.
+	http.ServeContent(c.Writer, c.Request, name, file.modTime, file.content)
+}
+
+// isSpaFallback checks whether the not found `filePath` falls back to the root index file,
+// which is the html request of path without extension and not excluded.
+func (h *staticHandler) isSpaFallback(c *gin.Context, filePath string) bool {
+	if !h.option.SpaFallback || path.Ext(filePath) != "" || !strings.Contains(c.GetHeader("Accept"), "text/html") {
+		return false
+	}
+	for _, prefix := range h.option.SpaExclude {
+		prefix = "/" + strings.Trim(prefix, "/")
+		if filePath == prefix || strings.HasPrefix(filePath, prefix+"/") {
+			return false
+		}
+	}
+	return true
+}
+
+// resolve returns the file of `filePath`, which searches the index files if it is a directory.
+// It returns nil if the file does not exist.
+func (h *staticHandler) resolve(filePath string) *staticFile {
+	file, isDir := h.open(filePath)
+	if !isDir {
+		return file
+	}
+	for _, index := range h.option.IndexFiles {
+		if file, _ = h.open(path.Join(filePath, index)); file != nil {
+			return file
+		}
+	}
+	return nil
+}
+
+// open opens the file of `filePath` from resource first and then from disk.
+// It returns nil file and true if `filePath` is a directory.
+func (h *staticHandler) open(filePath string) (*staticFile, bool) {
+	if !h.resource.IsEmpty() {
+		if file := h.resource.Get(path.Join(h.option.Path, filePath)); file != nil {
+			info := file.FileInfo()
+			if info.IsDir() {
+				return nil, true
+			}
+			return &staticFile{
+				path:    filePath,
+				modTime: info.ModTime(),
+				size:    info.Size(),
+				content: bytes.NewReader(file.Content()),
+			}, false
+		}
+	}
+	if h.realPath == "" {
+		return nil, false
+	}
+	realPath := filepath.Join(h.realPath, filepath.FromSlash(filePath))
+	// The path containing symbolic link is not served, as the link may point to outside of the directory.
+	if resolved, err := filepath.EvalSymlinks(realPath); err != nil || resolved != realPath {
+		return nil, false
+	}
+	info, err := os.Stat(realPath)
+	if err != nil {
+		return nil, false
+	}
+	if info.IsDir() {
+		return nil, true
+	}
+	file, err := os.Open(realPath)
+	if err != nil {
+		return nil, false
+	}
+	return &staticFile{
+		path:    filePath,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		content: file,
+	}, false
+}
+
+// closeStaticFile closes the file if it is opened from disk.
+func closeStaticFile(file *staticFile) {
+	if closer, ok := file.content.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
+
+// acceptsGzip checks whether the Accept-Encoding header accepts gzip encoding.
+func acceptsGzip(acceptEncoding string) bool {
+	for _, item := range strings.Split(acceptEncoding, ",") {
+		var (
+			parts    = strings.Split(item, ";")
+			encoding = strings.TrimSpace(parts[0])
+		)
+		if encoding != "gzip" && encoding != "*" {
+			continue
+		}
+		if len(parts) > 1 && strings.ReplaceAll(strings.TrimSpace(parts[1]), " ", "") == "q=0" {
+			return false
+		}
+		return true
+	}
+	return false
+}
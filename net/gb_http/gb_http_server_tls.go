@@ -0,0 +1,83 @@
+package gbhttp
+
+import (
+	"crypto/tls"
+	gbtls "ghostbb.io/gb/net/gb_tls"
+)
+
+// SetTLSCertificates sets additional certificates chosen by SNI of client and enables HTTPS feature for the server.
+// The certificate of EnableHTTPS is the default certificate if it is set, or else the first one of `pairs` is.
+// The certificate files are watched and reloaded for new connections once changed.
+func (s *Server) SetTLSCertificates(pairs ...gbtls.KeyPair) {
+	s.config.TLSCertificates = pairs
+}
+
+// SetTLSClientCA enables client certificate verification (mTLS) using the CA bundle `caFile`.
+// The optional parameter `clientAuth` is gbtls.ClientAuthRequire in default, or gbtls.ClientAuthOptional.
+func (s *Server) SetTLSClientCA(caFile string, clientAuth ...string) {
+	s.config.TLSClientCA = caFile
+	if len(clientAuth) > 0 {
+		s.config.TLSClientAuth = clientAuth[0]
+	}
+}
+
+// GetCertManager returns the certificate manager of the HTTPS server,
+// which is nil if the server is not started with certificate files.
+func (s *Server) GetCertManager() *gbtls.CertManager {
+	s.certMu.Lock()
+	defer s.certMu.Unlock()
+	return s.certManager
+}
+
+// isHttpsConfigured checks whether any certificate is configured for HTTPS.
+func (s *Server) isHttpsConfigured() bool {
+	return (s.config.HTTPSCertPath != "" && s.config.HTTPSKeyPath != "") || len(s.config.TLSCertificates) > 0
+}
+
+// newTLSConfig creates and returns the TLS configuration of HTTPS servers.
+// The custom TLSConfig is used as it is if it contains certificates and no certificate file is configured,
+// or else the certificates are managed by a certificate manager which supports SNI and hot reloading.
+func (s *Server) newTLSConfig() (*tls.Config, error) {
+	config := s.config.TLSConfig
+	if config != nil && (len(config.Certificates) > 0 || config.GetCertificate != nil) &&
+		!s.isHttpsConfigured() && s.config.TLSClientCA == "" {
+		return config, nil
+	}
+	manager := gbtls.NewCertManager()
+	manager.SetLogger(s.Logger())
+	if s.config.HTTPSCertPath != "" && s.config.HTTPSKeyPath != "" {
+		if err := manager.Add(s.config.HTTPSCertPath, s.config.HTTPSKeyPath); err != nil {
+			manager.Close()
+			return nil, err
+		}
+	}
+	for _, pair := range s.config.TLSCertificates {
+		if err := manager.Add(pair.CertFile, pair.KeyFile); err != nil {
+			manager.Close()
+			return nil, err
+		}
+	}
+	if s.config.TLSClientCA != "" {
+		if err := manager.SetClientCA(s.config.TLSClientCA, s.config.TLSClientAuth); err != nil {
+			manager.Close()
+			return nil, err
+		}
+	}
+	s.certMu.Lock()
+	if s.certManager != nil {
+		s.certManager.Close()
+	}
+	s.certManager = manager
+	s.certMu.Unlock()
+	return manager.TLSConfig(config), nil
+}
+
+// closeCertManager stops the certificate manager from watching certificate files.
+func (s *Server) closeCertManager() {
+	s.certMu.Lock()
+	defer s.certMu.Unlock()
+	if s.certManager != nil {
+		s.certManager.Close()
+		s.certManager = nil
+	}
+}
@@ -5,8 +5,11 @@ package gbhttp
 
 import (
 	"context"
+	"ghostbb.io/gb/internal/intlog"
+	gblog "ghostbb.io/gb/os/gb_log"
 	gbproc "ghostbb.io/gb/os/gb_proc"
 	"os"
+	"syscall"
 )
 
 // handleProcessSignal handles all signals from system in blocking way.
@@ -15,6 +18,17 @@ func handleProcessSignal() {
 	gbproc.AddSigHandlerShutdown(func(sig os.Signal) {
 		shutdownWebServersGracefully(ctx, sig)
 	})
+	gbproc.AddSigHandler(func(sig os.Signal) {
+		// If the graceful restart feature is not enabled,
+		// it does nothing except printing a warning log.
+		if !gracefulEnabled.Val() {
+			gblog.Warning(ctx, "graceful reload feature is disabled")
+			return
+		}
+		if err := restartWebServers(ctx, sig, ""); err != nil {
+			intlog.Errorf(ctx, `%+v`, err)
+		}
+	}, syscall.SIGUSR1)
 
 	gbproc.Listen()
 }
@@ -0,0 +1,58 @@
+package gbhttp
+
+import (
+	"context"
+	gbws "ghostbb.io/gb/net/gb_ws"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+// NewWebSocketHub creates and returns a new WebSocket hub attached to the server,
+// which is closed gracefully when the server shuts down.
+func (s *Server) NewWebSocketHub(config ...gbws.Config) *gbws.Hub {
+	hub := gbws.New(config...)
+	s.wsMu.Lock()
+	s.wsHubs = append(s.wsHubs, hub)
+	s.wsMu.Unlock()
+	return hub
+}
+
+// WebSocketHandler returns a handler which upgrades the request to WebSocket connection of `hub`.
+// The optional `handler` is called after the connection is established,
+// which is usually used for binding user information or joining rooms.
+func WebSocketHandler(hub *gbws.Hub, handler ...func(c *gin.Context, conn *gbws.Conn)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// The error response is written by the upgrader if it fails.
+		conn, err := hub.Upgrade(c.Writer, c.Request)
+		if err != nil {
+			_ = c.Error(err)
+			c.Abort()
+			return
+		}
+		if len(handler) > 0 && handler[0] != nil {
+			handler[0](c, conn)
+		}
+	}
+}
+
+// closeWebSocketHubs closes all WebSocket hubs of the server,
+// as the hijacked connections are not closed by shutting down the underlying http servers.
+func (s *Server) closeWebSocketHubs(ctx context.Context) {
+	s.wsMu.Lock()
+	hubs := s.wsHubs
+	s.wsHubs = nil
+	s.wsMu.Unlock()
+	if len(hubs) == 0 {
+		return
+	}
+	timeoutCtx, cancelFunc := context.WithTimeout(
+		ctx,
+		time.Duration(s.config.GracefulShutdownTimeout)*time.Second,
+	)
+	defer cancelFunc()
+	for _, hub := range hubs {
+		if err := hub.Close(timeoutCtx); err != nil {
+			s.Logger().Errorf(ctx, `%+v`, err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package gbhttp_test
+
+import (
+	"context"
+	gbjwt "ghostbb.io/gb/crypto/gb_jwt"
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	"ghostbb.io/gb/frame/g"
+	"ghostbb.io/gb/internal/json"
+	gbhttp "ghostbb.io/gb/net/gb_http"
+	gbtest "ghostbb.io/gb/test/gb_test"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+type counterReq struct {
+	g.Meta `path:"/counter" method:"get,post"`
+	Fail   bool
+}
+
+type counterRes struct {
+	Count int64 `json:"count"`
+}
+
+// newCounterHandler returns a typed handler counting its calls, which fails if the request asks.
+func newCounterHandler(count *int64) func(ctx context.Context, req *counterReq) (*counterRes, error) {
+	return func(ctx context.Context, req *counterReq) (*counterRes, error) {
+		n := atomic.AddInt64(count, 1)
+		if req.Fail {
+			return nil, gberror.NewCode(gbcode.CodeInvalidParameter, "failed")
+		}
+		return &counterRes{Count: n}, nil
+	}
+}
+
+func doRequest(s *gbhttp.Server, method, url string, header map[string]string) *httptest.ResponseRecorder {
+	var (
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(method, url, strings.NewReader(""))
+	)
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	s.ServeHTTP(w, r)
+	return w
+}
+
+// decodeResponse decodes the envelope written by ResponseMiddleware.
+func decodeResponse(t *gbtest.T, w *httptest.ResponseRecorder) gbhttp.DefaultResponse {
+	var res gbhttp.DefaultResponse
+	t.AssertNil(json.Unmarshal(w.Body.Bytes(), &res))
+	return res
+}
+
+func Test_IdempotencyMiddleware_ResponseMiddleware(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		var (
+			count int64
+			s     = gbhttp.GetServer("Test_IdempotencyMiddleware_ResponseMiddleware")
+			key   = map[string]string{"Idempotency-Key": "key1"}
+		)
+		s.Use(s.ResponseMiddleware())
+		gbhttp.BindHandler(s.Group("/", s.IdempotencyMiddleware()), newCounterHandler(&count))
+
+		w := doRequest(s, http.MethodPost, "/counter", key)
+		t.Assert(w.Code, http.StatusOK)
+		t.Assert(w.Body.String(), `{"code":0,"message":"OK","data":{"count":1}}`)
+
+		// The envelope written by ResponseMiddleware is replayed.
+		w = doRequest(s, http.MethodPost, "/counter", key)
+		t.Assert(w.Code, http.StatusOK)
+		t.Assert(w.Header().Get("Idempotent-Replayed"), "true")
+		t.Assert(w.Body.String(), `{"code":0,"message":"OK","data":{"count":1}}`)
+		t.Assert(atomic.LoadInt64(&count), 1)
+	})
+	gbtest.C(t, func(t *gbtest.T) {
+		var (
+			count int64
+			s     = gbhttp.GetServer("Test_IdempotencyMiddleware_ResponseMiddleware_Error")
+			key   = map[string]string{"Idempotency-Key": "key1"}
+		)
+		s.Use(s.ResponseMiddleware())
+		gbhttp.BindHandler(s.Group("/", s.IdempotencyMiddleware()), newCounterHandler(&count))
+
+		w := doRequest(s, http.MethodPost, "/counter?fail=true", key)
+		t.Assert(w.Code, http.StatusBadRequest)
+		t.Assert(w.Header().Get("Idempotent-Replayed"), "")
+
+		// The key is released for the failed request, so that it can be retried.
+		w = doRequest(s, http.MethodPost, "/counter?fail=true", key)
+		t.Assert(w.Code, http.StatusBadRequest)
+		t.Assert(w.Header().Get("Idempotent-Replayed"), "")
+		t.Assert(atomic.LoadInt64(&count), 2)
+	})
+	gbtest.C(t, func(t *gbtest.T) {
+		var (
+			count int64
+			s     = gbhttp.GetServer("Test_IdempotencyMiddleware_ResponseMiddleware_Reject")
+		)
+		s.Use(s.ResponseMiddleware())
+		gbhttp.BindHandler(
+			s.Group("/", s.IdempotencyMiddleware(gbhttp.IdempotencyOption{Required: true})),
+			newCounterHandler(&count),
+		)
+
+		// The rejection is written as the error envelope.
+		w := doRequest(s, http.MethodPost, "/counter", nil)
+		t.Assert(w.Code, http.StatusBadRequest)
+		res := decodeResponse(t, w)
+		t.Assert(res.Code, gbcode.CodeMissingParameter.Code())
+		t.Assert(res.Message, "missing Idempotency-Key header")
+		t.Assert(atomic.LoadInt64(&count), 0)
+	})
+}
+
+func Test_ResponseCache_ResponseMiddleware(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		var (
+			count int64
+			s     = gbhttp.GetServer("Test_ResponseCache_ResponseMiddleware")
+			cache = s.NewResponseCache()
+		)
+		s.Use(s.ResponseMiddleware())
+		gbhttp.BindHandler(s.Group("/", cache.Middleware(gbhttp.CacheRule{Query: []string{"fail"}})), newCounterHandler(&count))
+
+		w := doRequest(s, http.MethodGet, "/counter", nil)
+		t.Assert(w.Code, http.StatusOK)
+		t.Assert(w.Header().Get("X-Cache"), "MISS")
+		t.Assert(w.Body.String(), `{"code":0,"message":"OK","data":{"count":1}}`)
+
+		// The envelope written by ResponseMiddleware is cached.
+		w = doRequest(s, http.MethodGet, "/counter", nil)
+		t.Assert(w.Code, http.StatusOK)
+		t.Assert(w.Header().Get("X-Cache"), "HIT")
+		t.Assert(w.Body.String(), `{"code":0,"message":"OK","data":{"count":1}}`)
+		t.Assert(atomic.LoadInt64(&count), 1)
+
+		// The error response is not cached.
+		w = doRequest(s, http.MethodGet, "/counter?fail=true", nil)
+		t.Assert(w.Code, http.StatusBadRequest)
+		t.Assert(w.Header().Get("X-Cache"), "MISS")
+		w = doRequest(s, http.MethodGet, "/counter?fail=true", nil)
+		t.Assert(w.Code, http.StatusBadRequest)
+		t.Assert(w.Header().Get("X-Cache"), "MISS")
+		t.Assert(atomic.LoadInt64(&count), 3)
+	})
+}
+
+func Test_JwtMiddleware_ResponseMiddleware(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		var (
+			count int64
+			s     = gbhttp.GetServer("Test_JwtMiddleware_ResponseMiddleware")
+		)
+		s.Use(s.ResponseMiddleware())
+		jwt := s.JwtMiddleware(gbhttp.JwtOption{Verifier: gbjwt.NewVerifier(gbjwt.VerifierConfig{})})
+		gbhttp.BindHandler(s.Group("/", jwt), newCounterHandler(&count))
+
+		w := doRequest(s, http.MethodGet, "/counter", nil)
+		t.Assert(w.Code, http.StatusUnauthorized)
+		t.Assert(w.Header().Get("WWW-Authenticate"), "Bearer")
+		res := decodeResponse(t, w)
+		t.Assert(res.Code, gbcode.CodeNotAuthorized.Code())
+		t.Assert(res.Message, "missing token")
+
+		w = doRequest(s, http.MethodGet, "/counter", map[string]string{"Authorization": "Bearer invalid"})
+		t.Assert(w.Code, http.StatusUnauthorized)
+		res = decodeResponse(t, w)
+		t.Assert(res.Code, gbcode.CodeNotAuthorized.Code())
+		t.Assert(res.Message, "invalid token")
+		t.Assert(atomic.LoadInt64(&count), 0)
+	})
+}
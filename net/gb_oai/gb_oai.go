@@ -0,0 +1,161 @@
+// Package gboai implements simple OpenAPI v3 document generation for typed HTTP handlers.
+//
+// The request and response schemas are derived from struct fields, using `json` tags for names,
+// `v` tags for validation constraints and `dc` tags for descriptions.
+package gboai
+
+import (
+	"ghostbb.io/gb/internal/json"
+)
+
+const (
+	// OpenApiVersion is the OpenAPI specification version of generated document.
+	OpenApiVersion = "3.0.3"
+
+	MimeJson      = "application/json"    // Default content type for request and response body.
+	MimeForm      = "multipart/form-data" // Content type for request body having file uploading fields.
+	RefPrefix     = "#/components/schemas/"
+	ParameterPath = "path"   // Parameter in path.
+	ParameterQry  = "query"  // Parameter in query.
+	ParameterHdr  = "header" // Parameter in header.
+	ParameterCkie = "cookie" // Parameter in cookie.
+)
+
+// OpenApiV3 is the root object of OpenAPI v3 document.
+type OpenApiV3 struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Servers    []Server              `json:"servers,omitempty"`
+	Paths      map[string]Path       `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []SecurityRequirement `json:"security,omitempty"`
+	Tags       []Tag                 `json:"tags,omitempty"`
+}
+
+// Info provides metadata about the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is an object representing a server of the API.
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// Tag adds metadata to a single tag that is used by operations.
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Path describes the operations available on a single path, the key is the lower case method.
+type Path map[string]*Operation
+
+// Operation describes a single API operation on a path.
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+	Security    []SecurityRequirement `json:"security,omitempty"`
+}
+
+// Parameter describes a single operation parameter.
+type Parameter struct {
+	Name        string      `json:"name"`
+	In          string      `json:"in"`
+	Description string      `json:"description,omitempty"`
+	Required    bool        `json:"required,omitempty"`
+	Schema      *Schema     `json:"schema,omitempty"`
+	Example     interface{} `json:"example,omitempty"`
+}
+
+// RequestBody describes a single request body.
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+// Response describes a single response from an API operation.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType provides schema for the media type.
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Components holds a set of reusable objects.
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme defines a security scheme that can be used by the operations.
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Description  string `json:"description,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// SecurityRequirement lists the required security schemes to execute the operation.
+type SecurityRequirement map[string][]string
+
+// Schema is the definition of input and output data types.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Example              interface{}        `json:"example,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *uint64            `json:"minLength,omitempty"`
+	MaxLength            *uint64            `json:"maxLength,omitempty"`
+	MinItems             *uint64            `json:"minItems,omitempty"`
+	MaxItems             *uint64            `json:"maxItems,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+}
+
+// New creates and returns an empty OpenApiV3 document.
+func New() *OpenApiV3 {
+	return &OpenApiV3{
+		OpenAPI: OpenApiVersion,
+		Info: Info{
+			Title:   "API Reference",
+			Version: "1.0.0",
+		},
+		Paths: make(map[string]Path),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+		},
+	}
+}
+
+// String returns the document as JSON string.
+func (oai *OpenApiV3) String() string {
+	b, err := json.Marshal(oai)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
@@ -0,0 +1,173 @@
+package gboai
+
+import (
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	gbstr "ghostbb.io/gb/text/gb_str"
+	gbmeta "ghostbb.io/gb/util/gb_meta"
+	gbtag "ghostbb.io/gb/util/gb_tag"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// AddInput is the input parameter for function Add.
+type AddInput struct {
+	Path     string       // Route path in gin style like "/user/:id" or "/file/*filepath".
+	Method   string       // HTTP method of the route like "GET".
+	Request  reflect.Type // Struct type of the request object, which embeds g.Meta.
+	Response reflect.Type // Type of the response object, it can be nil.
+}
+
+// Add adds the operation of route to the document.
+//
+// The operation information like tags, summary and description is retrieved from g.Meta of request object.
+// The request fields are treated as parameters in path if the route contains the same name parameter,
+// or parameters specified by the `in` tag, or else in query for methods GET/HEAD/DELETE,
+// or else properties of the request body.
+func (oai *OpenApiV3) Add(in AddInput) error {
+	reqType := in.Request
+	for reqType != nil && reqType.Kind() == reflect.Ptr {
+		reqType = reqType.Elem()
+	}
+	if reqType == nil || reqType.Kind() != reflect.Struct {
+		return gberror.NewCodef(
+			gbcode.CodeInvalidParameter,
+			`invalid request type "%v" for route "%s %s", it should be type of struct`,
+			in.Request, in.Method, in.Path,
+		)
+	}
+	var (
+		meta       = gbmeta.Data(reflect.New(reqType).Interface())
+		path, keys = convertPath(in.Path)
+		method     = strings.ToLower(in.Method)
+		operation  = &Operation{
+			Tags:        gbstr.SplitAndTrim(meta["tags"], ","),
+			Summary:     lookupMeta(meta, gbtag.SummaryShort, gbtag.SummaryShort2, gbtag.Summary),
+			Description: lookupMeta(meta, gbtag.DescriptionShort, gbtag.DescriptionShort2, gbtag.Description),
+			OperationID: meta["operationId"],
+			Responses:   make(map[string]*Response),
+			Deprecated:  meta["deprecated"] == "true",
+		}
+		bodyFields = make([]schemaField, 0)
+	)
+	if security := gbstr.SplitAndTrim(meta[gbtag.Security], ","); len(security) > 0 {
+		for _, name := range security {
+			operation.Security = append(operation.Security, SecurityRequirement{name: {}})
+		}
+	}
+	// Parameters and request body.
+	for _, field := range oai.structFields(reqType) {
+		var (
+			paramName = field.Name
+			paramIn   = field.Field.Tag.Get(gbtag.In)
+		)
+		if key, ok := keys[strings.ToLower(field.Name)]; ok && (paramIn == "" || paramIn == ParameterPath) {
+			paramName, paramIn = key, ParameterPath
+		} else if paramIn == "" && isQueryMethod(in.Method) {
+			paramIn = ParameterQry
+		}
+		if paramIn == "" {
+			bodyFields = append(bodyFields, field)
+			continue
+		}
+		schema, required := oai.fieldSchema(field.Field)
+		parameter := &Parameter{
+			Name:     paramName,
+			In:       paramIn,
+			Required: required || paramIn == ParameterPath,
+			Schema:   schema,
+		}
+		// The description and example are moved from schema to parameter.
+		parameter.Description, schema.Description = schema.Description, ""
+		parameter.Example, schema.Example = schema.Example, nil
+		operation.Parameters = append(operation.Parameters, parameter)
+	}
+	if len(bodyFields) > 0 {
+		var (
+			name     = SchemaName(reqType)
+			bodyMime = lookupMeta(meta, gbtag.Consumes, gbtag.Mime)
+		)
+		if bodyMime == "" {
+			bodyMime = MimeJson
+			if hasFileField(bodyFields) {
+				bodyMime = MimeForm
+			}
+		}
+		oai.Components.Schemas[name] = oai.structSchema(bodyFields)
+		operation.RequestBody = &RequestBody{
+			Required: len(oai.Components.Schemas[name].Required) > 0,
+			Content: map[string]MediaType{
+				bodyMime: {Schema: &Schema{Ref: RefPrefix + name}},
+			},
+		}
+	}
+	// Response.
+	response := &Response{Description: http.StatusText(http.StatusOK)}
+	if in.Response != nil && in.Response.Kind() != reflect.Interface {
+		resMime := meta[gbtag.Mime]
+		if resMime == "" {
+			resMime = MimeJson
+		}
+		response.Content = map[string]MediaType{
+			resMime: {Schema: oai.SchemaRef(in.Response)},
+		}
+	}
+	operation.Responses["200"] = response
+
+	if oai.Paths[path] == nil {
+		oai.Paths[path] = make(Path)
+	}
+	oai.Paths[path][method] = operation
+	return nil
+}
+
+// convertPath converts gin style route path to OpenAPI style,
+// like "/user/:id/*filepath" to "/user/{id}/{filepath}",
+// and returns the parameter names mapping from lower case to original.
+func convertPath(path string) (string, map[string]string) {
+	var (
+		keys  = make(map[string]string)
+		parts = strings.Split(path, "/")
+	)
+	for i, part := range parts {
+		if len(part) > 1 && (part[0] == ':' || part[0] == '*') {
+			keys[strings.ToLower(part[1:])] = part[1:]
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/"), keys
+}
+
+// isQueryMethod checks whether the request of `method` passes parameters in query.
+func isQueryMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// hasFileField checks whether `fields` contain file uploading field.
+func hasFileField(fields []schemaField) bool {
+	for _, field := range fields {
+		fieldType := field.Field.Type
+		for fieldType.Kind() == reflect.Ptr || fieldType.Kind() == reflect.Slice {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType == reflectTypeFileHeader {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupMeta returns the first non-empty value of `names` from `meta`.
+func lookupMeta(meta map[string]string, names ...string) string {
+	for _, name := range names {
+		if v := meta[name]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
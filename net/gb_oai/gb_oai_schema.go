@@ -0,0 +1,275 @@
+package gboai
+
+import (
+	gbconv "ghostbb.io/gb/util/gb_conv"
+	gbmeta "ghostbb.io/gb/util/gb_meta"
+	gbtag "ghostbb.io/gb/util/gb_tag"
+	gbvalid "ghostbb.io/gb/util/gb_valid"
+	"mime/multipart"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	reflectTypeTime       = reflect.TypeOf(time.Time{})
+	reflectTypeMeta       = reflect.TypeOf(gbmeta.Meta{})
+	reflectTypeFileHeader = reflect.TypeOf(multipart.FileHeader{})
+)
+
+// schemaField is a struct field with its name in document.
+type schemaField struct {
+	Name  string
+	Field reflect.StructField
+}
+
+// SchemaRef returns the schema of `reflectType`.
+// The named struct type is added to components and a reference schema is returned.
+func (oai *OpenApiV3) SchemaRef(reflectType reflect.Type) *Schema {
+	for reflectType.Kind() == reflect.Ptr {
+		reflectType = reflectType.Elem()
+	}
+	switch reflectType {
+	case reflectTypeTime:
+		return &Schema{Type: "string", Format: "date-time"}
+	case reflectTypeFileHeader:
+		return &Schema{Type: "string", Format: "binary"}
+	}
+	switch reflectType.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if reflectType.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: oai.SchemaRef(reflectType.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: oai.SchemaRef(reflectType.Elem())}
+	case reflect.Struct:
+		if reflectType.Name() == "" {
+			return oai.structSchema(oai.structFields(reflectType))
+		}
+		name := SchemaName(reflectType)
+		if _, ok := oai.Components.Schemas[name]; !ok {
+			// Placeholder for recursive struct definition.
+			oai.Components.Schemas[name] = &Schema{}
+			*oai.Components.Schemas[name] = *oai.structSchema(oai.structFields(reflectType))
+		}
+		return &Schema{Ref: RefPrefix + name}
+	default:
+		return &Schema{}
+	}
+}
+
+// SchemaName returns the component name of `reflectType`, which is composed of its package path and type name.
+func SchemaName(reflectType reflect.Type) string {
+	for reflectType.Kind() == reflect.Ptr {
+		reflectType = reflectType.Elem()
+	}
+	name := reflectType.Name()
+	if pkgPath := reflectType.PkgPath(); pkgPath != "" {
+		name = pkgPath + "." + name
+	}
+	// Component name should match regular expression "^[a-zA-Z0-9\.\-_]+$".
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '.'
+		}
+	}, name)
+}
+
+// structFields retrieves the exported fields of struct, the embedded struct without json name is flattened.
+func (oai *OpenApiV3) structFields(reflectType reflect.Type) []schemaField {
+	fields := make([]schemaField, 0, reflectType.NumField())
+	for i := 0; i < reflectType.NumField(); i++ {
+		field := reflectType.Field(i)
+		if field.Type == reflectTypeMeta || !field.IsExported() {
+			continue
+		}
+		name, ok := fieldName(field)
+		if !ok {
+			continue
+		}
+		if field.Anonymous && field.Tag.Get(gbtag.Json) == "" {
+			fieldType := field.Type
+			for fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct && fieldType != reflectTypeTime {
+				fields = append(fields, oai.structFields(fieldType)...)
+				continue
+			}
+		}
+		fields = append(fields, schemaField{Name: name, Field: field})
+	}
+	return fields
+}
+
+// structSchema creates and returns the object schema of `fields`.
+func (oai *OpenApiV3) structSchema(fields []schemaField) *Schema {
+	schema := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+	for _, field := range fields {
+		property, required := oai.fieldSchema(field.Field)
+		schema.Properties[field.Name] = property
+		if required {
+			schema.Required = append(schema.Required, field.Name)
+		}
+	}
+	return schema
+}
+
+// fieldSchema returns the schema of struct field with its tags applied,
+// and whether it is required according to its validation rules.
+func (oai *OpenApiV3) fieldSchema(field reflect.StructField) (schema *Schema, required bool) {
+	var rules = lookupTag(field, gbtag.ValidShort, gbtag.Valid)
+	schema = oai.SchemaRef(field.Type)
+	if schema.Ref != "" {
+		// Siblings of $ref are ignored in OpenAPI v3.0.
+		required = applyRules(&Schema{}, rules)
+		return
+	}
+	required = applyRules(schema, rules)
+	schema.Description = lookupTag(field, gbtag.DescriptionShort, gbtag.DescriptionShort2, gbtag.Description)
+	if v := lookupTag(field, gbtag.DefaultShort, gbtag.Default); v != "" {
+		schema.Default = convertValue(v, schema.Type)
+	}
+	if v := lookupTag(field, gbtag.ExampleShort, gbtag.Example); v != "" {
+		schema.Example = convertValue(v, schema.Type)
+	}
+	return
+}
+
+// applyRules applies the constraints of validation `rules` to `schema`,
+// and returns whether the rules contain "required".
+func applyRules(schema *Schema, rules string) (required bool) {
+	if rules == "" {
+		return false
+	}
+	_, rules, _ = gbvalid.ParseTagValue(rules)
+	for _, rule := range strings.Split(rules, "|") {
+		var (
+			name, args, _ = strings.Cut(strings.TrimSpace(rule), ":")
+			arr           = strings.Split(args, ",")
+		)
+		switch name {
+		case "required":
+			required = true
+		case "min", "gte":
+			schema.Minimum = newFloat(args)
+		case "max", "lte":
+			schema.Maximum = newFloat(args)
+		case "between":
+			if len(arr) == 2 {
+				schema.Minimum, schema.Maximum = newFloat(arr[0]), newFloat(arr[1])
+			}
+		case "min-length":
+			setMinLength(schema, args)
+		case "max-length":
+			setMaxLength(schema, args)
+		case "length":
+			if len(arr) == 2 {
+				setMinLength(schema, arr[0])
+				setMaxLength(schema, arr[1])
+			}
+		case "size":
+			setMinLength(schema, args)
+			setMaxLength(schema, args)
+		case "in":
+			for _, v := range arr {
+				schema.Enum = append(schema.Enum, convertValue(strings.TrimSpace(v), schema.Type))
+			}
+		case "regex":
+			schema.Pattern = args
+		case "email":
+			schema.Format = "email"
+		case "url":
+			schema.Format = "uri"
+		case "date":
+			schema.Format = "date"
+		case "datetime":
+			schema.Format = "date-time"
+		case "ipv4", "ipv6":
+			schema.Format = name
+		}
+	}
+	return
+}
+
+// setMinLength sets the minimum length or items of schema according to its type.
+func setMinLength(schema *Schema, v string) {
+	n := gbconv.Uint64(strings.TrimSpace(v))
+	if schema.Type == "array" {
+		schema.MinItems = &n
+	} else {
+		schema.MinLength = &n
+	}
+}
+
+// setMaxLength sets the maximum length or items of schema according to its type.
+func setMaxLength(schema *Schema, v string) {
+	n := gbconv.Uint64(strings.TrimSpace(v))
+	if schema.Type == "array" {
+		schema.MaxItems = &n
+	} else {
+		schema.MaxLength = &n
+	}
+}
+
+// fieldName returns the name of field in document, which is its json name or attribute name.
+// It returns false if the field is ignored by json tag "-".
+func fieldName(field reflect.StructField) (string, bool) {
+	jsonTag := field.Tag.Get(gbtag.Json)
+	if jsonTag == "-" {
+		return "", false
+	}
+	if name, _, _ := strings.Cut(jsonTag, ","); name != "" {
+		return name, true
+	}
+	return field.Name, true
+}
+
+// lookupTag returns the first non-empty tag value of `names` from `field`.
+func lookupTag(field reflect.StructField, names ...string) string {
+	for _, name := range names {
+		if v := field.Tag.Get(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// convertValue converts string `v` to value of schema type `schemaType`.
+func convertValue(v string, schemaType string) interface{} {
+	switch schemaType {
+	case "integer":
+		return gbconv.Int64(v)
+	case "number":
+		return gbconv.Float64(v)
+	case "boolean":
+		return gbconv.Bool(v)
+	default:
+		return v
+	}
+}
+
+func newFloat(v string) *float64 {
+	f := gbconv.Float64(strings.TrimSpace(v))
+	return &f
+}
@@ -0,0 +1,126 @@
+package gboai_test
+
+import (
+	"ghostbb.io/gb/frame/g"
+	gboai "ghostbb.io/gb/net/gb_oai"
+	gbtest "ghostbb.io/gb/test/gb_test"
+	"reflect"
+	"testing"
+)
+
+type CommonPage struct {
+	Page int `json:"page" d:"1" v:"min:1" dc:"Page number"`
+	Size int `json:"size" d:"10" v:"between:1,100"`
+}
+
+type UserListReq struct {
+	g.Meta `path:"/user" method:"get" tags:"User" summary:"List users" security:"bearer"`
+	CommonPage
+	Status string `json:"status" v:"in:enabled,disabled" dc:"User status"`
+	Token  string `json:"token" in:"header"`
+}
+
+type UserCreateReq struct {
+	g.Meta `path:"/user/:id" method:"post" tags:"User" dc:"Create user"`
+	Id     int      `v:"required"`
+	Name   string   `json:"name" v:"required|length:2,20" eg:"john"`
+	Email  string   `json:"email" v:"email"`
+	Roles  []string `json:"roles" v:"max-length:3"`
+	Secret string   `json:"-"`
+}
+
+type UserItem struct {
+	Id     int         `json:"id"`
+	Name   string      `json:"name"`
+	Parent *UserItem   `json:"parent"`
+	Extra  g.MapStrAny `json:"extra"`
+}
+
+type UserListRes struct {
+	List  []UserItem `json:"list"`
+	Total int64      `json:"total"`
+}
+
+func Test_Add(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		oai := gboai.New()
+		err := oai.Add(gboai.AddInput{
+			Path:     "/api/user",
+			Method:   "GET",
+			Request:  reflect.TypeOf(&UserListReq{}),
+			Response: reflect.TypeOf(&UserListRes{}),
+		})
+		t.AssertNil(err)
+
+		operation := oai.Paths["/api/user"]["get"]
+		t.Assert(operation.Tags, []string{"User"})
+		t.Assert(operation.Summary, "List users")
+		t.Assert(operation.Security[0]["bearer"], []string{})
+		t.AssertNil(operation.RequestBody)
+		t.Assert(len(operation.Parameters), 4)
+		t.Assert(operation.Parameters[0].Name, "page")
+		t.Assert(operation.Parameters[0].In, gboai.ParameterQry)
+		t.Assert(operation.Parameters[0].Description, "Page number")
+		t.Assert(*operation.Parameters[0].Schema.Minimum, 1)
+		t.Assert(operation.Parameters[0].Schema.Default, 1)
+		t.Assert(*operation.Parameters[1].Schema.Maximum, 100)
+		t.Assert(operation.Parameters[2].Schema.Enum, []interface{}{"enabled", "disabled"})
+		t.Assert(operation.Parameters[3].In, gboai.ParameterHdr)
+
+		var (
+			resName = gboai.SchemaName(reflect.TypeOf(UserListRes{}))
+			resRef  = operation.Responses["200"].Content[gboai.MimeJson].Schema.Ref
+			res     = oai.Components.Schemas[resName]
+		)
+		t.Assert(resRef, gboai.RefPrefix+resName)
+		t.Assert(res.Properties["list"].Type, "array")
+		t.Assert(res.Properties["list"].Items.Ref, gboai.RefPrefix+gboai.SchemaName(reflect.TypeOf(UserItem{})))
+		t.Assert(res.Properties["total"].Format, "int64")
+
+		item := oai.Components.Schemas[gboai.SchemaName(reflect.TypeOf(UserItem{}))]
+		t.Assert(item.Properties["parent"].Ref, gboai.RefPrefix+gboai.SchemaName(reflect.TypeOf(UserItem{})))
+		t.Assert(item.Properties["extra"].Type, "object")
+	})
+}
+
+func Test_Add_RequestBody(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		oai := gboai.New()
+		err := oai.Add(gboai.AddInput{
+			Path:    "/user/:id",
+			Method:  "POST",
+			Request: reflect.TypeOf(UserCreateReq{}),
+		})
+		t.AssertNil(err)
+
+		operation := oai.Paths["/user/{id}"]["post"]
+		t.Assert(operation.Description, "Create user")
+		t.Assert(len(operation.Parameters), 1)
+		t.Assert(operation.Parameters[0].Name, "id")
+		t.Assert(operation.Parameters[0].In, gboai.ParameterPath)
+		t.Assert(operation.Parameters[0].Required, true)
+		t.Assert(operation.RequestBody.Required, true)
+		t.AssertNil(operation.Responses["200"].Content)
+
+		body := oai.Components.Schemas[gboai.SchemaName(reflect.TypeOf(UserCreateReq{}))]
+		t.Assert(body.Required, []string{"name"})
+		t.Assert(len(body.Properties), 3)
+		t.Assert(*body.Properties["name"].MinLength, 2)
+		t.Assert(*body.Properties["name"].MaxLength, 20)
+		t.Assert(body.Properties["name"].Example, "john")
+		t.Assert(body.Properties["email"].Format, "email")
+		t.Assert(*body.Properties["roles"].MaxItems, 3)
+	})
+}
+
+func Test_Add_InvalidRequest(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		oai := gboai.New()
+		err := oai.Add(gboai.AddInput{
+			Path:    "/user",
+			Method:  "GET",
+			Request: reflect.TypeOf(1),
+		})
+		t.AssertNE(err, nil)
+	})
+}
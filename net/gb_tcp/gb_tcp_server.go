@@ -6,6 +6,7 @@ import (
 	gbmap "ghostbb.io/gb/container/gb_map"
 	gbcode "ghostbb.io/gb/errors/gb_code"
 	gberror "ghostbb.io/gb/errors/gb_error"
+	gbtls "ghostbb.io/gb/net/gb_tls"
 	gbstr "ghostbb.io/gb/text/gb_str"
 	gbconv "ghostbb.io/gb/util/gb_conv"
 	"net"
@@ -23,11 +24,12 @@ const (
 
 // Server is a TCP server.
 type Server struct {
-	mu        sync.Mutex   // Used for Server.listen concurrent safety. -- The golang test with data race checks this.
-	listen    net.Listener // TCP address listener.
-	address   string       // Server listening address.
-	handler   func(*Conn)  // Connection handler.
-	tlsConfig *tls.Config  // TLS configuration.
+	mu        sync.Mutex         // Used for Server.listen concurrent safety. -- The golang test with data race checks this.
+	listen    net.Listener       // TCP address listener.
+	address   string             // Server listening address.
+	handler   func(*Conn)        // Connection handler.
+	tlsConfig *tls.Config        // TLS configuration.
+	certs     *gbtls.CertManager // Certificate manager for hot reloading certificates, which is optional.
 }
 
 // Map for name to server, for singleton purpose.
@@ -93,24 +95,49 @@ func (s *Server) SetHandler(handler func(*Conn)) {
 }
 
 // SetTLSKeyCrt sets the certificate and key file for TLS configuration of server.
+// The certificate files are watched, and the certificate is reloaded for new connections once changed.
 func (s *Server) SetTLSKeyCrt(crtFile, keyFile string) error {
-	tlsConfig, err := LoadKeyCrt(crtFile, keyFile)
-	if err != nil {
+	certs := gbtls.NewCertManager()
+	if err := certs.Add(crtFile, keyFile); err != nil {
+		certs.Close()
 		return err
 	}
-	s.tlsConfig = tlsConfig
+	s.SetTLSCertManager(certs)
 	return nil
 }
 
+// SetTLSCertManager sets the certificate manager for TLS configuration of server,
+// which supports multiple certificates chosen by SNI, hot reloading and client certificate verification.
+// The certificate manager is closed along with the server.
+func (s *Server) SetTLSCertManager(certs *gbtls.CertManager) {
+	s.setCertManager(certs)
+	s.tlsConfig = certs.TLSConfig()
+}
+
 // SetTLSConfig sets the TLS configuration of server.
 func (s *Server) SetTLSConfig(tlsConfig *tls.Config) {
+	s.setCertManager(nil)
 	s.tlsConfig = tlsConfig
 }
 
+// setCertManager replaces the certificate manager of server, and closes the previous one.
+func (s *Server) setCertManager(certs *gbtls.CertManager) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.certs != nil && s.certs != certs {
+		s.certs.Close()
+	}
+	s.certs = certs
+}
+
 // Close closes the listener and shutdowns the server.
 func (s *Server) Close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.certs != nil {
+		s.certs.Close()
+		s.certs = nil
+	}
 	if s.listen == nil {
 		return nil
 	}
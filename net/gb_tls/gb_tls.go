@@ -0,0 +1,96 @@
+// Package gbtls provides the certificate manager for TLS servers,
+// which supports multiple certificates chosen by SNI, hot reloading and client certificate verification.
+package gbtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	gbfile "ghostbb.io/gb/os/gb_file"
+	gbres "ghostbb.io/gb/os/gb_res"
+)
+
+// KeyPair is the certificate and key files of a certificate.
+type KeyPair struct {
+	CertFile string `json:"certFile"` // CertFile is the PEM encoded certificate chain file.
+	KeyFile  string `json:"keyFile"`  // KeyFile is the PEM encoded private key file.
+}
+
+// ClientAuth types for client certificate verification.
+const (
+	ClientAuthRequire  = "require"  // ClientAuthRequire requires and verifies client certificate.
+	ClientAuthOptional = "optional" // ClientAuthOptional verifies client certificate if it is given.
+)
+
+// LoadClientCAs loads the PEM encoded CA bundle `caFile` as certificate pool
+// for verifying client certificates.
+func LoadClientCAs(caFile string) (*x509.CertPool, error) {
+	content, _, err := readFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(content) {
+		return nil, gberror.NewCodef(gbcode.CodeInvalidParameter, `no valid certificate found in CA file "%s"`, caFile)
+	}
+	return pool, nil
+}
+
+// clientAuthType converts the ClientAuth type to tls.ClientAuthType.
+func clientAuthType(clientAuth string) (tls.ClientAuthType, error) {
+	switch clientAuth {
+	case "", ClientAuthRequire:
+		return tls.RequireAndVerifyClientCert, nil
+	case ClientAuthOptional:
+		return tls.VerifyClientCertIfGiven, nil
+	default:
+		return tls.NoClientCert, gberror.NewCodef(gbcode.CodeInvalidParameter, `invalid client auth type "%s"`, clientAuth)
+	}
+}
+
+// loadKeyPair loads and parses the certificate of `pair`.
+// It returns the real paths of files on disk, which are empty if files are from gbres.
+func loadKeyPair(pair KeyPair) (*tls.Certificate, []string, error) {
+	certContent, certPath, err := readFile(pair.CertFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyContent, keyPath, err := readFile(pair.KeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := tls.X509KeyPair(certContent, keyContent)
+	if err != nil {
+		return nil, nil, gberror.Wrapf(err,
+			`load certificate failed for certFile "%s" and keyFile "%s"`,
+			pair.CertFile, pair.KeyFile,
+		)
+	}
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return nil, nil, gberror.Wrapf(err, `parse certificate failed for certFile "%s"`, pair.CertFile)
+	}
+	var paths []string
+	for _, path := range []string{certPath, keyPath} {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return &cert, paths, nil
+}
+
+// readFile reads the content of `file`, which is searched on disk first and then in gbres.
+// It returns the real path of the file on disk, which is empty if it is from gbres.
+func readFile(file string) ([]byte, string, error) {
+	if realPath, _ := gbfile.Search(file); realPath != "" {
+		content := gbfile.GetBytes(realPath)
+		if content == nil {
+			return nil, "", gberror.NewCodef(gbcode.CodeInternalError, `read file "%s" failed`, realPath)
+		}
+		return content, realPath, nil
+	}
+	if gbres.Contains(file) {
+		return gbres.GetContent(file), "", nil
+	}
+	return nil, "", gberror.NewCodef(gbcode.CodeInvalidParameter, `file "%s" does not exist`, file)
+}
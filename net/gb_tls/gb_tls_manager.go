@@ -0,0 +1,303 @@
+package gbtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	"ghostbb.io/gb/internal/intlog"
+	gbfile "ghostbb.io/gb/os/gb_file"
+	gbfsnotify "ghostbb.io/gb/os/gb_fsnotify"
+	gblog "ghostbb.io/gb/os/gb_log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// reloadDelay is the delay of reloading after file changes, which merges the events
+	// of writing the certificate and key files during rotation.
+	reloadDelay = 200 * time.Millisecond
+)
+
+// CertManager manages the certificates of TLS server.
+//
+// The certificate is chosen by SNI of client, which falls back to the first added certificate.
+// The certificate and CA files on disk are watched, and they are reloaded and swapped atomically
+// once changed, so the certificates can be rotated without restarting server.
+type CertManager struct {
+	mu          sync.RWMutex
+	pairs       []*certEntry                // Added certificates in order, the first one is the default certificate.
+	names       map[string]*tls.Certificate // Lower case DNS names or wildcard names to certificates.
+	clientCA    string                      // CA bundle file for verifying client certificates, which enables mTLS.
+	clientAuth  tls.ClientAuthType          // Client authentication policy if clientCA is set.
+	clientCAs   *x509.CertPool              // Loaded client CA pool.
+	callbacks   map[string]*gbfsnotify.Callback
+	reloadTimer *time.Timer
+	logger      *gblog.Logger
+	closed      bool
+}
+
+// certEntry is an added certificate.
+type certEntry struct {
+	pair KeyPair
+	cert *tls.Certificate
+}
+
+// NewCertManager creates and returns a new certificate manager.
+func NewCertManager() *CertManager {
+	return &CertManager{
+		names:     make(map[string]*tls.Certificate),
+		callbacks: make(map[string]*gbfsnotify.Callback),
+	}
+}
+
+// SetLogger sets the logger for reloading information and errors, which uses internal logging if not set.
+func (m *CertManager) SetLogger(logger *gblog.Logger) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.logger = logger
+}
+
+// Add loads the certificate of `certFile` and `keyFile`, and watches the files for reloading.
+// The files are searched on disk first and then in gbres, and the files in gbres are not reloaded.
+func (m *CertManager) Add(certFile, keyFile string) error {
+	pair := KeyPair{CertFile: certFile, KeyFile: keyFile}
+	cert, paths, err := loadKeyPair(pair)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pairs = append(m.pairs, &certEntry{pair: pair, cert: cert})
+	m.updateNames()
+	return m.watch(paths...)
+}
+
+// SetClientCA enables client certificate verification (mTLS) using the CA bundle `caFile`,
+// and watches the file for reloading.
+// The optional parameter `clientAuth` is ClientAuthRequire in default, or ClientAuthOptional.
+func (m *CertManager) SetClientCA(caFile string, clientAuth ...string) error {
+	var authType string
+	if len(clientAuth) > 0 {
+		authType = clientAuth[0]
+	}
+	auth, err := clientAuthType(authType)
+	if err != nil {
+		return err
+	}
+	pool, err := LoadClientCAs(caFile)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clientCA = caFile
+	m.clientAuth = auth
+	m.clientCAs = pool
+	if realPath, _ := gbfile.Search(caFile); realPath != "" {
+		return m.watch(realPath)
+	}
+	return nil
+}
+
+// GetCertificate returns the certificate for the SNI of client,
+// which implements tls.Config.GetCertificate.
+func (m *CertManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if len(m.pairs) == 0 {
+		return nil, gberror.NewCode(gbcode.CodeMissingConfiguration, `no certificate is configured`)
+	}
+	if name := strings.ToLower(strings.TrimSuffix(hello.ServerName, ".")); name != "" {
+		if cert, ok := m.names[name]; ok {
+			return cert, nil
+		}
+		// Wildcard certificate only matches a single label.
+		if index := strings.Index(name, "."); index > 0 {
+			if cert, ok := m.names["*"+name[index:]]; ok {
+				return cert, nil
+			}
+		}
+	}
+	return m.pairs[0].cert, nil
+}
+
+// ClientCAs returns the current CA pool for verifying client certificates,
+// or nil if client certificate verification is not enabled.
+func (m *CertManager) ClientCAs() *x509.CertPool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.clientCAs
+}
+
+// TLSConfig returns a TLS configuration using the certificates of current manager.
+// The optional parameter `base` specifies the base configuration, which is cloned and not modified.
+//
+// The client CA pool is retrieved for each handshake if client certificate verification is enabled,
+// so that the reloaded CA bundle takes effect for new connections.
+func (m *CertManager) TLSConfig(base ...*tls.Config) *tls.Config {
+	var config *tls.Config
+	if len(base) > 0 && base[0] != nil {
+		config = base[0].Clone()
+	} else {
+		config = &tls.Config{}
+	}
+	if config.MinVersion == 0 {
+		config.MinVersion = tls.VersionTLS12
+	}
+	config.GetCertificate = m.GetCertificate
+	m.mu.RLock()
+	clientCAs, clientAuth := m.clientCAs, m.clientAuth
+	m.mu.RUnlock()
+	if clientCAs == nil {
+		return config
+	}
+	config.ClientAuth = clientAuth
+	config.ClientCAs = clientCAs
+	if config.GetConfigForClient == nil {
+		config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			c := config.Clone()
+			c.GetConfigForClient = nil
+			c.ClientCAs = m.ClientCAs()
+			return c, nil
+		}
+	}
+	return config
+}
+
+// Reload reloads all certificates and the client CA bundle.
+// The certificate that fails reloading keeps using the previous one, and the error is returned.
+func (m *CertManager) Reload() error {
+	m.mu.RLock()
+	var (
+		pairs    = make([]KeyPair, len(m.pairs))
+		clientCA = m.clientCA
+	)
+	for i, entry := range m.pairs {
+		pairs[i] = entry.pair
+	}
+	m.mu.RUnlock()
+
+	var (
+		errs   []error
+		certs  = make([]*tls.Certificate, len(pairs))
+		caPool *x509.CertPool
+		err    error
+	)
+	for i, pair := range pairs {
+		if certs[i], _, err = loadKeyPair(pair); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if clientCA != "" {
+		if caPool, err = LoadClientCAs(clientCA); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	m.mu.Lock()
+	for i, cert := range certs {
+		if cert != nil && i < len(m.pairs) {
+			m.pairs[i].cert = cert
+		}
+	}
+	if caPool != nil {
+		m.clientCAs = caPool
+	}
+	m.updateNames()
+	m.mu.Unlock()
+
+	if len(errs) > 0 {
+		err = errs[0]
+		for _, e := range errs[1:] {
+			err = gberror.Wrap(err, e.Error())
+		}
+		return err
+	}
+	return nil
+}
+
+// Close stops watching the files.
+func (m *CertManager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	if m.reloadTimer != nil {
+		m.reloadTimer.Stop()
+	}
+	for dir, callback := range m.callbacks {
+		_ = gbfsnotify.RemoveCallback(callback.Id)
+		delete(m.callbacks, dir)
+	}
+}
+
+// updateNames rebuilds the name mapping of certificates, which should be called with lock.
+// The name of former added certificate takes precedence.
+func (m *CertManager) updateNames() {
+	names := make(map[string]*tls.Certificate)
+	for _, entry := range m.pairs {
+		leaf := entry.cert.Leaf
+		dnsNames := leaf.DNSNames
+		if len(dnsNames) == 0 && leaf.Subject.CommonName != "" {
+			dnsNames = []string{leaf.Subject.CommonName}
+		}
+		for _, name := range dnsNames {
+			name = strings.ToLower(name)
+			if _, ok := names[name]; !ok {
+				names[name] = entry.cert
+			}
+		}
+	}
+	m.names = names
+}
+
+// watch watches the directories of `paths`, which should be called with lock.
+// The directory is watched instead of the file, as the file is usually replaced
+// by renaming during rotation, like the mounted secrets of Kubernetes.
+func (m *CertManager) watch(paths ...string) error {
+	for _, path := range paths {
+		dir := gbfile.Dir(path)
+		if _, ok := m.callbacks[dir]; ok {
+			continue
+		}
+		callback, err := gbfsnotify.Add(dir, func(event *gbfsnotify.Event) {
+			m.scheduleReload()
+		}, false)
+		if err != nil {
+			return err
+		}
+		m.callbacks[dir] = callback
+	}
+	return nil
+}
+
+// scheduleReload reloads the certificates after reloadDelay, which merges the continuous file events.
+func (m *CertManager) scheduleReload() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return
+	}
+	if m.reloadTimer != nil {
+		m.reloadTimer.Stop()
+	}
+	m.reloadTimer = time.AfterFunc(reloadDelay, func() {
+		var (
+			ctx = context.TODO()
+			err = m.Reload()
+		)
+		m.mu.RLock()
+		logger := m.logger
+		m.mu.RUnlock()
+		switch {
+		case logger != nil && err != nil:
+			logger.Errorf(ctx, `reload certificates failed, keep using the previous ones: %+v`, err)
+		case logger != nil:
+			logger.Info(ctx, `certificates reloaded`)
+		case err != nil:
+			intlog.Errorf(ctx, `reload certificates failed: %+v`, err)
+		}
+	})
+}
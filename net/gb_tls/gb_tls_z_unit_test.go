@@ -0,0 +1,184 @@
+package gbtls_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	gbtls "ghostbb.io/gb/net/gb_tls"
+	gbfile "ghostbb.io/gb/os/gb_file"
+	gbtest "ghostbb.io/gb/test/gb_test"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+var serial int64
+
+// issue creates a certificate for `names` signed by `ca`, or a self-signed CA certificate if `ca` is nil.
+func issue(t *gbtest.T, ca *tls.Certificate, names ...string) *tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	t.AssertNil(err)
+	serial++
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "test"},
+		DNSNames:     names,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	var (
+		parent                = template
+		parentKey interface{} = key
+	)
+	if ca == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		parent, parentKey = ca.Leaf, ca.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	t.AssertNil(err)
+	leaf, err := x509.ParseCertificate(der)
+	t.AssertNil(err)
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+// save writes `cert` to `certFile` and `keyFile` in PEM format.
+func save(t *gbtest.T, cert *tls.Certificate, certFile, keyFile string) {
+	keyDer, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+	t.AssertNil(err)
+	t.AssertNil(gbfile.PutBytes(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]})))
+	if keyFile != "" {
+		t.AssertNil(gbfile.PutBytes(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})))
+	}
+}
+
+func serialOf(t *gbtest.T, m *gbtls.CertManager, serverName string) int64 {
+	cert, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: serverName})
+	t.AssertNil(err)
+	return cert.Leaf.SerialNumber.Int64()
+}
+
+func TestCertManager_SNI(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		var (
+			dir = gbfile.Temp("gbtls-sni", time.Now().Format("150405.000000"))
+			a   = issue(t, nil, "a.example.com")
+			b   = issue(t, nil, "*.example.org", "example.org")
+			m   = gbtls.NewCertManager()
+		)
+		defer gbfile.Remove(dir)
+		defer m.Close()
+		save(t, a, dir+"/a.crt", dir+"/a.key")
+		save(t, b, dir+"/b.crt", dir+"/b.key")
+
+		_, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: "a.example.com"})
+		t.AssertNE(err, nil)
+
+		t.AssertNil(m.Add(dir+"/a.crt", dir+"/a.key"))
+		t.AssertNil(m.Add(dir+"/b.crt", dir+"/b.key"))
+		t.AssertNE(m.Add(dir+"/none.crt", dir+"/none.key"), nil)
+
+		t.Assert(serialOf(t, m, "a.example.com"), a.Leaf.SerialNumber.Int64())
+		t.Assert(serialOf(t, m, "A.Example.Com."), a.Leaf.SerialNumber.Int64())
+		t.Assert(serialOf(t, m, "www.example.org"), b.Leaf.SerialNumber.Int64())
+		t.Assert(serialOf(t, m, "example.org"), b.Leaf.SerialNumber.Int64())
+		// Wildcard only matches a single label, and the first certificate is the default.
+		t.Assert(serialOf(t, m, "a.b.example.org"), a.Leaf.SerialNumber.Int64())
+		t.Assert(serialOf(t, m, ""), a.Leaf.SerialNumber.Int64())
+	})
+}
+
+func TestCertManager_Reload(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		var (
+			dir   = gbfile.Temp("gbtls-reload", time.Now().Format("150405.000000"))
+			old   = issue(t, nil, "a.example.com")
+			newer = issue(t, nil, "a.example.com")
+			m     = gbtls.NewCertManager()
+		)
+		defer gbfile.Remove(dir)
+		defer m.Close()
+		save(t, old, dir+"/a.crt", dir+"/a.key")
+		t.AssertNil(m.Add(dir+"/a.crt", dir+"/a.key"))
+		t.Assert(serialOf(t, m, "a.example.com"), old.Leaf.SerialNumber.Int64())
+
+		// The previous certificate is kept if the new one is invalid.
+		t.AssertNil(gbfile.PutContents(dir+"/a.crt", "invalid"))
+		t.AssertNE(m.Reload(), nil)
+		t.Assert(serialOf(t, m, "a.example.com"), old.Leaf.SerialNumber.Int64())
+
+		// The certificate is reloaded by watching the files.
+		save(t, newer, dir+"/a.crt", dir+"/a.key")
+		for i := 0; i < 100 && serialOf(t, m, "a.example.com") != newer.Leaf.SerialNumber.Int64(); i++ {
+			time.Sleep(50 * time.Millisecond)
+		}
+		t.Assert(serialOf(t, m, "a.example.com"), newer.Leaf.SerialNumber.Int64())
+	})
+}
+
+func TestCertManager_ClientCA(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		var (
+			dir    = gbfile.Temp("gbtls-mtls", time.Now().Format("150405.000000"))
+			ca     = issue(t, nil)
+			server = issue(t, ca, "localhost")
+			client = issue(t, ca, "client")
+			other  = issue(t, nil, "client")
+			m      = gbtls.NewCertManager()
+		)
+		defer gbfile.Remove(dir)
+		defer m.Close()
+		save(t, ca, dir+"/ca.crt", "")
+		save(t, server, dir+"/server.crt", dir+"/server.key")
+		t.AssertNil(m.Add(dir+"/server.crt", dir+"/server.key"))
+		t.AssertNE(m.SetClientCA(dir+"/ca.crt", "invalid"), nil)
+		t.AssertNil(m.SetClientCA(dir+"/ca.crt", gbtls.ClientAuthRequire))
+
+		ln, err := tls.Listen("tcp", "127.0.0.1:0", m.TLSConfig())
+		t.AssertNil(err)
+		defer ln.Close()
+		go func() {
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				go func() {
+					defer conn.Close()
+					if conn.(*tls.Conn).Handshake() == nil {
+						_, _ = conn.Write([]byte("ok"))
+					}
+				}()
+			}
+		}()
+
+		roots := x509.NewCertPool()
+		roots.AddCert(ca.Leaf)
+		dial := func(cert *tls.Certificate) error {
+			config := &tls.Config{RootCAs: roots, ServerName: "localhost"}
+			if cert != nil {
+				config.Certificates = []tls.Certificate{*cert}
+			}
+			conn, err := tls.DialWithDialer(&net.Dialer{Timeout: time.Second}, "tcp", ln.Addr().String(), config)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+			_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+			_, err = io.ReadAll(conn)
+			return err
+		}
+		t.AssertNil(dial(client))
+		t.AssertNE(dial(nil), nil)
+		t.AssertNE(dial(other), nil)
+	})
+}
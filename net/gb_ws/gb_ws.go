@@ -0,0 +1,74 @@
+// Package gbws provides the WebSocket hub for server side,
+// which manages connections, rooms and broadcasting across instances.
+package gbws
+
+import (
+	gbredis "ghostbb.io/gb/database/gb_redis"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"time"
+)
+
+// Message types, which are the same as the WebSocket frame types.
+const (
+	TextMessage   = websocket.TextMessage
+	BinaryMessage = websocket.BinaryMessage
+)
+
+const (
+	defaultSendQueueSize  = 256
+	defaultPingInterval   = 30 * time.Second
+	defaultPongTimeout    = 60 * time.Second
+	defaultWriteTimeout   = 10 * time.Second
+	defaultMaxMessageSize = 64 * 1024
+	defaultChannel        = "gb:ws:default"
+)
+
+// Config is the configuration for Hub.
+type Config struct {
+	ReadBufferSize  int                        // ReadBufferSize specifies the I/O read buffer size in bytes.
+	WriteBufferSize int                        // WriteBufferSize specifies the I/O write buffer size in bytes.
+	CheckOrigin     func(r *http.Request) bool // CheckOrigin checks the request origin, which rejects cross-origin requests if nil.
+	SendQueueSize   int                        // SendQueueSize specifies the size of send queue of each connection.
+	PingInterval    time.Duration              // PingInterval specifies the interval of ping messages sent to peer.
+	PongTimeout     time.Duration              // PongTimeout specifies the max duration waiting for any message or pong from peer.
+	WriteTimeout    time.Duration              // WriteTimeout specifies the timeout of writing a message to peer.
+	MaxMessageSize  int64                      // MaxMessageSize specifies the max size in bytes of message read from peer.
+	Redis           *gbredis.Redis             // Redis enables broadcasting across instances using redis pub/sub if it is given.
+	Channel         string                     // Channel specifies the redis pub/sub channel for broadcasting across instances.
+}
+
+// Message is the message for broadcasting.
+type Message struct {
+	Type    int    `json:"type"`    // Type is TextMessage or BinaryMessage, which is TextMessage if it is zero.
+	Room    string `json:"room"`    // Room specifies the room that the message is sent to, which is sent to all connections if empty.
+	Data    []byte `json:"data"`    // Data is the content of the message.
+	Exclude string `json:"exclude"` // Exclude specifies the connection id that is excluded from broadcasting, usually the sender.
+}
+
+// checkConfig fills the default values of `config`.
+func checkConfig(config Config) Config {
+	if config.SendQueueSize <= 0 {
+		config.SendQueueSize = defaultSendQueueSize
+	}
+	if config.PingInterval <= 0 {
+		config.PingInterval = defaultPingInterval
+	}
+	if config.PongTimeout <= 0 {
+		config.PongTimeout = defaultPongTimeout
+	}
+	// The ping should be sent before the peer is treated as timeout.
+	if config.PingInterval >= config.PongTimeout {
+		config.PingInterval = config.PongTimeout * 9 / 10
+	}
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = defaultWriteTimeout
+	}
+	if config.MaxMessageSize <= 0 {
+		config.MaxMessageSize = defaultMaxMessageSize
+	}
+	if config.Channel == "" {
+		config.Channel = defaultChannel
+	}
+	return config
+}
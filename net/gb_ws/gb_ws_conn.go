@@ -0,0 +1,264 @@
+package gbws
+
+import (
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	gbuid "ghostbb.io/gb/util/gb_uid"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Conn is a WebSocket connection registered in Hub.
+type Conn struct {
+	id         string
+	hub        *Hub
+	ws         *websocket.Conn
+	request    *http.Request
+	queue      chan *websocket.PreparedMessage // Send queue, which is never closed to avoid sending on closed channel.
+	rooms      map[string]struct{}             // Joined rooms, which is guarded by the lock of hub.
+	values     sync.Map                        // Custom values bound to the connection.
+	closeOnce  sync.Once
+	closeCode  int
+	closeText  string
+	done       chan struct{} // Closed when the connection is closing.
+	writerDone chan struct{} // Closed when the writing goroutine exits.
+}
+
+// newConn creates and returns a new connection of `hub`.
+func newConn(hub *Hub, ws *websocket.Conn, r *http.Request) *Conn {
+	return &Conn{
+		id:         gbuid.S(),
+		hub:        hub,
+		ws:         ws,
+		request:    r,
+		queue:      make(chan *websocket.PreparedMessage, hub.config.SendQueueSize),
+		rooms:      make(map[string]struct{}),
+		done:       make(chan struct{}),
+		writerDone: make(chan struct{}),
+	}
+}
+
+// Id returns the unique id of the connection.
+func (c *Conn) Id() string {
+	return c.id
+}
+
+// Request returns the HTTP request which is upgraded to the connection.
+func (c *Conn) Request() *http.Request {
+	return c.request
+}
+
+// Set binds custom value to the connection like the authenticated user id.
+func (c *Conn) Set(key string, value interface{}) {
+	c.values.Store(key, value)
+}
+
+// Get retrieves the custom value bound to the connection.
+func (c *Conn) Get(key string) interface{} {
+	v, _ := c.values.Load(key)
+	return v
+}
+
+// Join adds the connection to `rooms`.
+func (c *Conn) Join(rooms ...string) {
+	h := c.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// The closed connection is removed from hub, which should not be added back.
+	if _, ok := h.conns[c.id]; !ok {
+		return
+	}
+	for _, room := range rooms {
+		c.rooms[room] = struct{}{}
+		if h.rooms[room] == nil {
+			h.rooms[room] = make(map[string]*Conn)
+		}
+		h.rooms[room][c.id] = c
+	}
+}
+
+// Leave removes the connection from `rooms`.
+func (c *Conn) Leave(rooms ...string) {
+	c.hub.mu.Lock()
+	defer c.hub.mu.Unlock()
+	for _, room := range rooms {
+		c.hub.leave(c, room)
+	}
+}
+
+// Rooms returns the rooms that the connection joins.
+func (c *Conn) Rooms() []string {
+	c.hub.mu.RLock()
+	defer c.hub.mu.RUnlock()
+	rooms := make([]string, 0, len(c.rooms))
+	for room := range c.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
+
+// Send sends text message `data` to the connection.
+func (c *Conn) Send(data []byte) error {
+	return c.SendMessage(TextMessage, data)
+}
+
+// SendMessage sends message `data` of `messageType` to the connection.
+//
+// The message is put into the send queue of the connection without blocking.
+// If the queue is full, which means the peer cannot keep up with the messages,
+// the connection is closed and an error is returned.
+func (c *Conn) SendMessage(messageType int, data []byte) error {
+	prepared, err := websocket.NewPreparedMessage(messageType, data)
+	if err != nil {
+		return gberror.Wrap(err, `prepare websocket message failed`)
+	}
+	return c.send(prepared)
+}
+
+// Close closes the connection with normal close message.
+func (c *Conn) Close() error {
+	c.closeWithCode(websocket.CloseNormalClosure, "")
+	return nil
+}
+
+// Done returns a channel that is closed when the connection is closing.
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// send puts `message` into the send queue.
+func (c *Conn) send(message *websocket.PreparedMessage) error {
+	select {
+	case <-c.done:
+		return gberror.NewCode(gbcode.CodeInvalidOperation, `websocket connection is closed`)
+	default:
+	}
+	select {
+	case c.queue <- message:
+		return nil
+	case <-c.done:
+		return gberror.NewCode(gbcode.CodeInvalidOperation, `websocket connection is closed`)
+	default:
+		c.closeWithCode(websocket.CloseTryAgainLater, "send queue is full")
+		return gberror.NewCodef(gbcode.CodeServerBusy, `websocket send queue is full for connection "%s"`, c.id)
+	}
+}
+
+// closeWithCode marks the connection closing with close `code` and `text`,
+// which is sent to peer by the writing goroutine.
+func (c *Conn) closeWithCode(code int, text string) {
+	c.closeOnce.Do(func() {
+		c.closeCode = code
+		c.closeText = text
+		c.hub.remove(c)
+		close(c.done)
+	})
+}
+
+// readPump reads the messages from peer until the connection is closed,
+// and then calls the close callback after the writing goroutine exits.
+func (c *Conn) readPump() {
+	defer c.hub.wg.Done()
+	var (
+		h            = c.hub
+		readDeadline = func() error {
+			return c.ws.SetReadDeadline(time.Now().Add(h.config.PongTimeout))
+		}
+	)
+	c.ws.SetReadLimit(h.config.MaxMessageSize)
+	_ = readDeadline()
+	c.ws.SetPongHandler(func(string) error {
+		return readDeadline()
+	})
+	for {
+		messageType, data, err := c.ws.ReadMessage()
+		if err != nil {
+			// The connection is broken or closed by peer, in which case the close message
+			// is replied by the default close handler.
+			c.closeWithCode(websocket.CloseAbnormalClosure, "")
+			break
+		}
+		_ = readDeadline()
+		if h.onMessage != nil {
+			h.onMessage(c, messageType, data)
+		}
+	}
+	<-c.writerDone
+	if h.onClose != nil {
+		h.onClose(c)
+	}
+}
+
+// writePump writes the queued messages and heartbeat pings to peer,
+// and it flushes the queued messages and sends the close message when the connection is closing.
+func (c *Conn) writePump() {
+	var (
+		h      = c.hub
+		ticker = time.NewTicker(h.config.PingInterval)
+	)
+	defer func() {
+		ticker.Stop()
+		// Closing the underlying connection also stops the reading goroutine.
+		_ = c.ws.Close()
+		close(c.writerDone)
+		h.wg.Done()
+	}()
+	for {
+		select {
+		case message := <-c.queue:
+			if err := c.write(message); err != nil {
+				c.closeWithCode(websocket.CloseAbnormalClosure, "")
+				return
+			}
+
+		case <-ticker.C:
+			if err := c.ws.WriteControl(
+				websocket.PingMessage, nil, time.Now().Add(h.config.WriteTimeout),
+			); err != nil {
+				c.closeWithCode(websocket.CloseAbnormalClosure, "")
+				return
+			}
+
+		case <-c.done:
+			// It is no need writing anything if the connection is broken or closed by peer.
+			if c.closeCode == websocket.CloseAbnormalClosure {
+				return
+			}
+			c.flush()
+			_ = c.ws.WriteControl(
+				websocket.CloseMessage,
+				websocket.FormatCloseMessage(c.closeCode, c.closeText),
+				time.Now().Add(h.config.WriteTimeout),
+			)
+			return
+		}
+	}
+}
+
+// flush writes the remaining queued messages without blocking.
+// All the messages share a single write timeout, so that a slow peer cannot delay the closing.
+func (c *Conn) flush() {
+	if err := c.ws.SetWriteDeadline(time.Now().Add(c.hub.config.WriteTimeout)); err != nil {
+		return
+	}
+	for {
+		select {
+		case message := <-c.queue:
+			if err := c.ws.WritePreparedMessage(message); err != nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// write writes `message` to peer with write timeout.
+func (c *Conn) write(message *websocket.PreparedMessage) error {
+	if err := c.ws.SetWriteDeadline(time.Now().Add(c.hub.config.WriteTimeout)); err != nil {
+		return err
+	}
+	return c.ws.WritePreparedMessage(message)
+}
@@ -0,0 +1,262 @@
+package gbws
+
+import (
+	"context"
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	gbuid "ghostbb.io/gb/util/gb_uid"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Hub manages the WebSocket connections, rooms and broadcasting.
+type Hub struct {
+	config    Config
+	upgrader  websocket.Upgrader
+	node      string                      // Unique id of current hub, which ignores the messages published by itself.
+	mu        sync.RWMutex                // Concurrent safety for connections and rooms.
+	conns     map[string]*Conn            // Connections mapping from connection id.
+	rooms     map[string]map[string]*Conn // Rooms mapping from room name to its connections.
+	closed    bool                        // Marks the hub is closed.
+	wg        sync.WaitGroup              // Waits for all goroutines of the hub.
+	ctx       context.Context             // Context for background goroutines, which is canceled when the hub is closed.
+	cancel    context.CancelFunc
+	onOpen    func(conn *Conn)
+	onMessage func(conn *Conn, messageType int, data []byte)
+	onClose   func(conn *Conn)
+}
+
+// New creates and returns a new WebSocket hub.
+// It starts the redis subscription for broadcasting across instances if `Config.Redis` is given.
+func New(config ...Config) *Hub {
+	var c Config
+	if len(config) > 0 {
+		c = config[0]
+	}
+	c = checkConfig(c)
+	h := &Hub{
+		config: c,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  c.ReadBufferSize,
+			WriteBufferSize: c.WriteBufferSize,
+			CheckOrigin:     c.CheckOrigin,
+		},
+		node:  gbuid.S(),
+		conns: make(map[string]*Conn),
+		rooms: make(map[string]map[string]*Conn),
+	}
+	h.ctx, h.cancel = context.WithCancel(context.Background())
+	if c.Redis != nil {
+		h.wg.Add(1)
+		go h.subscribe()
+	}
+	return h
+}
+
+// OnOpen sets the callback function which is called when a connection is established.
+// Note that it should be called before any connection is upgraded.
+func (h *Hub) OnOpen(f func(conn *Conn)) {
+	h.onOpen = f
+}
+
+// OnMessage sets the callback function which is called when a message is received from a connection.
+// The callback is called in the reading goroutine of the connection,
+// so the messages of a single connection are handled in order.
+// Note that it should be called before any connection is upgraded.
+func (h *Hub) OnMessage(f func(conn *Conn, messageType int, data []byte)) {
+	h.onMessage = f
+}
+
+// OnClose sets the callback function which is called after a connection is closed.
+// Note that it should be called before any connection is upgraded.
+func (h *Hub) OnClose(f func(conn *Conn)) {
+	h.onClose = f
+}
+
+// Upgrade upgrades the HTTP request to WebSocket connection and registers it to the hub.
+// The connection is served in background goroutines, so it returns immediately
+// and the HTTP handler can return after it.
+func (h *Hub) Upgrade(w http.ResponseWriter, r *http.Request, responseHeader ...http.Header) (*Conn, error) {
+	h.mu.RLock()
+	closed := h.closed
+	h.mu.RUnlock()
+	if closed {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return nil, gberror.NewCode(gbcode.CodeInvalidOperation, `websocket hub is closed`)
+	}
+	var header http.Header
+	if len(responseHeader) > 0 {
+		header = responseHeader[0]
+	}
+	ws, err := h.upgrader.Upgrade(w, r, header)
+	if err != nil {
+		return nil, gberror.Wrap(err, `websocket upgrade failed`)
+	}
+	conn := newConn(h, ws, r)
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		_ = ws.WriteControl(
+			websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutdown"),
+			time.Now().Add(h.config.WriteTimeout),
+		)
+		_ = ws.Close()
+		return nil, gberror.NewCode(gbcode.CodeInvalidOperation, `websocket hub is closed`)
+	}
+	h.conns[conn.id] = conn
+	h.wg.Add(2)
+	h.mu.Unlock()
+
+	// The open callback is called before serving, so it is always called before the message callback.
+	if h.onOpen != nil {
+		h.onOpen(conn)
+	}
+	go conn.writePump()
+	go conn.readPump()
+	return conn, nil
+}
+
+// Get returns the connection of `id`, or nil if it does not exist.
+func (h *Hub) Get(id string) *Conn {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.conns[id]
+}
+
+// Conns returns all connections of current hub.
+func (h *Hub) Conns() []*Conn {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	conns := make([]*Conn, 0, len(h.conns))
+	for _, conn := range h.conns {
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+// Size returns the connection count of current hub.
+func (h *Hub) Size() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.conns)
+}
+
+// RoomSize returns the connection count of `room` in current hub.
+func (h *Hub) RoomSize(room string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.rooms[room])
+}
+
+// Broadcast sends text message `data` to all connections, including those of other instances.
+func (h *Hub) Broadcast(ctx context.Context, data []byte) error {
+	return h.BroadcastMessage(ctx, Message{Data: data})
+}
+
+// BroadcastRoom sends text message `data` to all connections in `room`, including those of other instances.
+func (h *Hub) BroadcastRoom(ctx context.Context, room string, data []byte) error {
+	return h.BroadcastMessage(ctx, Message{Room: room, Data: data})
+}
+
+// BroadcastMessage sends `message` to the connections of current instance,
+// and publishes it to other instances if redis is configured.
+//
+// The message is dropped for the connections whose send queue is full,
+// and these connections are closed as slow consumers.
+func (h *Hub) BroadcastMessage(ctx context.Context, message Message) error {
+	if message.Type == 0 {
+		message.Type = TextMessage
+	}
+	if err := h.deliver(message); err != nil {
+		return err
+	}
+	if h.config.Redis != nil {
+		return h.publish(ctx, message)
+	}
+	return nil
+}
+
+// Close closes all connections with "going away" close message and stops the hub.
+// The queued messages of each connection are flushed before closing.
+// It waits until all goroutines of the hub exit or `ctx` is done.
+func (h *Hub) Close(ctx context.Context) error {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil
+	}
+	h.closed = true
+	conns := make([]*Conn, 0, len(h.conns))
+	for _, conn := range h.conns {
+		conns = append(conns, conn)
+	}
+	h.mu.Unlock()
+
+	h.cancel()
+	for _, conn := range conns {
+		conn.closeWithCode(websocket.CloseGoingAway, "server shutdown")
+	}
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return gberror.WrapCode(gbcode.CodeOperationFailed, ctx.Err(), `websocket hub close timeout`)
+	}
+}
+
+// deliver sends `message` to the connections of current instance.
+func (h *Hub) deliver(message Message) error {
+	prepared, err := websocket.NewPreparedMessage(message.Type, message.Data)
+	if err != nil {
+		return gberror.Wrap(err, `prepare websocket message failed`)
+	}
+	h.mu.RLock()
+	var conns map[string]*Conn
+	if message.Room == "" {
+		conns = h.conns
+	} else {
+		conns = h.rooms[message.Room]
+	}
+	targets := make([]*Conn, 0, len(conns))
+	for id, conn := range conns {
+		if id != message.Exclude {
+			targets = append(targets, conn)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, conn := range targets {
+		// The slow consumer is closed by send, so the error is ignored here.
+		_ = conn.send(prepared)
+	}
+	return nil
+}
+
+// remove removes `conn` from the hub and all its rooms.
+func (h *Hub) remove(conn *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.conns, conn.id)
+	for room := range conn.rooms {
+		h.leave(conn, room)
+	}
+}
+
+// leave removes `conn` from `room`, which should be called with lock.
+func (h *Hub) leave(conn *Conn, room string) {
+	delete(conn.rooms, room)
+	if members, ok := h.rooms[room]; ok {
+		delete(members, conn.id)
+		if len(members) == 0 {
+			delete(h.rooms, room)
+		}
+	}
+}
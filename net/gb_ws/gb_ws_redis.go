@@ -0,0 +1,84 @@
+package gbws
+
+import (
+	"context"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	"ghostbb.io/gb/internal/intlog"
+	"ghostbb.io/gb/internal/json"
+	"time"
+)
+
+const (
+	subscribeRetryInterval = time.Second // Interval of retrying the broken redis subscription.
+)
+
+// redisMessage is the message published to redis for broadcasting across instances.
+type redisMessage struct {
+	Node    string  `json:"node"`    // Node is the id of the hub publishing the message.
+	Message Message `json:"message"` // Message is the broadcasting message.
+}
+
+// publish publishes `message` to other instances using redis pub/sub.
+func (h *Hub) publish(ctx context.Context, message Message) error {
+	payload, err := json.Marshal(redisMessage{
+		Node:    h.node,
+		Message: message,
+	})
+	if err != nil {
+		return gberror.Wrap(err, `marshal websocket message failed`)
+	}
+	if _, err = h.config.Redis.Publish(ctx, h.config.Channel, string(payload)); err != nil {
+		return gberror.Wrapf(err, `publish websocket message to channel "%s" failed`, h.config.Channel)
+	}
+	return nil
+}
+
+// subscribe receives the messages from other instances and delivers them to local connections
+// until the hub is closed. The subscription is retried if it is broken.
+func (h *Hub) subscribe() {
+	defer h.wg.Done()
+	for {
+		if err := h.doSubscribe(); err != nil && h.ctx.Err() == nil {
+			intlog.Errorf(h.ctx, `%+v`, err)
+		}
+		select {
+		case <-h.ctx.Done():
+			return
+		case <-time.After(subscribeRetryInterval):
+		}
+	}
+}
+
+// doSubscribe subscribes the channel and handles the messages until the subscription is broken.
+func (h *Hub) doSubscribe() error {
+	conn, _, err := h.config.Redis.Subscribe(h.ctx, h.config.Channel)
+	if err != nil {
+		return gberror.Wrapf(err, `subscribe websocket channel "%s" failed`, h.config.Channel)
+	}
+	// Closing the connection breaks the blocking receiving when the hub is closed.
+	stop := context.AfterFunc(h.ctx, func() {
+		_ = conn.Close(context.Background())
+	})
+	defer func() {
+		if stop() {
+			_ = conn.Close(context.Background())
+		}
+	}()
+	for {
+		msg, err := conn.ReceiveMessage(h.ctx)
+		if err != nil {
+			return gberror.Wrapf(err, `receive websocket message from channel "%s" failed`, h.config.Channel)
+		}
+		var message redisMessage
+		if err = json.Unmarshal([]byte(msg.Payload), &message); err != nil {
+			intlog.Errorf(h.ctx, `unmarshal websocket message failed: %+v`, err)
+			continue
+		}
+		if message.Node == h.node {
+			continue
+		}
+		if err = h.deliver(message.Message); err != nil {
+			intlog.Errorf(h.ctx, `%+v`, err)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package gbws_test
+
+import (
+	"context"
+	gbws "ghostbb.io/gb/net/gb_ws"
+	gbtest "ghostbb.io/gb/test/gb_test"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	ctx = context.Background()
+)
+
+// newServer creates a test server upgrading requests to `hub`,
+// which joins the room specified by query parameter "room".
+func newServer(hub *gbws.Hub) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := hub.Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		if room := r.URL.Query().Get("room"); room != "" {
+			conn.Join(room)
+		}
+	}))
+}
+
+func dial(t *gbtest.T, server *httptest.Server, query string) *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/?" + query
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	t.AssertNil(err)
+	return conn
+}
+
+func read(t *gbtest.T, conn *websocket.Conn) string {
+	t.AssertNil(conn.SetReadDeadline(time.Now().Add(time.Second)))
+	_, data, err := conn.ReadMessage()
+	t.AssertNil(err)
+	return string(data)
+}
+
+// waitFor waits until `condition` is satisfied as the connections are registered asynchronously.
+func waitFor(condition func() bool) {
+	for i := 0; i < 100 && !condition(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func waitSize(hub *gbws.Hub, size int) {
+	waitFor(func() bool { return hub.Size() == size })
+}
+
+func TestHub_Broadcast(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		var (
+			hub    = gbws.New()
+			server = newServer(hub)
+		)
+		defer server.Close()
+		defer hub.Close(ctx)
+
+		c1 := dial(t, server, "room=r1")
+		c2 := dial(t, server, "room=r2")
+		c3 := dial(t, server, "room=r1")
+		defer c1.Close()
+		defer c2.Close()
+		defer c3.Close()
+		waitFor(func() bool { return hub.RoomSize("r1") == 2 && hub.RoomSize("r2") == 1 })
+		t.Assert(hub.Size(), 3)
+		t.Assert(hub.RoomSize("r1"), 2)
+
+		t.AssertNil(hub.Broadcast(ctx, []byte("all")))
+		t.Assert(read(t, c1), "all")
+		t.Assert(read(t, c2), "all")
+		t.Assert(read(t, c3), "all")
+
+		t.AssertNil(hub.BroadcastRoom(ctx, "r1", []byte("r1")))
+		t.AssertNil(hub.BroadcastRoom(ctx, "r2", []byte("r2")))
+		t.Assert(read(t, c1), "r1")
+		t.Assert(read(t, c2), "r2")
+		t.Assert(read(t, c3), "r1")
+
+		// Disconnected connection is removed from hub and rooms.
+		t.AssertNil(c3.Close())
+		waitSize(hub, 2)
+		t.Assert(hub.Size(), 2)
+		t.Assert(hub.RoomSize("r1"), 1)
+	})
+}
+
+func TestHub_Message(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		var (
+			hub    = gbws.New()
+			server = newServer(hub)
+			closed = make(chan string, 1)
+		)
+		defer server.Close()
+		defer hub.Close(ctx)
+		// Echo to others in the same room.
+		hub.OnMessage(func(conn *gbws.Conn, messageType int, data []byte) {
+			_ = hub.BroadcastMessage(ctx, gbws.Message{
+				Type:    messageType,
+				Room:    "chat",
+				Data:    data,
+				Exclude: conn.Id(),
+			})
+		})
+		hub.OnClose(func(conn *gbws.Conn) {
+			closed <- conn.Id()
+		})
+
+		c1 := dial(t, server, "room=chat")
+		c2 := dial(t, server, "room=chat")
+		defer c2.Close()
+		waitFor(func() bool { return hub.RoomSize("chat") == 2 })
+
+		t.AssertNil(c1.WriteMessage(websocket.TextMessage, []byte("hello")))
+		t.Assert(read(t, c2), "hello")
+
+		t.AssertNil(c1.WriteMessage(
+			websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		))
+		select {
+		case id := <-closed:
+			t.AssertNE(id, "")
+		case <-time.After(time.Second):
+			t.Error("close callback is not called")
+		}
+		t.Assert(hub.Size(), 1)
+	})
+}
+
+func TestHub_Close(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		var (
+			hub    = gbws.New()
+			server = newServer(hub)
+		)
+		defer server.Close()
+
+		c1 := dial(t, server, "")
+		defer c1.Close()
+		waitSize(hub, 1)
+
+		// The queued messages are flushed before closing.
+		conn := hub.Conns()[0]
+		t.AssertNil(conn.Send([]byte("bye")))
+		t.AssertNil(hub.Close(ctx))
+		t.Assert(hub.Size(), 0)
+		t.Assert(read(t, c1), "bye")
+		_, _, err := c1.ReadMessage()
+		t.Assert(websocket.IsCloseError(err, websocket.CloseGoingAway), true)
+
+		// New connection is rejected after closed.
+		url := "ws" + strings.TrimPrefix(server.URL, "http")
+		_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+		t.AssertNE(err, nil)
+		t.Assert(resp.StatusCode, http.StatusServiceUnavailable)
+	})
+}
+
+func TestHub_SlowConsumer(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		var (
+			hub    = gbws.New(gbws.Config{SendQueueSize: 1})
+			server = newServer(hub)
+		)
+		defer server.Close()
+		defer hub.Close(ctx)
+
+		c1 := dial(t, server, "")
+		defer c1.Close()
+		waitSize(hub, 1)
+
+		conn := hub.Conns()[0]
+		var err error
+		for i := 0; i < 10000 && err == nil; i++ {
+			err = conn.Send([]byte(strings.Repeat("x", 1024)))
+		}
+		t.AssertNE(err, nil)
+		waitSize(hub, 0)
+		t.Assert(hub.Size(), 0)
+	})
+}
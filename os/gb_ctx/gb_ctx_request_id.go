@@ -0,0 +1,24 @@
+package gbctx
+
+import (
+	"context"
+)
+
+// CtxKeyRequestId is the context key for request id, which is used for correlating logs of one request
+// among services. It is logged by gblog in default.
+const CtxKeyRequestId StrKey = "RequestId"
+
+// WithRequestId creates and returns a context containing request id `id` upon given parent context `ctx`.
+func WithRequestId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, CtxKeyRequestId, id)
+}
+
+// RequestId retrieves and returns the request id from context.
+// It returns empty string if the context does not contain request id.
+func RequestId(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(CtxKeyRequestId).(string)
+	return id
+}
@@ -32,3 +32,12 @@ func Test_SetInitCtx(t *testing.T) {
 		t.Assert(gbctx.GetInitCtx().Value("TEST"), 1)
 	})
 }
+
+func Test_RequestId(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		t.Assert(gbctx.RequestId(context.TODO()), "")
+		ctx := gbctx.WithRequestId(context.TODO(), "abc")
+		t.Assert(gbctx.RequestId(ctx), "abc")
+		t.Assert(ctx.Value(gbctx.CtxKeyRequestId), "abc")
+	})
+}
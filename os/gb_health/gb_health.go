@@ -0,0 +1,210 @@
+// Package gbhealth provides a registry of health checkers for liveness and readiness probes.
+//
+// Components register named checkers with the probes they participate in,
+// and the probes are commonly exposed by HTTP server as "/healthz" and "/readyz".
+package gbhealth
+
+import (
+	"context"
+	gbmap "ghostbb.io/gb/container/gb_map"
+	gbtype "ghostbb.io/gb/container/gb_type"
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Probe is the kind of health probe.
+type Probe int
+
+const (
+	ProbeLiveness  Probe = 1 << iota // ProbeLiveness checks whether the process is alive and should not be restarted.
+	ProbeReadiness                   // ProbeReadiness checks whether the process is ready to accept traffic.
+	ProbeAll       = ProbeLiveness | ProbeReadiness
+)
+
+const (
+	StatusUp   = "up"   // StatusUp marks the check passed.
+	StatusDown = "down" // StatusDown marks the check failed.
+
+	defaultCheckTimeout = 5 * time.Second
+)
+
+// Checker is the interface for health checking.
+type Checker interface {
+	// Check checks the health and returns an error if it is unhealthy.
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc is the function implementing Checker.
+type CheckerFunc func(ctx context.Context) error
+
+// Check implements interface Checker.
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Result is the result of a probe.
+type Result struct {
+	Status string                 `json:"status"` // Status is StatusUp only if all checks passed.
+	Checks map[string]CheckResult `json:"checks"` // Checks is the result of each checker by its name.
+}
+
+// CheckResult is the result of a single checker.
+type CheckResult struct {
+	Status   string `json:"status"`             // Status of the checker.
+	Error    string `json:"error,omitempty"`    // Error message if the checker failed.
+	Duration string `json:"duration,omitempty"` // Duration the checker costs.
+}
+
+// registeredChecker is the checker with the probes it participates in.
+type registeredChecker struct {
+	checker Checker
+	probe   Probe
+}
+
+var (
+	// checkers stores all registered checkers by their name.
+	checkers = gbmap.NewStrAnyMap(true)
+
+	// ready marks whether the process is ready to accept traffic.
+	// It is set to false when the process starts shutting down.
+	ready = gbtype.NewBool(true)
+
+	// checkTimeout is the timeout for each probe.
+	checkTimeout = gbtype.NewInt64(int64(defaultCheckTimeout))
+)
+
+// Register registers `checker` with `name` for given probes.
+// It participates in all probes if `probe` is not given.
+// It overwrites the checker if `name` is already registered.
+func Register(name string, checker Checker, probe ...Probe) {
+	p := ProbeAll
+	if len(probe) > 0 {
+		p = probe[0]
+	}
+	checkers.Set(name, registeredChecker{checker: checker, probe: p})
+}
+
+// RegisterFunc registers checker function `f` with `name` for given probes.
+func RegisterFunc(name string, f func(ctx context.Context) error, probe ...Probe) {
+	Register(name, CheckerFunc(f), probe...)
+}
+
+// Deregister removes the checker of `name`.
+func Deregister(name string) {
+	checkers.Remove(name)
+}
+
+// Names returns the sorted names of all registered checkers.
+func Names() []string {
+	names := checkers.Keys()
+	sort.Strings(names)
+	return names
+}
+
+// SetReady sets whether the process is ready to accept traffic.
+// The readiness probe always fails if it is set to false.
+func SetReady(enabled bool) {
+	ready.Set(enabled)
+}
+
+// IsReady returns whether the process is marked ready to accept traffic.
+func IsReady() bool {
+	return ready.Val()
+}
+
+// SetTimeout sets the timeout for each probe, which is 5 seconds in default.
+func SetTimeout(timeout time.Duration) {
+	checkTimeout.Set(int64(timeout))
+}
+
+// Liveness runs all checkers participating in liveness probe.
+func Liveness(ctx context.Context) Result {
+	return Check(ctx, ProbeLiveness)
+}
+
+// Readiness runs all checkers participating in readiness probe.
+// It fails without running checkers if the process is marked not ready.
+func Readiness(ctx context.Context) Result {
+	if !IsReady() {
+		return Result{
+			Status: StatusDown,
+			Checks: map[string]CheckResult{
+				"process": {
+					Status: StatusDown,
+					Error:  gberror.NewCode(gbcode.CodeInvalidOperation, "process is shutting down").Error(),
+				},
+			},
+		}
+	}
+	return Check(ctx, ProbeReadiness)
+}
+
+// Check runs all checkers participating in `probe` concurrently and returns the result.
+func Check(ctx context.Context, probe Probe) Result {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		result = Result{
+			Status: StatusUp,
+			Checks: make(map[string]CheckResult),
+		}
+		timeoutCtx, cancel = context.WithTimeout(ctx, time.Duration(checkTimeout.Val()))
+	)
+	defer cancel()
+	checkers.RLockFunc(func(m map[string]interface{}) {
+		for name, v := range m {
+			item := v.(registeredChecker)
+			if item.probe&probe == 0 {
+				continue
+			}
+			wg.Add(1)
+			go func(name string, checker Checker) {
+				defer wg.Done()
+				checkResult := doCheck(timeoutCtx, checker)
+				mu.Lock()
+				defer mu.Unlock()
+				result.Checks[name] = checkResult
+				if checkResult.Status != StatusUp {
+					result.Status = StatusDown
+				}
+			}(name, item.checker)
+		}
+	})
+	wg.Wait()
+	return result
+}
+
+// doCheck runs `checker` and returns its result.
+// It returns a failed result if the checker panics or does not return before `ctx` is done.
+func doCheck(ctx context.Context, checker Checker) CheckResult {
+	var (
+		start = time.Now()
+		done  = make(chan error, 1)
+		err   error
+	)
+	go func() {
+		defer func() {
+			if exception := recover(); exception != nil {
+				done <- gberror.NewCodef(gbcode.CodeInternalPanic, "%+v", exception)
+			}
+		}()
+		done <- checker.Check(ctx)
+	}()
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = gberror.WrapCode(gbcode.CodeOperationFailed, ctx.Err(), "health check timeout")
+	}
+	result := CheckResult{
+		Status:   StatusUp,
+		Duration: time.Since(start).String(),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
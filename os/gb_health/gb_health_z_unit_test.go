@@ -0,0 +1,76 @@
+package gbhealth_test
+
+import (
+	"context"
+	"errors"
+	gbhealth "ghostbb.io/gb/os/gb_health"
+	gbtest "ghostbb.io/gb/test/gb_test"
+	"testing"
+	"time"
+)
+
+var (
+	ctx = context.Background()
+)
+
+func TestCheck(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		gbhealth.RegisterFunc("test.live", func(ctx context.Context) error {
+			return nil
+		}, gbhealth.ProbeLiveness)
+		gbhealth.RegisterFunc("test.ready", func(ctx context.Context) error {
+			return errors.New("not connected")
+		}, gbhealth.ProbeReadiness)
+		defer gbhealth.Deregister("test.live")
+		defer gbhealth.Deregister("test.ready")
+
+		t.Assert(gbhealth.Names(), []string{"test.live", "test.ready"})
+
+		liveness := gbhealth.Liveness(ctx)
+		t.Assert(liveness.Status, gbhealth.StatusUp)
+		t.Assert(len(liveness.Checks), 1)
+		t.Assert(liveness.Checks["test.live"].Status, gbhealth.StatusUp)
+
+		readiness := gbhealth.Readiness(ctx)
+		t.Assert(readiness.Status, gbhealth.StatusDown)
+		t.Assert(len(readiness.Checks), 1)
+		t.Assert(readiness.Checks["test.ready"].Status, gbhealth.StatusDown)
+		t.Assert(readiness.Checks["test.ready"].Error, "not connected")
+	})
+}
+
+func TestCheck_PanicAndTimeout(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		gbhealth.SetTimeout(100 * time.Millisecond)
+		defer gbhealth.SetTimeout(5 * time.Second)
+		gbhealth.RegisterFunc("test.panic", func(ctx context.Context) error {
+			panic("boom")
+		})
+		gbhealth.RegisterFunc("test.slow", func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		})
+		defer gbhealth.Deregister("test.panic")
+		defer gbhealth.Deregister("test.slow")
+
+		result := gbhealth.Liveness(ctx)
+		t.Assert(result.Status, gbhealth.StatusDown)
+		t.Assert(result.Checks["test.panic"].Status, gbhealth.StatusDown)
+		t.Assert(result.Checks["test.panic"].Error, "boom")
+		t.Assert(result.Checks["test.slow"].Status, gbhealth.StatusDown)
+	})
+}
+
+func TestSetReady(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		t.Assert(gbhealth.IsReady(), true)
+		t.Assert(gbhealth.Readiness(ctx).Status, gbhealth.StatusUp)
+
+		gbhealth.SetReady(false)
+		defer gbhealth.SetReady(true)
+		t.Assert(gbhealth.IsReady(), false)
+		result := gbhealth.Readiness(ctx)
+		t.Assert(result.Status, gbhealth.StatusDown)
+		t.Assert(result.Checks["process"].Status, gbhealth.StatusDown)
+	})
+}
@@ -0,0 +1,129 @@
+// Package gblimiter provides rate limiting feature using token bucket and sliding window algorithms,
+// with local memory backend and distributed Redis backend.
+package gblimiter
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Algorithm is the rate limiting algorithm.
+type Algorithm string
+
+const (
+	// AlgorithmTokenBucket allows bursts up to Burst requests, and refills Limit tokens every Period.
+	AlgorithmTokenBucket Algorithm = "token-bucket"
+	// AlgorithmSlidingWindow allows Limit requests in any sliding window of Period,
+	// which is estimated using the counts of current and previous fixed windows.
+	AlgorithmSlidingWindow Algorithm = "sliding-window"
+)
+
+const (
+	defaultPrefix = "gb:limiter:" // Default key prefix of limiter.
+)
+
+// Limiter is the interface for rate limiting.
+type Limiter interface {
+	// Allow checks whether the request of `key` is allowed, which consumes one quota if allowed.
+	Allow(ctx context.Context, key string) (*Result, error)
+
+	// GetConfig returns the configuration of the limiter.
+	GetConfig() Config
+}
+
+// Config is the configuration of Limiter.
+type Config struct {
+	Algorithm Algorithm     `json:"algorithm"` // Algorithm of limiter, which is AlgorithmTokenBucket in default.
+	Limit     int           `json:"limit"`     // Limit is the allowed request count in every Period.
+	Period    time.Duration `json:"period"`    // Period of the limit, which is 1 second in default.
+	Burst     int           `json:"burst"`     // Burst is the capacity of token bucket, which is Limit in default.
+	Prefix    string        `json:"prefix"`    // Prefix of the storage key, which is "gb:limiter:" in default.
+}
+
+// Result is the result of Limiter.Allow.
+type Result struct {
+	Allowed    bool          // Allowed marks whether the request is allowed.
+	Limit      int           // Limit is the maximum request count of the quota.
+	Remaining  int           // Remaining is the remaining request count of the quota.
+	ResetAfter time.Duration // ResetAfter is the duration after which the quota is fully reset.
+	RetryAfter time.Duration // RetryAfter is the duration after which the request can be retried, only if it is not allowed.
+}
+
+// checkConfig checks and fills the configuration with default values.
+func checkConfig(config Config) Config {
+	if config.Algorithm == "" {
+		config.Algorithm = AlgorithmTokenBucket
+	}
+	if config.Limit <= 0 {
+		config.Limit = 1
+	}
+	if config.Period <= 0 {
+		config.Period = time.Second
+	}
+	if config.Burst <= 0 {
+		config.Burst = config.Limit
+	}
+	if config.Prefix == "" {
+		config.Prefix = defaultPrefix
+	}
+	return config
+}
+
+// ttl returns the expiration of the storage key, after which the quota is surely fully reset.
+func (c Config) ttl() time.Duration {
+	if c.Algorithm == AlgorithmSlidingWindow {
+		return 2 * c.Period
+	}
+	return time.Duration(float64(c.Burst)/c.rate()) + time.Second
+}
+
+// rate returns the refilled token count of token bucket per nanosecond.
+func (c Config) rate() float64 {
+	return float64(c.Limit) / float64(c.Period)
+}
+
+// tokenBucketResult creates the Result of token bucket from remaining `tokens` after consuming.
+func (c Config) tokenBucketResult(allowed bool, tokens float64) *Result {
+	result := &Result{
+		Allowed:    allowed,
+		Limit:      c.Burst,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: time.Duration((float64(c.Burst) - tokens) / c.rate()),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) / c.rate())
+	}
+	return result
+}
+
+// slidingWindowResult creates the Result of sliding window from the counts of windows,
+// and `elapsed` which is the elapsed duration of current window.
+func (c Config) slidingWindowResult(allowed bool, prev, cur int64, elapsed time.Duration) *Result {
+	var (
+		weight    = float64(c.Period-elapsed) / float64(c.Period)
+		estimated = float64(prev)*weight + float64(cur)
+		result    = &Result{
+			Allowed:    allowed,
+			Limit:      c.Limit,
+			Remaining:  int(math.Max(0, math.Floor(float64(c.Limit)-estimated))),
+			ResetAfter: 2*c.Period - elapsed,
+		}
+	)
+	if cur == 0 {
+		result.ResetAfter = c.Period - elapsed
+	}
+	if !allowed {
+		// The time that the weighted previous count decreases enough for one more request,
+		// or else the start of next window, from which current count becomes the previous one.
+		result.RetryAfter = c.Period - elapsed
+		if prev > 0 && float64(cur) < float64(c.Limit) {
+			need := (float64(c.Limit) - 1 - float64(cur)) / float64(prev)
+			result.RetryAfter = time.Duration((1-need)*float64(c.Period)) - elapsed
+		}
+		if result.RetryAfter < 0 {
+			result.RetryAfter = 0
+		}
+	}
+	return result
+}
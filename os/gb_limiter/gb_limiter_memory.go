@@ -0,0 +1,96 @@
+package gblimiter
+
+import (
+	"context"
+	gbcache "ghostbb.io/gb/os/gb_cache"
+	"math"
+	"sync"
+	"time"
+)
+
+// MemoryLimiter is the Limiter using local memory storage, which limits the requests of current process only.
+type MemoryLimiter struct {
+	mu     sync.Mutex
+	config Config
+	cache  *gbcache.Cache // Storage of limiter states, which expire automatically.
+}
+
+// tokenBucketState is the state of token bucket.
+type tokenBucketState struct {
+	Tokens float64   // Remaining tokens.
+	Time   time.Time // Last refilled time.
+}
+
+// slidingWindowState is the state of sliding window.
+type slidingWindowState struct {
+	Index int64 // Index of current window.
+	Prev  int64 // Request count of previous window.
+	Cur   int64 // Request count of current window.
+}
+
+// NewMemory creates and returns a Limiter using local memory storage based on gbcache memory adapter.
+func NewMemory(config Config) *MemoryLimiter {
+	return &MemoryLimiter{
+		config: checkConfig(config),
+		cache:  gbcache.New(),
+	}
+}
+
+// GetConfig returns the configuration of the limiter.
+func (l *MemoryLimiter) GetConfig() Config {
+	return l.config
+}
+
+// Allow checks whether the request of `key` is allowed, which consumes one quota if allowed.
+func (l *MemoryLimiter) Allow(ctx context.Context, key string) (*Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key = l.config.Prefix + key
+	v, err := l.cache.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		now    = time.Now()
+		result *Result
+		state  interface{}
+	)
+	switch l.config.Algorithm {
+	case AlgorithmSlidingWindow:
+		var (
+			s, _    = v.Val().(*slidingWindowState)
+			index   = now.UnixNano() / int64(l.config.Period)
+			elapsed = time.Duration(now.UnixNano() - index*int64(l.config.Period))
+		)
+		if s == nil || s.Index < index-1 {
+			s = &slidingWindowState{Index: index}
+		} else if s.Index == index-1 {
+			s = &slidingWindowState{Index: index, Prev: s.Cur}
+		}
+		weight := float64(l.config.Period-elapsed) / float64(l.config.Period)
+		allowed := float64(s.Prev)*weight+float64(s.Cur)+1 <= float64(l.config.Limit)
+		if allowed {
+			s.Cur++
+		}
+		result = l.config.slidingWindowResult(allowed, s.Prev, s.Cur, elapsed)
+		state = s
+
+	default:
+		s, _ := v.Val().(*tokenBucketState)
+		if s == nil {
+			s = &tokenBucketState{Tokens: float64(l.config.Burst), Time: now}
+		}
+		s.Tokens = math.Min(float64(l.config.Burst), s.Tokens+float64(now.Sub(s.Time))*l.config.rate())
+		s.Time = now
+		allowed := s.Tokens >= 1
+		if allowed {
+			s.Tokens--
+		}
+		result = l.config.tokenBucketResult(allowed, s.Tokens)
+		state = s
+	}
+	if err = l.cache.Set(ctx, key, state, l.config.ttl()); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,130 @@
+package gblimiter
+
+import (
+	"context"
+	gbredis "ghostbb.io/gb/database/gb_redis"
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	gbconv "ghostbb.io/gb/util/gb_conv"
+	"time"
+)
+
+// RedisLimiter is the Limiter using Redis storage, which limits the requests across processes.
+// The limiting is done atomically using Lua script, with time of the Redis server.
+type RedisLimiter struct {
+	config Config
+	redis  *gbredis.Redis
+}
+
+const (
+	// redisScriptTokenBucket implements token bucket using hash key with fields "tokens" and "time".
+	// ARGV: rate in tokens per millisecond, burst, ttl in milliseconds.
+	// Returns: allowed (1 or 0), remaining tokens as string.
+	redisScriptTokenBucket = `
+local rate  = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local ttl   = tonumber(ARGV[3])
+local t     = redis.call('TIME')
+local now   = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local data  = redis.call('HMGET', KEYS[1], 'tokens', 'time')
+local tokens = tonumber(data[1])
+local last   = tonumber(data[2])
+if tokens == nil or last == nil then
+	tokens = burst
+	last   = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - last) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens  = tokens - 1
+	allowed = 1
+end
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'time', tostring(now))
+redis.call('PEXPIRE', KEYS[1], ttl)
+return {allowed, tostring(tokens)}
+`
+
+	// redisScriptSlidingWindow implements sliding window using hash key with fields "index", "prev" and "cur".
+	// ARGV: period in microseconds, limit, ttl in milliseconds.
+	// Returns: allowed (1 or 0), previous count, current count, elapsed microseconds of current window.
+	redisScriptSlidingWindow = `
+local period = tonumber(ARGV[1])
+local limit  = tonumber(ARGV[2])
+local ttl    = tonumber(ARGV[3])
+local t      = redis.call('TIME')
+local now    = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local index  = math.floor(now / period)
+local elapsed = now - index * period
+local data   = redis.call('HMGET', KEYS[1], 'index', 'prev', 'cur')
+local last   = tonumber(data[1])
+local prev   = 0
+local cur    = 0
+if last == index then
+	prev = tonumber(data[2]) or 0
+	cur  = tonumber(data[3]) or 0
+elseif last == index - 1 then
+	prev = tonumber(data[3]) or 0
+end
+local allowed = 0
+if prev * (period - elapsed) / period + cur + 1 <= limit then
+	cur     = cur + 1
+	allowed = 1
+end
+redis.call('HMSET', KEYS[1], 'index', index, 'prev', prev, 'cur', cur)
+redis.call('PEXPIRE', KEYS[1], ttl)
+return {allowed, prev, cur, elapsed}
+`
+)
+
+// NewRedis creates and returns a Limiter using Redis storage.
+func NewRedis(redis *gbredis.Redis, config Config) *RedisLimiter {
+	return &RedisLimiter{
+		config: checkConfig(config),
+		redis:  redis,
+	}
+}
+
+// GetConfig returns the configuration of the limiter.
+func (l *RedisLimiter) GetConfig() Config {
+	return l.config
+}
+
+// Allow checks whether the request of `key` is allowed, which consumes one quota if allowed.
+func (l *RedisLimiter) Allow(ctx context.Context, key string) (*Result, error) {
+	if l.redis == nil {
+		return nil, gberror.NewCode(gbcode.CodeMissingConfiguration, `redis instance of limiter is not configured`)
+	}
+	var (
+		keys = []string{l.config.Prefix + key}
+		ttl  = l.config.ttl().Milliseconds()
+	)
+	switch l.config.Algorithm {
+	case AlgorithmSlidingWindow:
+		v, err := l.redis.GroupScript().Eval(ctx, redisScriptSlidingWindow, 1, keys, []interface{}{
+			l.config.Period.Microseconds(), l.config.Limit, ttl,
+		})
+		if err != nil {
+			return nil, err
+		}
+		values := v.Int64s()
+		if len(values) != 4 {
+			return nil, gberror.NewCodef(gbcode.CodeInternalError, `invalid limiter script result: %s`, v.String())
+		}
+		return l.config.slidingWindowResult(
+			values[0] == 1, values[1], values[2], time.Duration(values[3])*time.Microsecond,
+		), nil
+
+	default:
+		v, err := l.redis.GroupScript().Eval(ctx, redisScriptTokenBucket, 1, keys, []interface{}{
+			l.config.rate() * float64(time.Millisecond), l.config.Burst, ttl,
+		})
+		if err != nil {
+			return nil, err
+		}
+		values := v.Strings()
+		if len(values) != 2 {
+			return nil, gberror.NewCodef(gbcode.CodeInternalError, `invalid limiter script result: %s`, v.String())
+		}
+		return l.config.tokenBucketResult(values[0] == "1", gbconv.Float64(values[1])), nil
+	}
+}
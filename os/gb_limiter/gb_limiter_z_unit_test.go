@@ -0,0 +1,85 @@
+package gblimiter_test
+
+import (
+	"context"
+	gblimiter "ghostbb.io/gb/os/gb_limiter"
+	gbtest "ghostbb.io/gb/test/gb_test"
+	"testing"
+	"time"
+)
+
+var (
+	ctx = context.Background()
+)
+
+func TestMemory_TokenBucket(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		limiter := gblimiter.NewMemory(gblimiter.Config{
+			Limit:  10,
+			Period: time.Second,
+			Burst:  3,
+		})
+		t.Assert(limiter.GetConfig().Algorithm, gblimiter.AlgorithmTokenBucket)
+		for i := 2; i >= 0; i-- {
+			result, err := limiter.Allow(ctx, "ip")
+			t.AssertNil(err)
+			t.Assert(result.Allowed, true)
+			t.Assert(result.Limit, 3)
+			t.Assert(result.Remaining, i)
+		}
+		result, err := limiter.Allow(ctx, "ip")
+		t.AssertNil(err)
+		t.Assert(result.Allowed, false)
+		t.Assert(result.Remaining, 0)
+		t.Assert(result.RetryAfter > 0, true)
+		t.Assert(result.RetryAfter <= 100*time.Millisecond, true)
+
+		// Other keys are not affected.
+		result, err = limiter.Allow(ctx, "other")
+		t.AssertNil(err)
+		t.Assert(result.Allowed, true)
+
+		// One token is refilled every 100ms.
+		time.Sleep(120 * time.Millisecond)
+		result, err = limiter.Allow(ctx, "ip")
+		t.AssertNil(err)
+		t.Assert(result.Allowed, true)
+	})
+}
+
+func TestMemory_SlidingWindow(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		limiter := gblimiter.NewMemory(gblimiter.Config{
+			Algorithm: gblimiter.AlgorithmSlidingWindow,
+			Limit:     3,
+			Period:    200 * time.Millisecond,
+		})
+		// Wait for the start of a window to avoid the weight of previous window.
+		period := int64(200 * time.Millisecond)
+		time.Sleep(time.Duration(period - time.Now().UnixNano()%period))
+		for i := 2; i >= 0; i-- {
+			result, err := limiter.Allow(ctx, "ip")
+			t.AssertNil(err)
+			t.Assert(result.Allowed, true)
+			t.Assert(result.Limit, 3)
+			t.Assert(result.Remaining, i)
+		}
+		result, err := limiter.Allow(ctx, "ip")
+		t.AssertNil(err)
+		t.Assert(result.Allowed, false)
+		t.Assert(result.RetryAfter > 0, true)
+
+		// The requests of previous window are still counted with weight.
+		time.Sleep(220 * time.Millisecond)
+		result, err = limiter.Allow(ctx, "ip")
+		t.AssertNil(err)
+		t.Assert(result.Allowed, false)
+
+		// All requests are out of the sliding window.
+		time.Sleep(400 * time.Millisecond)
+		result, err = limiter.Allow(ctx, "ip")
+		t.AssertNil(err)
+		t.Assert(result.Allowed, true)
+		t.Assert(result.Remaining, 2)
+	})
+}
@@ -6,6 +6,7 @@ import (
 	gbcode "ghostbb.io/gb/errors/gb_code"
 	gberror "ghostbb.io/gb/errors/gb_error"
 	"ghostbb.io/gb/internal/intlog"
+	gbctx "ghostbb.io/gb/os/gb_ctx"
 	gbfile "ghostbb.io/gb/os/gb_file"
 	gbconv "ghostbb.io/gb/util/gb_conv"
 	gbutil "ghostbb.io/gb/util/gb_util"
@@ -54,7 +55,7 @@ func DefaultConfig() Config {
 		Flags:               F_TIME_STD,
 		TimeFormat:          "",
 		Level:               LEVEL_ALL,
-		CtxKeys:             []interface{}{},
+		CtxKeys:             []interface{}{gbctx.CtxKeyRequestId},
 		StStatus:            1,
 		HeaderPrint:         true,
 		StdoutPrint:         true,
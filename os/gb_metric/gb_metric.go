@@ -0,0 +1,95 @@
+// Package gbmetric provides metrics feature like counters, gauges and histograms using OpenTelemetry,
+// and exposes the collected metrics in Prometheus text format.
+package gbmetric
+
+import (
+	"ghostbb.io/gb"
+	gbmap "ghostbb.io/gb/container/gb_map"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	"sort"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+const (
+	instrumentName = "ghostbb.io/gb/os/gbmetric"
+)
+
+// Option holds the options for metric instrument creation.
+type Option struct {
+	Help    string    // Help is the description of the metric.
+	Unit    string    // Unit is the unit of the metric, like "s", "By".
+	Buckets []float64 // Buckets specifies the explicit bucket boundaries, which is only used for histogram.
+}
+
+// Attributes is the attribute (label in Prometheus) key-value pairs for metric values.
+type Attributes map[string]string
+
+var (
+	// reader is the reader collecting metrics for Prometheus exposition.
+	reader = sdkmetric.NewManualReader()
+
+	// provider is the default meter provider of current process.
+	provider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	// meter is the meter for all instruments created by this package.
+	meter = provider.Meter(instrumentName, metric.WithInstrumentationVersion(gb.VERSION))
+
+	// instruments stores the created instruments by their kind and name,
+	// which makes the creation of instruments idempotent.
+	instruments = gbmap.NewStrAnyMap(true)
+)
+
+// GetMeterProvider returns the meter provider which backs all instruments of this package.
+func GetMeterProvider() *sdkmetric.MeterProvider {
+	return provider
+}
+
+// SetGlobal sets the meter provider of this package as the global meter provider of OpenTelemetry,
+// so that any instrumentation using otel API are also collected and exposed.
+// It is not set in default, which leaves the global meter provider of application untouched.
+func SetGlobal() {
+	otel.SetMeterProvider(provider)
+}
+
+// getOrCreateInstrument retrieves the instrument by `kind` and `name`, or creates it using `f`.
+// It panics if any error occurs in creation, as it is commonly a programming error.
+func getOrCreateInstrument(kind, name string, f func() (interface{}, error)) interface{} {
+	return instruments.GetOrSetFuncLock(kind+":"+name, func() interface{} {
+		v, err := f()
+		if err != nil {
+			panic(gberror.Wrapf(err, `create %s metric "%s" failed`, kind, name))
+		}
+		return v
+	})
+}
+
+// getOption returns the first option of given optional parameter.
+func getOption(option []Option) Option {
+	if len(option) > 0 {
+		return option[0]
+	}
+	return Option{}
+}
+
+// attributeSet converts the optional Attributes to attribute.Set.
+func attributeSet(attributes []Attributes) attribute.Set {
+	if len(attributes) == 0 || len(attributes[0]) == 0 {
+		return *attribute.EmptySet()
+	}
+	var (
+		keys = make([]string, 0, len(attributes[0]))
+		kvs  = make([]attribute.KeyValue, 0, len(attributes[0]))
+	)
+	for k := range attributes[0] {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		kvs = append(kvs, attribute.String(k, attributes[0][k]))
+	}
+	return attribute.NewSet(kvs...)
+}
@@ -0,0 +1,76 @@
+package gbmetric
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Counter is a metric that only increases.
+type Counter struct {
+	counter metric.Float64Counter
+}
+
+// UpDownCounter is a metric that can increase and decrease, like in-flight requests.
+type UpDownCounter struct {
+	counter metric.Float64UpDownCounter
+}
+
+// NewCounter creates and returns a Counter with given `name`.
+// It returns the same Counter if it is already created with the same `name`.
+func NewCounter(name string, option ...Option) *Counter {
+	return getOrCreateInstrument("counter", name, func() (interface{}, error) {
+		opt := getOption(option)
+		counter, err := meter.Float64Counter(
+			name,
+			metric.WithDescription(opt.Help),
+			metric.WithUnit(opt.Unit),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return &Counter{counter: counter}, nil
+	}).(*Counter)
+}
+
+// Inc increases the counter by 1.
+func (c *Counter) Inc(ctx context.Context, attributes ...Attributes) {
+	c.Add(ctx, 1, attributes...)
+}
+
+// Add increases the counter by `increment`, which must be non-negative.
+func (c *Counter) Add(ctx context.Context, increment float64, attributes ...Attributes) {
+	c.counter.Add(ctx, increment, metric.WithAttributeSet(attributeSet(attributes)))
+}
+
+// NewUpDownCounter creates and returns an UpDownCounter with given `name`.
+// It returns the same UpDownCounter if it is already created with the same `name`.
+func NewUpDownCounter(name string, option ...Option) *UpDownCounter {
+	return getOrCreateInstrument("updowncounter", name, func() (interface{}, error) {
+		opt := getOption(option)
+		counter, err := meter.Float64UpDownCounter(
+			name,
+			metric.WithDescription(opt.Help),
+			metric.WithUnit(opt.Unit),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return &UpDownCounter{counter: counter}, nil
+	}).(*UpDownCounter)
+}
+
+// Inc increases the counter by 1.
+func (c *UpDownCounter) Inc(ctx context.Context, attributes ...Attributes) {
+	c.Add(ctx, 1, attributes...)
+}
+
+// Dec decreases the counter by 1.
+func (c *UpDownCounter) Dec(ctx context.Context, attributes ...Attributes) {
+	c.Add(ctx, -1, attributes...)
+}
+
+// Add adds `delta` to the counter, which can be negative.
+func (c *UpDownCounter) Add(ctx context.Context, delta float64, attributes ...Attributes) {
+	c.counter.Add(ctx, delta, metric.WithAttributeSet(attributeSet(attributes)))
+}
@@ -0,0 +1,71 @@
+package gbmetric
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Gauge is a metric that represents a single value which can arbitrarily go up and down.
+type Gauge struct {
+	mu     sync.RWMutex
+	values map[attribute.Distinct]gaugeValue
+}
+
+// gaugeValue is the last value set of a Gauge for certain attributes.
+type gaugeValue struct {
+	set   attribute.Set
+	value float64
+}
+
+// NewGauge creates and returns a Gauge with given `name`.
+// It returns the same Gauge if it is already created with the same `name`.
+func NewGauge(name string, option ...Option) *Gauge {
+	return getOrCreateInstrument("gauge", name, func() (interface{}, error) {
+		var (
+			opt   = getOption(option)
+			gauge = &Gauge{
+				values: make(map[attribute.Distinct]gaugeValue),
+			}
+		)
+		_, err := meter.Float64ObservableGauge(
+			name,
+			metric.WithDescription(opt.Help),
+			metric.WithUnit(opt.Unit),
+			metric.WithFloat64Callback(gauge.observe),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return gauge, nil
+	}).(*Gauge)
+}
+
+// Set sets the gauge to `value`.
+func (g *Gauge) Set(value float64, attributes ...Attributes) {
+	set := attributeSet(attributes)
+	g.mu.Lock()
+	g.values[set.Equivalent()] = gaugeValue{set: set, value: value}
+	g.mu.Unlock()
+}
+
+// Add adds `delta` to the gauge, which can be negative.
+func (g *Gauge) Add(delta float64, attributes ...Attributes) {
+	set := attributeSet(attributes)
+	g.mu.Lock()
+	v := g.values[set.Equivalent()]
+	g.values[set.Equivalent()] = gaugeValue{set: set, value: v.value + delta}
+	g.mu.Unlock()
+}
+
+// observe is the callback reporting all values of the gauge when metrics are collected.
+func (g *Gauge) observe(_ context.Context, observer metric.Float64Observer) error {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	for _, v := range g.values {
+		observer.Observe(v.value, metric.WithAttributeSet(v.set))
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package gbmetric
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Histogram is a metric that samples observations, like request durations or response sizes,
+// and counts them in configurable buckets.
+type Histogram struct {
+	histogram metric.Float64Histogram
+}
+
+// NewHistogram creates and returns a Histogram with given `name`.
+// It returns the same Histogram if it is already created with the same `name`.
+// The buckets are specified by Option.Buckets, or else it uses the default buckets of OpenTelemetry.
+func NewHistogram(name string, option ...Option) *Histogram {
+	return getOrCreateInstrument("histogram", name, func() (interface{}, error) {
+		var (
+			opt     = getOption(option)
+			options = []metric.Float64HistogramOption{
+				metric.WithDescription(opt.Help),
+				metric.WithUnit(opt.Unit),
+			}
+		)
+		if len(opt.Buckets) > 0 {
+			options = append(options, metric.WithExplicitBucketBoundaries(opt.Buckets...))
+		}
+		histogram, err := meter.Float64Histogram(name, options...)
+		if err != nil {
+			return nil, err
+		}
+		return &Histogram{histogram: histogram}, nil
+	}).(*Histogram)
+}
+
+// Observe records `value` to the histogram.
+func (h *Histogram) Observe(ctx context.Context, value float64, attributes ...Attributes) {
+	h.histogram.Record(ctx, value, metric.WithAttributeSet(attributeSet(attributes)))
+}
@@ -0,0 +1,174 @@
+package gbmetric
+
+import (
+	"bytes"
+	"context"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+const (
+	// PrometheusContentType is the content type of Prometheus text exposition format.
+	PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// prometheusFamily is a metric family in Prometheus text format.
+type prometheusFamily struct {
+	name    string
+	help    string
+	kind    string
+	samples bytes.Buffer
+}
+
+// WritePrometheus collects all metrics and writes them to `writer` in Prometheus text format.
+func WritePrometheus(ctx context.Context, writer io.Writer) error {
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		return gberror.Wrap(err, `collect metrics failed`)
+	}
+	var (
+		families = make([]*prometheusFamily, 0)
+		indexes  = make(map[string]*prometheusFamily)
+	)
+	getFamily := func(name, help, kind string) *prometheusFamily {
+		if f, ok := indexes[name]; ok {
+			return f
+		}
+		f := &prometheusFamily{name: name, help: help, kind: kind}
+		indexes[name] = f
+		families = append(families, f)
+		return f
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			name := prometheusName(m.Name)
+			switch data := m.Data.(type) {
+			case metricdata.Sum[float64]:
+				writeSum(getFamily, name, m.Description, data.IsMonotonic, data.DataPoints)
+			case metricdata.Sum[int64]:
+				writeSum(getFamily, name, m.Description, data.IsMonotonic, data.DataPoints)
+			case metricdata.Gauge[float64]:
+				writeGauge(getFamily(name, m.Description, "gauge"), data.DataPoints)
+			case metricdata.Gauge[int64]:
+				writeGauge(getFamily(name, m.Description, "gauge"), data.DataPoints)
+			case metricdata.Histogram[float64]:
+				writeHistogram(getFamily(name, m.Description, "histogram"), data.DataPoints)
+			case metricdata.Histogram[int64]:
+				writeHistogram(getFamily(name, m.Description, "histogram"), data.DataPoints)
+			}
+		}
+	}
+	var buffer bytes.Buffer
+	for _, f := range families {
+		if f.help != "" {
+			buffer.WriteString("# HELP " + f.name + " " + escapeHelp(f.help) + "\n")
+		}
+		buffer.WriteString("# TYPE " + f.name + " " + f.kind + "\n")
+		buffer.Write(f.samples.Bytes())
+	}
+	_, err := writer.Write(buffer.Bytes())
+	return err
+}
+
+func writeSum[N int64 | float64](
+	getFamily func(name, help, kind string) *prometheusFamily,
+	name, help string, isMonotonic bool, points []metricdata.DataPoint[N],
+) {
+	if !isMonotonic {
+		writeGauge(getFamily(name, help, "gauge"), points)
+		return
+	}
+	if !strings.HasSuffix(name, "_total") {
+		name += "_total"
+	}
+	f := getFamily(name, help, "counter")
+	for _, p := range points {
+		writeSample(&f.samples, f.name, p.Attributes, "", "", float64(p.Value))
+	}
+}
+
+func writeGauge[N int64 | float64](f *prometheusFamily, points []metricdata.DataPoint[N]) {
+	for _, p := range points {
+		writeSample(&f.samples, f.name, p.Attributes, "", "", float64(p.Value))
+	}
+}
+
+func writeHistogram[N int64 | float64](f *prometheusFamily, points []metricdata.HistogramDataPoint[N]) {
+	for _, p := range points {
+		var cumulative uint64
+		for i, bound := range p.Bounds {
+			if i < len(p.BucketCounts) {
+				cumulative += p.BucketCounts[i]
+			}
+			writeSample(&f.samples, f.name+"_bucket", p.Attributes, "le", formatFloat(bound), float64(cumulative))
+		}
+		writeSample(&f.samples, f.name+"_bucket", p.Attributes, "le", "+Inf", float64(p.Count))
+		writeSample(&f.samples, f.name+"_sum", p.Attributes, "", "", float64(p.Sum))
+		writeSample(&f.samples, f.name+"_count", p.Attributes, "", "", float64(p.Count))
+	}
+}
+
+// writeSample writes one sample line with its labels.
+// The `extraKey` and `extraValue` specify an additional label like "le" for histogram buckets.
+func writeSample(buffer *bytes.Buffer, name string, set attribute.Set, extraKey, extraValue string, value float64) {
+	buffer.WriteString(name)
+	if set.Len() > 0 || extraKey != "" {
+		buffer.WriteByte('{')
+		for i, kv := range set.ToSlice() {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			buffer.WriteString(prometheusName(string(kv.Key)))
+			buffer.WriteString(`="`)
+			buffer.WriteString(escapeLabelValue(kv.Value.Emit()))
+			buffer.WriteByte('"')
+		}
+		if extraKey != "" {
+			if set.Len() > 0 {
+				buffer.WriteByte(',')
+			}
+			buffer.WriteString(extraKey + `="` + extraValue + `"`)
+		}
+		buffer.WriteByte('}')
+	}
+	buffer.WriteByte(' ')
+	buffer.WriteString(formatFloat(value))
+	buffer.WriteByte('\n')
+}
+
+// prometheusName converts the name to a valid Prometheus metric or label name,
+// which replaces all invalid characters like '.' and '-' with '_'.
+func prometheusName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || r == ':' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
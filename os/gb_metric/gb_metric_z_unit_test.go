@@ -0,0 +1,109 @@
+package gbmetric_test
+
+import (
+	"bytes"
+	"context"
+	gbmetric "ghostbb.io/gb/os/gb_metric"
+	gbtest "ghostbb.io/gb/test/gb_test"
+	"go.opentelemetry.io/otel"
+	"testing"
+)
+
+var (
+	ctx = context.Background()
+)
+
+func TestCounter(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		counter := gbmetric.NewCounter("test_counter", gbmetric.Option{Help: "test counter"})
+		t.Assert(gbmetric.NewCounter("test_counter"), counter)
+		counter.Inc(ctx, gbmetric.Attributes{"path": "/a"})
+		counter.Add(ctx, 2, gbmetric.Attributes{"path": "/a"})
+		counter.Inc(ctx)
+
+		buffer := bytes.NewBuffer(nil)
+		t.AssertNil(gbmetric.WritePrometheus(ctx, buffer))
+		content := buffer.String()
+		t.Assert(lineOf(content, "# HELP test_counter_total"), "# HELP test_counter_total test counter")
+		t.Assert(lineOf(content, "# TYPE test_counter_total"), "# TYPE test_counter_total counter")
+		t.Assert(lineOf(content, `test_counter_total{path="/a"}`), `test_counter_total{path="/a"} 3`)
+		t.Assert(lineOf(content, `test_counter_total `), `test_counter_total 1`)
+	})
+}
+
+func TestUpDownCounter(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		counter := gbmetric.NewUpDownCounter("test_up_down_counter")
+		counter.Inc(ctx)
+		counter.Inc(ctx)
+		counter.Dec(ctx)
+
+		buffer := bytes.NewBuffer(nil)
+		t.AssertNil(gbmetric.WritePrometheus(ctx, buffer))
+		content := buffer.String()
+		t.Assert(lineOf(content, "# TYPE test_up_down_counter"), "# TYPE test_up_down_counter gauge")
+		t.Assert(lineOf(content, "test_up_down_counter "), "test_up_down_counter 1")
+	})
+}
+
+func TestGauge(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		gauge := gbmetric.NewGauge("test_gauge")
+		gauge.Set(10, gbmetric.Attributes{"name": "a"})
+		gauge.Add(-2.5, gbmetric.Attributes{"name": "a"})
+
+		buffer := bytes.NewBuffer(nil)
+		t.AssertNil(gbmetric.WritePrometheus(ctx, buffer))
+		content := buffer.String()
+		t.Assert(lineOf(content, "# TYPE test_gauge"), "# TYPE test_gauge gauge")
+		t.Assert(lineOf(content, `test_gauge{name="a"}`), `test_gauge{name="a"} 7.5`)
+	})
+}
+
+func TestHistogram(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		histogram := gbmetric.NewHistogram("test_histogram", gbmetric.Option{
+			Buckets: []float64{1, 5},
+		})
+		histogram.Observe(ctx, 0.5)
+		histogram.Observe(ctx, 3)
+		histogram.Observe(ctx, 10)
+
+		buffer := bytes.NewBuffer(nil)
+		t.AssertNil(gbmetric.WritePrometheus(ctx, buffer))
+		content := buffer.String()
+		t.Assert(lineOf(content, "# TYPE test_histogram"), "# TYPE test_histogram histogram")
+		t.Assert(lineOf(content, `test_histogram_bucket{le="1"}`), `test_histogram_bucket{le="1"} 1`)
+		t.Assert(lineOf(content, `test_histogram_bucket{le="5"}`), `test_histogram_bucket{le="5"} 2`)
+		t.Assert(lineOf(content, `test_histogram_bucket{le="+Inf"}`), `test_histogram_bucket{le="+Inf"} 3`)
+		t.Assert(lineOf(content, `test_histogram_sum`), `test_histogram_sum 13.5`)
+		t.Assert(lineOf(content, `test_histogram_count`), `test_histogram_count 3`)
+	})
+}
+
+// lineOf returns the first line of `content` which has prefix `prefix`.
+func lineOf(content, prefix string) string {
+	for _, line := range bytes.Split([]byte(content), []byte("\n")) {
+		if bytes.HasPrefix(line, []byte(prefix)) {
+			return string(line)
+		}
+	}
+	return ""
+}
+
+func TestSetGlobal(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		counter, _ := otel.GetMeterProvider().Meter("test").Int64Counter("test_global_counter")
+		counter.Add(ctx, 1)
+		buffer := bytes.NewBuffer(nil)
+		t.AssertNil(gbmetric.WritePrometheus(ctx, buffer))
+		t.Assert(lineOf(buffer.String(), "test_global_counter_total "), "")
+
+		gbmetric.SetGlobal()
+		counter, _ = otel.GetMeterProvider().Meter("test").Int64Counter("test_global_counter")
+		counter.Add(ctx, 2)
+		buffer.Reset()
+		t.AssertNil(gbmetric.WritePrometheus(ctx, buffer))
+		t.Assert(lineOf(buffer.String(), "test_global_counter_total "), "test_global_counter_total 2")
+	})
+}
@@ -0,0 +1,23 @@
+// Package gbsession implements server-side session management with pluggable storages.
+package gbsession
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+)
+
+const (
+	sessionIdLength = 32 // Byte length of the random session id, which is 64 in hex string.
+)
+
+// NewSessionId creates and returns a new and unique session id string,
+// which is a 64 bytes hex string generated using cryptographically secure random.
+func NewSessionId() string {
+	b := make([]byte, sessionIdLength)
+	if _, err := rand.Read(b); err != nil {
+		panic(gberror.WrapCode(gbcode.CodeInternalError, err, `generate session id failed`))
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,67 @@
+package gbsession
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	DefaultTTL = 24 * time.Hour // DefaultTTL is the default expiration of session.
+)
+
+// Manager for sessions.
+type Manager struct {
+	ttl     time.Duration // TTL for sessions.
+	storage Storage       // Storage interface for session storage.
+}
+
+// New creates and returns a new session manager.
+// It uses StorageMemory if `storage` is not given.
+func New(ttl time.Duration, storage ...Storage) *Manager {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	m := &Manager{
+		ttl: ttl,
+	}
+	if len(storage) > 0 && storage[0] != nil {
+		m.storage = storage[0]
+	} else {
+		m.storage = NewStorageMemory()
+	}
+	return m
+}
+
+// New creates or fetches the session for given session id.
+// The session data is lazily loaded from storage when it is firstly used.
+// A new session id is created when data is set if `sessionId` is empty or the session does not exist.
+func (m *Manager) New(ctx context.Context, sessionId ...string) *Session {
+	s := &Session{
+		ctx:     ctx,
+		manager: m,
+	}
+	if len(sessionId) > 0 && sessionId[0] != "" {
+		s.id = sessionId[0]
+	}
+	return s
+}
+
+// SetStorage sets the session storage for manager.
+func (m *Manager) SetStorage(storage Storage) {
+	m.storage = storage
+}
+
+// GetStorage returns the session storage of current manager.
+func (m *Manager) GetStorage() Storage {
+	return m.storage
+}
+
+// SetTTL the TTL for the session manager.
+func (m *Manager) SetTTL(ttl time.Duration) {
+	m.ttl = ttl
+}
+
+// GetTTL returns the TTL of the session manager.
+func (m *Manager) GetTTL() time.Duration {
+	return m.ttl
+}
@@ -0,0 +1,233 @@
+package gbsession
+
+import (
+	"context"
+	gbvar "ghostbb.io/gb/container/gb_var"
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	"sync"
+)
+
+// Session struct for storing single session data, which is bound to a single request.
+// The Session object is not reusable across requests, and should be closed after request done.
+type Session struct {
+	mu      sync.RWMutex
+	id      string                 // Session id, which is empty if it is not created.
+	ctx     context.Context        // Context for storage operations.
+	data    map[string]interface{} // Current Session data, which is retrieved from Storage.
+	dirty   bool                   // Used to mark session is modified.
+	start   bool                   // Used to mark session is started and loaded from storage.
+	manager *Manager               // Parent session Manager.
+}
+
+// init does the lazy initialization for session, which loads the session data from storage.
+// The session id is dropped if the session does not exist in storage, which prevents session fixation.
+func (s *Session) init() error {
+	if s.start {
+		return nil
+	}
+	if s.id != "" {
+		data, err := s.manager.storage.Get(s.ctx, s.id)
+		if err != nil {
+			return gberror.Wrapf(err, `session restoring failed for id "%s"`, s.id)
+		}
+		if data == nil {
+			s.id = ""
+		}
+		s.data = data
+	}
+	if s.data == nil {
+		s.data = make(map[string]interface{})
+	}
+	s.start = true
+	return nil
+}
+
+// Id returns the session id of this session.
+// It creates a new session id if the session is not created yet.
+func (s *Session) Id() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.init(); err != nil {
+		return "", err
+	}
+	if s.id == "" {
+		s.id = NewSessionId()
+		s.dirty = true
+	}
+	return s.id, nil
+}
+
+// CurrentId returns the current session id without creating or loading the session.
+// It returns empty string if the session is not created or is removed.
+func (s *Session) CurrentId() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.id
+}
+
+// IsCreated checks and returns whether the session exists or is created in this request.
+func (s *Session) IsCreated() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.init(); err != nil {
+		return false, err
+	}
+	return s.id != "", nil
+}
+
+// Set sets key-value pair to this session.
+func (s *Session) Set(key string, value interface{}) error {
+	return s.SetMap(map[string]interface{}{key: value})
+}
+
+// SetMap batch sets the session using map.
+func (s *Session) SetMap(data map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.init(); err != nil {
+		return err
+	}
+	if s.id == "" {
+		s.id = NewSessionId()
+	}
+	for k, v := range data {
+		s.data[k] = v
+	}
+	s.dirty = true
+	return nil
+}
+
+// Get retrieves session value with given key.
+// It returns `def` if the key does not exist in the session if `def` is given,
+// or else it returns nil.
+func (s *Session) Get(key string, def ...interface{}) (*gbvar.Var, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.init(); err != nil {
+		return nil, err
+	}
+	if v, ok := s.data[key]; ok {
+		return gbvar.New(v), nil
+	}
+	if len(def) > 0 {
+		return gbvar.New(def[0]), nil
+	}
+	return nil, nil
+}
+
+// Data returns all data as map.
+func (s *Session) Data() (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.init(); err != nil {
+		return nil, err
+	}
+	data := make(map[string]interface{}, len(s.data))
+	for k, v := range s.data {
+		data[k] = v
+	}
+	return data, nil
+}
+
+// Contains checks whether key exists in session.
+func (s *Session) Contains(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.init(); err != nil {
+		return false, err
+	}
+	_, ok := s.data[key]
+	return ok, nil
+}
+
+// Size returns the size of the session.
+func (s *Session) Size() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.init(); err != nil {
+		return 0, err
+	}
+	return len(s.data), nil
+}
+
+// Remove removes key along with its value from this session.
+func (s *Session) Remove(keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.init(); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		delete(s.data, key)
+	}
+	s.dirty = true
+	return nil
+}
+
+// RemoveAll deletes the session from storage, which is usually used for logout.
+func (s *Session) RemoveAll() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.init(); err != nil {
+		return err
+	}
+	if s.id != "" {
+		if err := s.manager.storage.Remove(s.ctx, s.id); err != nil {
+			return err
+		}
+	}
+	s.id = ""
+	s.data = make(map[string]interface{})
+	s.dirty = false
+	return nil
+}
+
+// RegenerateId regenerates a new session id for current session, keeping the session data.
+// It is usually used after login to prevent session fixation attack.
+// The old session is deleted from storage if `deleteOld` is true.
+func (s *Session) RegenerateId(deleteOld bool) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.init(); err != nil {
+		return "", err
+	}
+	oldId := s.id
+	s.id = NewSessionId()
+	s.dirty = true
+	if deleteOld && oldId != "" {
+		if err := s.manager.storage.Remove(s.ctx, oldId); err != nil {
+			return "", err
+		}
+	}
+	return s.id, nil
+}
+
+// IsDirty checks whether there's any data change in the session.
+func (s *Session) IsDirty() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.dirty
+}
+
+// Close closes current session and saves its data to storage if it is modified,
+// or else renews its expiration in storage, even if the session is not used in this request.
+// It does nothing if the session is not created.
+func (s *Session) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.id == "" {
+		return nil
+	}
+	if s.manager.storage == nil {
+		return gberror.NewCode(gbcode.CodeMissingConfiguration, `session storage is not configured`)
+	}
+	if s.dirty {
+		if err := s.manager.storage.Set(s.ctx, s.id, s.data, s.manager.ttl); err != nil {
+			return err
+		}
+		s.dirty = false
+		return nil
+	}
+	return s.manager.storage.UpdateTTL(s.ctx, s.id, s.manager.ttl)
+}
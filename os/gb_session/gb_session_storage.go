@@ -0,0 +1,22 @@
+package gbsession
+
+import (
+	"context"
+	"time"
+)
+
+// Storage is the interface definition for session storage.
+type Storage interface {
+	// Get retrieves and returns the session data of `sessionId`.
+	// It returns nil data and nil error if the session does not exist or is expired.
+	Get(ctx context.Context, sessionId string) (data map[string]interface{}, err error)
+
+	// Set saves the session data of `sessionId` with expiration `ttl`.
+	Set(ctx context.Context, sessionId string, data map[string]interface{}, ttl time.Duration) error
+
+	// UpdateTTL renews the expiration of session `sessionId` to `ttl` without changing its data.
+	UpdateTTL(ctx context.Context, sessionId string, ttl time.Duration) error
+
+	// Remove deletes the session `sessionId` from storage.
+	Remove(ctx context.Context, sessionId string) error
+}
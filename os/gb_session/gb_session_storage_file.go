@@ -0,0 +1,131 @@
+package gbsession
+
+import (
+	"context"
+	gbcode "ghostbb.io/gb/errors/gb_code"
+	gberror "ghostbb.io/gb/errors/gb_error"
+	"ghostbb.io/gb/internal/intlog"
+	"ghostbb.io/gb/internal/json"
+	gbfile "ghostbb.io/gb/os/gb_file"
+	gbtimer "ghostbb.io/gb/os/gb_timer"
+	"os"
+	"time"
+)
+
+const (
+	// DefaultStorageFileCleanupInterval is the interval for cleaning up expired session files.
+	DefaultStorageFileCleanupInterval = time.Hour
+
+	defaultStorageFileDirName = "gbsessions" // Default directory name under system temporary directory.
+	fileStorageExt            = ".session"   // File extension of session file.
+)
+
+// StorageFile implements the session storage interface with files,
+// which stores each session as a JSON file named by its session id.
+// The expiration time of a session is saved as the modification time of its file.
+type StorageFile struct {
+	path string // Directory for storing session files.
+}
+
+// NewStorageFile creates and returns a file storage object for session.
+// The optional parameter `path` specifies the directory for session files,
+// which is "gbsessions" under system temporary directory in default.
+func NewStorageFile(path ...string) *StorageFile {
+	storagePath := gbfile.Temp(defaultStorageFileDirName)
+	if len(path) > 0 && path[0] != "" {
+		storagePath = path[0]
+	}
+	if !gbfile.Exists(storagePath) {
+		if err := gbfile.Mkdir(storagePath); err != nil {
+			panic(gberror.WrapCodef(gbcode.CodeInternalError, err, `Mkdir "%s" failed`, storagePath))
+		}
+	}
+	s := &StorageFile{
+		path: storagePath,
+	}
+	gbtimer.AddSingleton(context.Background(), DefaultStorageFileCleanupInterval, s.cleanup)
+	return s
+}
+
+// Get retrieves and returns the session data of `sessionId`.
+func (s *StorageFile) Get(ctx context.Context, sessionId string) (map[string]interface{}, error) {
+	path, err := s.sessionFilePath(sessionId)
+	if err != nil || !gbfile.Exists(path) {
+		return nil, err
+	}
+	if gbfile.MTime(path).Before(time.Now()) {
+		return nil, gbfile.Remove(path)
+	}
+	content := gbfile.GetBytes(path)
+	if len(content) == 0 {
+		return nil, nil
+	}
+	var data map[string]interface{}
+	if err = json.UnmarshalUseNumber(content, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Set saves the session data of `sessionId` with expiration `ttl`.
+func (s *StorageFile) Set(ctx context.Context, sessionId string, data map[string]interface{}, ttl time.Duration) error {
+	path, err := s.sessionFilePath(sessionId)
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if err = gbfile.PutBytes(path, content); err != nil {
+		return err
+	}
+	return s.UpdateTTL(ctx, sessionId, ttl)
+}
+
+// UpdateTTL renews the expiration of session `sessionId` to `ttl` without changing its data.
+func (s *StorageFile) UpdateTTL(ctx context.Context, sessionId string, ttl time.Duration) error {
+	path, err := s.sessionFilePath(sessionId)
+	if err != nil || !gbfile.Exists(path) {
+		return err
+	}
+	expireAt := time.Now().Add(ttl)
+	return os.Chtimes(path, expireAt, expireAt)
+}
+
+// Remove deletes the session `sessionId` from storage.
+func (s *StorageFile) Remove(ctx context.Context, sessionId string) error {
+	path, err := s.sessionFilePath(sessionId)
+	if err != nil {
+		return err
+	}
+	return gbfile.Remove(path)
+}
+
+// sessionFilePath returns the storage file path for given session id.
+// It checks the session id to avoid path traversal.
+func (s *StorageFile) sessionFilePath(sessionId string) (string, error) {
+	for _, r := range sessionId {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return "", gberror.NewCodef(gbcode.CodeInvalidParameter, `invalid session id "%s"`, sessionId)
+		}
+	}
+	return gbfile.Join(s.path, sessionId+fileStorageExt), nil
+}
+
+// cleanup removes the expired session files.
+func (s *StorageFile) cleanup(ctx context.Context) {
+	files, err := gbfile.ScanDirFile(s.path, "*"+fileStorageExt)
+	if err != nil {
+		intlog.Errorf(ctx, `%+v`, err)
+		return
+	}
+	now := time.Now()
+	for _, file := range files {
+		if gbfile.MTime(file).Before(now) {
+			if err = gbfile.Remove(file); err != nil {
+				intlog.Errorf(ctx, `%+v`, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package gbsession
+
+import (
+	"context"
+	gbcache "ghostbb.io/gb/os/gb_cache"
+	"time"
+)
+
+// StorageMemory implements the session storage interface with memory,
+// which is based on gbcache memory adapter.
+// Note that the sessions are lost when the process restarts.
+type StorageMemory struct {
+	cache *gbcache.Cache // Cache for storing session data, which expires automatically.
+}
+
+// NewStorageMemory creates and returns a memory storage object for session.
+func NewStorageMemory() *StorageMemory {
+	return &StorageMemory{
+		cache: gbcache.New(),
+	}
+}
+
+// Get retrieves and returns the session data of `sessionId`.
+func (s *StorageMemory) Get(ctx context.Context, sessionId string) (map[string]interface{}, error) {
+	v, err := s.cache.Get(ctx, sessionId)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	data, ok := v.Val().(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	return copyData(data), nil
+}
+
+// Set saves the session data of `sessionId` with expiration `ttl`.
+func (s *StorageMemory) Set(ctx context.Context, sessionId string, data map[string]interface{}, ttl time.Duration) error {
+	return s.cache.Set(ctx, sessionId, copyData(data), ttl)
+}
+
+// UpdateTTL renews the expiration of session `sessionId` to `ttl` without changing its data.
+func (s *StorageMemory) UpdateTTL(ctx context.Context, sessionId string, ttl time.Duration) error {
+	_, err := s.cache.UpdateExpire(ctx, sessionId, ttl)
+	return err
+}
+
+// Remove deletes the session `sessionId` from storage.
+func (s *StorageMemory) Remove(ctx context.Context, sessionId string) error {
+	_, err := s.cache.Remove(ctx, sessionId)
+	return err
+}
+
+// copyData returns a shallow copy of session data, which avoids concurrent modification of stored data.
+func copyData(data map[string]interface{}) map[string]interface{} {
+	m := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		m[k] = v
+	}
+	return m
+}
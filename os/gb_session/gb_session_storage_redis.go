@@ -0,0 +1,79 @@
+package gbsession
+
+import (
+	"context"
+	gbredis "ghostbb.io/gb/database/gb_redis"
+	"ghostbb.io/gb/internal/json"
+	"time"
+)
+
+const (
+	defaultStorageRedisPrefix = "gb:session:" // Default key prefix of redis session storage.
+)
+
+// StorageRedis implements the session storage interface with redis,
+// which stores the session data as JSON string.
+type StorageRedis struct {
+	redis  *gbredis.Redis // Redis client for session storage.
+	prefix string         // Redis key prefix for session id.
+}
+
+// NewStorageRedis creates and returns a redis storage object for session.
+// The optional parameter `prefix` specifies the key prefix, which is "gb:session:" in default.
+func NewStorageRedis(redis *gbredis.Redis, prefix ...string) *StorageRedis {
+	s := &StorageRedis{
+		redis:  redis,
+		prefix: defaultStorageRedisPrefix,
+	}
+	if len(prefix) > 0 && prefix[0] != "" {
+		s.prefix = prefix[0]
+	}
+	return s
+}
+
+// Get retrieves and returns the session data of `sessionId`.
+func (s *StorageRedis) Get(ctx context.Context, sessionId string) (map[string]interface{}, error) {
+	v, err := s.redis.Get(ctx, s.key(sessionId))
+	if err != nil || v.IsNil() {
+		return nil, err
+	}
+	var data map[string]interface{}
+	if err = json.UnmarshalUseNumber(v.Bytes(), &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Set saves the session data of `sessionId` with expiration `ttl`.
+func (s *StorageRedis) Set(ctx context.Context, sessionId string, data map[string]interface{}, ttl time.Duration) error {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return s.redis.SetEX(ctx, s.key(sessionId), content, ttlSeconds(ttl))
+}
+
+// UpdateTTL renews the expiration of session `sessionId` to `ttl` without changing its data.
+func (s *StorageRedis) UpdateTTL(ctx context.Context, sessionId string, ttl time.Duration) error {
+	_, err := s.redis.Expire(ctx, s.key(sessionId), ttlSeconds(ttl))
+	return err
+}
+
+// Remove deletes the session `sessionId` from storage.
+func (s *StorageRedis) Remove(ctx context.Context, sessionId string) error {
+	_, err := s.redis.Del(ctx, s.key(sessionId))
+	return err
+}
+
+// key returns the redis key of `sessionId`.
+func (s *StorageRedis) key(sessionId string) string {
+	return s.prefix + sessionId
+}
+
+// ttlSeconds returns the seconds of `ttl`, which is at least 1.
+func ttlSeconds(ttl time.Duration) int64 {
+	if seconds := int64(ttl.Seconds()); seconds > 0 {
+		return seconds
+	}
+	return 1
+}
@@ -0,0 +1,113 @@
+package gbsession_test
+
+import (
+	"context"
+	gbfile "ghostbb.io/gb/os/gb_file"
+	gbsession "ghostbb.io/gb/os/gb_session"
+	gbtest "ghostbb.io/gb/test/gb_test"
+	"testing"
+	"time"
+)
+
+var (
+	ctx = context.Background()
+)
+
+func testStorage(t *gbtest.T, storage gbsession.Storage) {
+	var (
+		manager = gbsession.New(time.Second, storage)
+		s1      = manager.New(ctx)
+	)
+	t.AssertNil(s1.Set("k1", "v1"))
+	t.AssertNil(s1.SetMap(map[string]interface{}{"k2": 2}))
+	id, err := s1.Id()
+	t.AssertNil(err)
+	t.Assert(len(id), 64)
+	t.Assert(s1.IsDirty(), true)
+	t.AssertNil(s1.Close())
+
+	// Restore.
+	s2 := manager.New(ctx, id)
+	v, err := s2.Get("k1")
+	t.AssertNil(err)
+	t.Assert(v.String(), "v1")
+	v, err = s2.Get("k2")
+	t.AssertNil(err)
+	t.Assert(v.Int(), 2)
+	v, err = s2.Get("k3", "def")
+	t.AssertNil(err)
+	t.Assert(v.String(), "def")
+	size, err := s2.Size()
+	t.AssertNil(err)
+	t.Assert(size, 2)
+	t.AssertNil(s2.Remove("k2"))
+	t.AssertNil(s2.Close())
+
+	// Regenerate id.
+	s3 := manager.New(ctx, id)
+	newId, err := s3.RegenerateId(true)
+	t.AssertNil(err)
+	t.AssertNE(newId, id)
+	t.AssertNil(s3.Close())
+	created, err := manager.New(ctx, id).IsCreated()
+	t.AssertNil(err)
+	t.Assert(created, false)
+
+	s4 := manager.New(ctx, newId)
+	data, err := s4.Data()
+	t.AssertNil(err)
+	t.Assert(data, map[string]interface{}{"k1": "v1"})
+	t.AssertNil(s4.Close())
+
+	// TTL renewal.
+	time.Sleep(600 * time.Millisecond)
+	t.AssertNil(manager.New(ctx, newId).Close())
+	time.Sleep(600 * time.Millisecond)
+	created, err = manager.New(ctx, newId).IsCreated()
+	t.AssertNil(err)
+	t.Assert(created, true)
+
+	// Expiration.
+	time.Sleep(1100 * time.Millisecond)
+	s5 := manager.New(ctx, newId)
+	created, err = s5.IsCreated()
+	t.AssertNil(err)
+	t.Assert(created, false)
+	v, err = s5.Get("k1")
+	t.AssertNil(err)
+	t.AssertNil(v)
+
+	// Remove all.
+	s6 := manager.New(ctx)
+	t.AssertNil(s6.Set("k", "v"))
+	t.AssertNil(s6.Close())
+	id, _ = s6.Id()
+	t.AssertNil(manager.New(ctx, id).RemoveAll())
+	created, err = manager.New(ctx, id).IsCreated()
+	t.AssertNil(err)
+	t.Assert(created, false)
+}
+
+func TestStorageMemory(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		testStorage(t, gbsession.NewStorageMemory())
+	})
+}
+
+func TestStorageFile(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		path := gbfile.Temp("gbsession_test")
+		defer gbfile.Remove(path)
+		testStorage(t, gbsession.NewStorageFile(path))
+	})
+}
+
+func TestStorageFile_InvalidId(t *testing.T) {
+	gbtest.C(t, func(t *gbtest.T) {
+		path := gbfile.Temp("gbsession_test_invalid")
+		defer gbfile.Remove(path)
+		s := gbsession.New(time.Minute, gbsession.NewStorageFile(path)).New(ctx, "../passwd")
+		_, err := s.Get("k")
+		t.AssertNE(err, nil)
+	})
+}